@@ -1,6 +1,9 @@
 package action
 
+import "sync"
+
 var (
+	lock   sync.Mutex
 	before Action
 	after  Action
 	args   []interface{}
@@ -13,55 +16,86 @@ type Action interface {
 
 // SetBefore defines which action will be executed before an Hydroform operation.
 func SetBefore(a Action) {
+	lock.Lock()
+	defer lock.Unlock()
 	before = a
 }
 
 // Before runs the action set with SetBefore. It is called and evaluated before each Hydroform operation (Provision, Status, Credentials and Deprovision)
 // After running, the set action is cleared.
 func Before() error {
-	// clear the action after running it
-	defer func() {
-		before = nil
-	}()
+	lock.Lock()
+	// clear the action before running it, so it runs only once
+	a, actionArgs := before, args
+	before = nil
+	lock.Unlock()
 
-	if before != nil {
-		_, err := before.Run(args...)
-		return err
-	}
-	return nil
+	return Hooks{Before: a, Args: actionArgs}.RunBefore()
 }
 
 // SetAfter defines which action will be executed after an Hydroform operation.
 func SetAfter(a Action) {
+	lock.Lock()
+	defer lock.Unlock()
 	after = a
 }
 
 // After runs the action set with SetAfter. It is called and evaluated after each Hydroform operation if there are no errors (Provision, Status, Credentials and Deprovision)
 // After running, the set action is cleared.
 func After() error {
-	// clear the action after running it
-	defer func() {
-		after = nil
-	}()
+	lock.Lock()
+	// clear the action before running it, so it runs only once
+	a, actionArgs := after, args
+	after = nil
+	lock.Unlock()
 
-	if after != nil {
-		_, err := after.Run(args...)
-		return err
-	}
-	return nil
+	return Hooks{After: a, Args: actionArgs}.RunAfter()
 }
 
 // SetArgs allows to define arbitrary arguments that Before and After actions will consume.
 // Calling SetArgs a second time clears the args from the previous call.
 func SetArgs(a ...interface{}) {
+	lock.Lock()
+	defer lock.Unlock()
 	args = a
 }
 
 // Args returns the defined arguments for the actions
 func Args() []interface{} {
+	lock.Lock()
+	defer lock.Unlock()
 	return args
 }
 
+// Hooks groups the actions run before and after a Hydroform operation together with the arguments they consume.
+// Unlike the package-level actions, Hooks are not cleared after running, so they can be reused for every operation of a Hydroform client.
+type Hooks struct {
+	// Before is run before each operation.
+	Before Action
+	// After is run after each operation that finished without errors.
+	After Action
+	// Args are passed to the Before and After actions.
+	Args []interface{}
+}
+
+// RunBefore runs the Before action, if set.
+func (h Hooks) RunBefore() error {
+	if h.Before != nil {
+		_, err := h.Before.Run(h.Args...)
+		return err
+	}
+	return nil
+}
+
+// RunAfter runs the After action, if set.
+func (h Hooks) RunAfter() error {
+	if h.After != nil {
+		_, err := h.After.Run(h.Args...)
+		return err
+	}
+	return nil
+}
+
 // FuncAction allows to use a pure function as an Action. By creating a function with this signature it cn be directly used as action.
 // See examples on the unit tests.
 type FuncAction func(args ...interface{}) (interface{}, error)
@@ -131,3 +131,29 @@ func TestAfter(t *testing.T) {
 	// check that actions are cleared after running
 	require.Nil(t, after)
 }
+
+func TestHooks(t *testing.T) {
+	// Empty hooks do nothing
+	require.NoError(t, Hooks{}.RunBefore())
+	require.NoError(t, Hooks{}.RunAfter())
+
+	var received []interface{}
+	record := FuncAction(func(args ...interface{}) (interface{}, error) {
+		received = append(received, args...)
+		return nil, nil
+	})
+	h := Hooks{
+		Before: record,
+		After: FuncAction(func(args ...interface{}) (interface{}, error) {
+			return nil, errors.New("This action always fails")
+		}),
+		Args: []interface{}{"arg1"},
+	}
+
+	// check that hooks are not cleared after running
+	require.NoError(t, h.RunBefore())
+	require.NoError(t, h.RunBefore())
+	require.Equal(t, []interface{}{"arg1", "arg1"}, received)
+	// Check that errors are forwarded
+	require.Error(t, h.RunAfter())
+}
@@ -0,0 +1,259 @@
+package hydroform
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kyma-incubator/hydroform/action"
+	"github.com/kyma-incubator/hydroform/types"
+)
+
+// defaultConcurrency is the number of clusters a batch works on at the same time, unless set with the Concurrency option.
+const defaultConcurrency = 5
+
+// ClusterRequest is a cluster to work on in a batch, together with its provider.
+type ClusterRequest struct {
+	Cluster  *types.Cluster
+	Provider *types.Provider
+}
+
+// BatchOption configures ProvisionAll and DeprovisionAll.
+type BatchOption func(o *batchOptions)
+
+type batchOptions struct {
+	concurrency int
+	failFast    bool
+}
+
+// Concurrency sets how many clusters of a batch are worked on at the same time. It defaults to 5. Values lower than 1 are treated as 1.
+func Concurrency(n int) BatchOption {
+	return func(o *batchOptions) {
+		o.concurrency = n
+	}
+}
+
+// FailFast stops a batch from starting operations on further clusters once an operation failed. The operations already running are completed, and the clusters that were not started are reported as skipped.
+func FailFast() BatchOption {
+	return func(o *batchOptions) {
+		o.failFast = true
+	}
+}
+
+// ClusterResult is the outcome of the operation on one cluster of a batch.
+type ClusterResult struct {
+	// Request is the request the result belongs to.
+	Request ClusterRequest
+	// Cluster is the cluster returned by the operation. After a failed provisioning, it still holds the state reached so far, if any.
+	Cluster *types.Cluster
+	// Err is the error of the operation, if it failed.
+	Err error
+	// Skipped is true if the operation was never started, because the batch failed fast or its context was cancelled.
+	Skipped bool
+	// Duration is how long the operation took.
+	Duration time.Duration
+}
+
+// BatchReport reports the outcome of a batch. The results are in the order of the requests.
+type BatchReport struct {
+	// Operation is the operation run by the batch, for example `provision`.
+	Operation string
+	Results   []ClusterResult
+	// Duration is how long the whole batch took.
+	Duration time.Duration
+}
+
+// Succeeded returns the results of the operations that finished without errors.
+func (r *BatchReport) Succeeded() []ClusterResult {
+	return r.filter(func(result ClusterResult) bool { return !result.Skipped && result.Err == nil })
+}
+
+// Failed returns the results of the operations that failed.
+func (r *BatchReport) Failed() []ClusterResult {
+	return r.filter(func(result ClusterResult) bool { return result.Err != nil })
+}
+
+// Skipped returns the results of the operations that were never started.
+func (r *BatchReport) Skipped() []ClusterResult {
+	return r.filter(func(result ClusterResult) bool { return result.Skipped })
+}
+
+// Err returns a *BatchError if any operation of the batch failed or was skipped, and nil otherwise.
+func (r *BatchReport) Err() error {
+	failed, skipped := r.Failed(), r.Skipped()
+	if len(failed) == 0 && len(skipped) == 0 {
+		return nil
+	}
+	return &BatchError{
+		Operation: r.Operation,
+		Total:     len(r.Results),
+		Failed:    failed,
+		Skipped:   len(skipped),
+	}
+}
+
+func (r *BatchReport) String() string {
+	return fmt.Sprintf("%s of %d clusters: %d succeeded, %d failed, %d skipped in %s",
+		r.Operation, len(r.Results), len(r.Succeeded()), len(r.Failed()), len(r.Skipped()), r.Duration)
+}
+
+func (r *BatchReport) filter(f func(result ClusterResult) bool) []ClusterResult {
+	var results []ClusterResult
+	for _, result := range r.Results {
+		if f(result) {
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+// BatchError is returned by ProvisionAll and DeprovisionAll if the operation failed or was skipped for some of the clusters.
+type BatchError struct {
+	// Operation is the operation run by the batch.
+	Operation string
+	// Total is the number of clusters in the batch.
+	Total int
+	// Failed holds the results of the failed operations.
+	Failed []ClusterResult
+	// Skipped is the number of clusters the operation was never started for.
+	Skipped int
+}
+
+func (e *BatchError) Error() string {
+	failures := make([]string, 0, len(e.Failed))
+	for _, result := range e.Failed {
+		failures = append(failures, fmt.Sprintf("\n - cluster %q on %s: %s", result.Request.Cluster.Name, result.Request.Provider.Type, result.Err))
+	}
+	return fmt.Sprintf("%s failed for %d and was skipped for %d of %d clusters%s", e.Operation, len(e.Failed), e.Skipped, e.Total, strings.Join(failures, ""))
+}
+
+// ProvisionAll provisions the clusters of all requests, several of them at the same time. It returns a report with the result for every cluster, and a *BatchError if any of them was not provisioned.
+// The package-level actions set in the action package run once for the whole batch, not for each cluster.
+func ProvisionAll(requests []ClusterRequest, opts ...BatchOption) (*BatchReport, error) {
+	return ProvisionAllContext(context.Background(), requests, opts...)
+}
+
+// ProvisionAllContext works like ProvisionAll. Cancelling the context stops the ongoing provisionings and skips the clusters not started yet.
+func ProvisionAllContext(ctx context.Context, requests []ClusterRequest, opts ...BatchOption) (*BatchReport, error) {
+	return runDefaultBatch(func(c *Client) (*BatchReport, error) {
+		return c.ProvisionAll(ctx, requests, opts...)
+	})
+}
+
+// DeprovisionAll deprovisions the clusters of all requests, several of them at the same time. It returns a report with the result for every cluster, and a *BatchError if any of them was not deprovisioned.
+// The package-level actions set in the action package run once for the whole batch, not for each cluster.
+func DeprovisionAll(requests []ClusterRequest, opts ...BatchOption) (*BatchReport, error) {
+	return DeprovisionAllContext(context.Background(), requests, opts...)
+}
+
+// DeprovisionAllContext works like DeprovisionAll. Cancelling the context stops the ongoing deprovisionings and skips the clusters not started yet.
+func DeprovisionAllContext(ctx context.Context, requests []ClusterRequest, opts ...BatchOption) (*BatchReport, error) {
+	return runDefaultBatch(func(c *Client) (*BatchReport, error) {
+		return c.DeprovisionAll(ctx, requests, opts...)
+	})
+}
+
+// runDefaultBatch runs a batch of the package-level functions. The package-level actions are cleared after running once, so running them around each cluster would only run them for whichever cluster comes first. Instead, the clusters are worked on by a copy of the default client without actions, and the actions run around the whole batch.
+func runDefaultBatch(f func(c *Client) (*BatchReport, error)) (*BatchReport, error) {
+	if err := action.Before(); err != nil {
+		return nil, err
+	}
+
+	c := *defaultClient
+	c.hooks = &action.Hooks{}
+	report, err := f(&c)
+	if err != nil {
+		return report, err
+	}
+	return report, action.After()
+}
+
+// ProvisionAll provisions the clusters of all requests, several of them at the same time. See the package-level ProvisionAll function for details.
+// The actions of the client run around the provisioning of each cluster.
+func (c *Client) ProvisionAll(ctx context.Context, requests []ClusterRequest, opts ...BatchOption) (*BatchReport, error) {
+	return c.runBatch(ctx, "provision", requests, opts, func(r ClusterRequest) (*types.Cluster, error) {
+		return c.Provision(ctx, r.Cluster, r.Provider)
+	})
+}
+
+// DeprovisionAll deprovisions the clusters of all requests, several of them at the same time. See the package-level DeprovisionAll function for details.
+// The actions of the client run around the deprovisioning of each cluster.
+func (c *Client) DeprovisionAll(ctx context.Context, requests []ClusterRequest, opts ...BatchOption) (*BatchReport, error) {
+	return c.runBatch(ctx, "deprovision", requests, opts, func(r ClusterRequest) (*types.Cluster, error) {
+		return r.Cluster, c.Deprovision(ctx, r.Cluster, r.Provider)
+	})
+}
+
+// runBatch runs the operation for every request with a pool of workers, and collects the results in the order of the requests.
+func (c *Client) runBatch(ctx context.Context, operation string, requests []ClusterRequest, opts []BatchOption, f func(r ClusterRequest) (*types.Cluster, error)) (*BatchReport, error) {
+	options := &batchOptions{concurrency: defaultConcurrency}
+	for _, opt := range opts {
+		opt(options)
+	}
+	if options.concurrency < 1 {
+		options.concurrency = 1
+	}
+
+	report := &BatchReport{
+		Operation: operation,
+		Results:   make([]ClusterResult, len(requests)),
+	}
+	for i, r := range requests {
+		report.Results[i] = ClusterResult{Request: r, Skipped: true}
+	}
+
+	// stop is closed once the batch must not start any further operations
+	stop := make(chan struct{})
+	var stopOnce sync.Once
+	stopBatch := func() { stopOnce.Do(func() { close(stop) }) }
+
+	start := time.Now()
+	jobs := make(chan int)
+	wg := sync.WaitGroup{}
+	for w := 0; w < options.concurrency && w < len(requests); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				result := &report.Results[i]
+				started := time.Now()
+				result.Cluster, result.Err = f(result.Request)
+				result.Duration = time.Since(started)
+				result.Skipped = false
+
+				if result.Err != nil && options.failFast {
+					c.logger.Printf("hydroform: %s of cluster %q on %s failed, no further clusters are started", operation, result.Request.Cluster.Name, result.Request.Provider.Type)
+					stopBatch()
+				}
+			}
+		}()
+	}
+
+feed:
+	for i := range requests {
+		// checked first, as select picks randomly among the ready cases
+		select {
+		case <-stop:
+			break feed
+		case <-ctx.Done():
+			break feed
+		default:
+		}
+
+		select {
+		case jobs <- i:
+		case <-stop:
+			break feed
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	report.Duration = time.Since(start)
+	c.logger.Printf("hydroform: %s", report)
+	return report, report.Err()
+}
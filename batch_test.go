@@ -0,0 +1,160 @@
+package hydroform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kyma-incubator/hydroform/action"
+	"github.com/kyma-incubator/hydroform/types"
+	"github.com/stretchr/testify/require"
+)
+
+// batchProvisioner fails for clusters whose name starts with "broken", and records how many operations ran at the same time.
+type batchProvisioner struct {
+	fakeProvisioner
+	lock       sync.Mutex
+	running    int
+	maxRunning int
+}
+
+func (b *batchProvisioner) Provision(ctx context.Context, cluster *types.Cluster, provider *types.Provider) (*types.Cluster, error) {
+	return cluster, b.work(cluster)
+}
+
+func (b *batchProvisioner) Deprovision(ctx context.Context, cluster *types.Cluster, provider *types.Provider) error {
+	return b.work(cluster)
+}
+
+func (b *batchProvisioner) work(cluster *types.Cluster) error {
+	b.lock.Lock()
+	b.running++
+	if b.running > b.maxRunning {
+		b.maxRunning = b.running
+	}
+	b.lock.Unlock()
+
+	time.Sleep(5 * time.Millisecond)
+
+	b.lock.Lock()
+	b.running--
+	b.lock.Unlock()
+
+	if strings.HasPrefix(cluster.Name, "broken") {
+		return errors.New("quota exceeded")
+	}
+	return nil
+}
+
+func newBatchRequests(names ...string) []ClusterRequest {
+	requests := make([]ClusterRequest, 0, len(names))
+	for _, name := range names {
+		requests = append(requests, ClusterRequest{
+			Cluster:  &types.Cluster{Name: name},
+			Provider: &types.Provider{Type: fakeProvider},
+		})
+	}
+	return requests
+}
+
+func TestProvisionAll(t *testing.T) {
+	p := &batchProvisioner{}
+	var hooks int
+	c := New(
+		WithProvider(fakeProvider, func(OperatorType) Provisioner { return p }),
+		WithBefore(action.FuncAction(func(args ...interface{}) (interface{}, error) {
+			p.lock.Lock()
+			defer p.lock.Unlock()
+			hooks++
+			return nil, nil
+		})),
+	)
+
+	var names []string
+	for i := 0; i < 10; i++ {
+		names = append(names, fmt.Sprintf("cluster-%d", i))
+	}
+	requests := newBatchRequests(names...)
+
+	report, err := c.ProvisionAll(context.Background(), requests, Concurrency(3))
+	require.NoError(t, err)
+	require.Equal(t, "provision", report.Operation)
+	require.Len(t, report.Succeeded(), 10)
+	require.True(t, p.maxRunning > 0 && p.maxRunning <= 3, "The batch should not run more operations at the same time than allowed, ran %d", p.maxRunning)
+	require.Equal(t, 10, hooks, "The actions of the client should run for every cluster")
+	for i, result := range report.Results {
+		require.Equal(t, requests[i], result.Request, "The results should be in the order of the requests")
+		require.Equal(t, requests[i].Cluster, result.Cluster)
+	}
+}
+
+func TestProvisionAllFailures(t *testing.T) {
+	p := &batchProvisioner{}
+	c := New(WithProvider(fakeProvider, func(OperatorType) Provisioner { return p }))
+	requests := newBatchRequests("cluster-1", "broken-1", "cluster-2", "broken-2")
+
+	report, err := c.ProvisionAll(context.Background(), requests)
+	var batchErr *BatchError
+	require.True(t, errors.As(err, &batchErr), "Failures should be reported with a BatchError")
+	require.Len(t, batchErr.Failed, 2)
+	require.Equal(t, 4, batchErr.Total)
+	require.Contains(t, err.Error(), `cluster "broken-1" on fake: quota exceeded`)
+
+	require.Len(t, report.Succeeded(), 2, "A failure should not stop the other clusters")
+	require.Len(t, report.Failed(), 2)
+	require.Empty(t, report.Skipped())
+	require.Equal(t, "broken-1", report.Failed()[0].Request.Cluster.Name)
+}
+
+func TestDeprovisionAllFailFast(t *testing.T) {
+	p := &batchProvisioner{}
+	c := New(WithProvider(fakeProvider, func(OperatorType) Provisioner { return p }))
+	requests := newBatchRequests("broken-1", "cluster-1", "cluster-2", "cluster-3")
+
+	report, err := c.DeprovisionAll(context.Background(), requests, Concurrency(1), FailFast())
+	require.Error(t, err)
+	require.Equal(t, "deprovision", report.Operation)
+	require.Len(t, report.Failed(), 1)
+	require.Len(t, report.Skipped(), 3, "No cluster should be started after a failure")
+	require.Equal(t, 3, err.(*BatchError).Skipped)
+}
+
+func TestProvisionAllCancelled(t *testing.T) {
+	c := New(WithProvider(fakeProvider, func(OperatorType) Provisioner { return &batchProvisioner{} }))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	report, err := c.ProvisionAll(ctx, newBatchRequests("cluster-1", "cluster-2"))
+	require.Error(t, err)
+	require.Len(t, report.Skipped(), 2)
+}
+
+func TestProvisionAllPackageActions(t *testing.T) {
+	p := &batchProvisioner{}
+	RegisterProvider(fakeProvider, func(OperatorType) Provisioner { return p })
+	defer func() {
+		defaultRegistry.lock.Lock()
+		delete(defaultRegistry.factories, fakeProvider)
+		defaultRegistry.lock.Unlock()
+	}()
+
+	var before, after int
+	action.SetBefore(action.FuncAction(func(args ...interface{}) (interface{}, error) {
+		before++
+		return nil, nil
+	}))
+	action.SetAfter(action.FuncAction(func(args ...interface{}) (interface{}, error) {
+		after++
+		return nil, nil
+	}))
+
+	report, err := ProvisionAll(newBatchRequests("cluster-1", "cluster-2", "cluster-3"))
+	require.NoError(t, err)
+	require.Len(t, report.Succeeded(), 3)
+	require.Equal(t, 1, before, "The package-level before action should run once for the whole batch")
+	require.Equal(t, 1, after, "The package-level after action should run once for the whole batch")
+}
@@ -0,0 +1,110 @@
+// Package catalog lists the machine types a provider offers, caches them, and checks the machine types of cluster specifications against them.
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	"github.com/kyma-incubator/hydroform/types"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// Catalog lists the machine types a provider offers in a location.
+type Catalog interface {
+	MachineTypes(ctx context.Context, provider *types.Provider, location string) ([]types.MachineType, error)
+}
+
+// Static is a catalog read from a file, which maps the locations to the machine types offered there. It never calls the provider, so it can be used in tests and offline.
+type Static map[string][]types.MachineType
+
+// LoadFile reads a static catalog from a JSON file, for example `{"europe-west3": [{"name": "n1-standard-4", "cpus": 4, "memoryMB": 15360}]}`.
+func LoadFile(path string) (Static, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read the machine type catalog")
+	}
+	static := Static{}
+	if err := json.Unmarshal(data, &static); err != nil {
+		return nil, errors.Wrapf(err, "unable to decode the machine type catalog %s", path)
+	}
+	return static, nil
+}
+
+// MachineTypes returns the machine types of the location. It fails for locations the catalog does not know.
+func (s Static) MachineTypes(ctx context.Context, provider *types.Provider, location string) ([]types.MachineType, error) {
+	machineTypes, ok := s[location]
+	if !ok {
+		return nil, errors.Errorf("the machine type catalog has no location %s", location)
+	}
+	return machineTypes, nil
+}
+
+// Cache keeps the machine types listed by a provider for a while, as they rarely change. It is safe for concurrent use.
+type Cache struct {
+	ttl     time.Duration
+	lock    sync.Mutex
+	entries map[string]entry
+	// now returns the current time. It is replaced in tests.
+	now func() time.Time
+}
+
+type entry struct {
+	machineTypes []types.MachineType
+	expires      time.Time
+}
+
+// NewCache creates a cache which keeps the machine types for the given time.
+func NewCache(ttl time.Duration) *Cache {
+	return &Cache{
+		ttl:     ttl,
+		entries: map[string]entry{},
+		now:     time.Now,
+	}
+}
+
+// Get returns the machine types cached for the key, or lists them with list if they are missing or expired. The key has to tell apart everything the list depends on, such as the project and the location. Failed lists are not cached.
+func (c *Cache) Get(ctx context.Context, key string, list func(ctx context.Context) ([]types.MachineType, error)) ([]types.MachineType, error) {
+	c.lock.Lock()
+	e, ok := c.entries[key]
+	c.lock.Unlock()
+	if ok && c.now().Before(e.expires) {
+		return e.machineTypes, nil
+	}
+
+	machineTypes, err := list(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c.lock.Lock()
+	c.entries[key] = entry{machineTypes: machineTypes, expires: c.now().Add(c.ttl)}
+	c.lock.Unlock()
+	return machineTypes, nil
+}
+
+// Validate checks that the machine types of the cluster, or of its node pools if it has any, are among the given ones, which are offered in the location of the cluster.
+func Validate(cluster *types.Cluster, machineTypes []types.MachineType) []types.FieldError {
+	offered := map[string]bool{}
+	for _, machineType := range machineTypes {
+		offered[machineType.Name] = true
+	}
+
+	var fieldErrs []types.FieldError
+	check := func(path, machineType string) {
+		if machineType != "" && !offered[machineType] {
+			fieldErrs = append(fieldErrs, types.FieldError{Field: path, Reason: fmt.Sprintf("is not available in %s", cluster.Location), Value: machineType})
+		}
+	}
+	if len(cluster.NodePools) == 0 {
+		check("Cluster.MachineType", cluster.MachineType)
+	}
+	for i, pool := range cluster.NodePools {
+		check(field.NewPath("Cluster", "NodePools").Index(i).Child("MachineType").String(), pool.MachineType)
+	}
+	return fieldErrs
+}
@@ -0,0 +1,91 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/kyma-incubator/hydroform/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadFile(t *testing.T) {
+	file, err := ioutil.TempFile("", "machine-types")
+	require.NoError(t, err)
+	defer os.Remove(file.Name())
+	_, err = file.WriteString(`{"europe-west3": [{"name": "n1-standard-4", "cpus": 4, "memoryMB": 15360}]}`)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	static, err := LoadFile(file.Name())
+	require.NoError(t, err)
+	machineTypes, err := static.MachineTypes(context.Background(), &types.Provider{}, "europe-west3")
+	require.NoError(t, err)
+	require.Equal(t, []types.MachineType{{Name: "n1-standard-4", CPUs: 4, MemoryMB: 15360}}, machineTypes)
+
+	_, err = static.MachineTypes(context.Background(), &types.Provider{}, "us-central1")
+	require.Error(t, err, "Unknown locations should not be mistaken for locations without machine types")
+
+	_, err = LoadFile(file.Name() + ".missing")
+	require.Error(t, err)
+}
+
+func TestCache(t *testing.T) {
+	now := time.Now()
+	cache := NewCache(time.Hour)
+	cache.now = func() time.Time { return now }
+
+	calls := 0
+	list := func(context.Context) ([]types.MachineType, error) {
+		calls++
+		return []types.MachineType{{Name: "n1-standard-4"}}, nil
+	}
+	for i := 0; i < 2; i++ {
+		machineTypes, err := cache.Get(context.Background(), "my-project/europe-west3", list)
+		require.NoError(t, err)
+		require.Equal(t, []types.MachineType{{Name: "n1-standard-4"}}, machineTypes)
+	}
+	require.Equal(t, 1, calls, "The machine types should be listed once")
+
+	_, err := cache.Get(context.Background(), "my-project/us-central1", list)
+	require.NoError(t, err)
+	require.Equal(t, 2, calls, "Every key should be listed separately")
+
+	now = now.Add(time.Hour)
+	_, err = cache.Get(context.Background(), "my-project/europe-west3", list)
+	require.NoError(t, err)
+	require.Equal(t, 3, calls, "Expired machine types should be listed again")
+
+	failing := func(context.Context) ([]types.MachineType, error) {
+		calls++
+		return nil, errors.New("quota exceeded")
+	}
+	for i := 0; i < 2; i++ {
+		_, err = cache.Get(context.Background(), "other-project/europe-west3", failing)
+		require.Error(t, err)
+	}
+	require.Equal(t, 5, calls, "Failed lists should not be cached")
+}
+
+func TestValidate(t *testing.T) {
+	machineTypes := []types.MachineType{{Name: "n1-standard-4"}, {Name: "n1-highmem-8"}}
+
+	cluster := &types.Cluster{Location: "europe-west3", MachineType: "n1-standard-4"}
+	require.Empty(t, Validate(cluster, machineTypes))
+
+	cluster.MachineType = "n1-standrad-4"
+	require.Equal(t, []types.FieldError{
+		{Field: "Cluster.MachineType", Reason: "is not available in europe-west3", Value: "n1-standrad-4"},
+	}, Validate(cluster, machineTypes))
+
+	cluster.NodePools = []types.NodePool{
+		{Name: "cpu", MachineType: "n1-standard-4"},
+		{Name: "gpu", MachineType: "n1-highmem-16"},
+	}
+	require.Equal(t, []types.FieldError{
+		{Field: "Cluster.NodePools[1].MachineType", Reason: "is not available in europe-west3", Value: "n1-highmem-16"},
+	}, Validate(cluster, machineTypes), "The machine type of the cluster should not be used if it has node pools")
+}
@@ -0,0 +1,554 @@
+package hydroform
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"time"
+
+	"github.com/kyma-incubator/hydroform/action"
+	"github.com/kyma-incubator/hydroform/catalog"
+	"github.com/kyma-incubator/hydroform/lock"
+	"github.com/kyma-incubator/hydroform/state"
+	"github.com/kyma-incubator/hydroform/types"
+	"github.com/pkg/errors"
+)
+
+// Logger is used by a Client to report the progress of its operations. It is satisfied by *log.Logger.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// Client runs Hydroform operations with its own configuration. Unlike the package-level functions, a Client does not share any state with other clients, so several clients can safely be used concurrently.
+type Client struct {
+	operatorType OperatorType
+	registry     *providerRegistry
+	logger       Logger
+	// hooks are run around each operation. If nil, the client falls back to the package-level actions of the action package.
+	hooks *action.Hooks
+	// store keeps the ClusterInfo of the clusters between processes. If nil, the state is only held by the returned clusters.
+	store state.Store
+	// locker guards the clusters against concurrent operations. If nil, operations are not locked.
+	locker      lock.Locker
+	lockTimeout time.Duration
+	// catalogs replace the machine types the providers offer, keyed by provider type.
+	catalogs map[types.ProviderType]catalog.Catalog
+}
+
+// Option configures a Client.
+type Option func(c *Client)
+
+// WithOperator sets the type of the operator used to manage the clusters. It defaults to TerraformOperator.
+func WithOperator(operatorType OperatorType) Option {
+	return func(c *Client) {
+		c.operatorType = operatorType
+	}
+}
+
+// WithBefore sets the action run before each operation of the client.
+func WithBefore(a action.Action) Option {
+	return func(c *Client) {
+		c.hooks.Before = a
+	}
+}
+
+// WithAfter sets the action run after each operation of the client that finished without errors.
+func WithAfter(a action.Action) Option {
+	return func(c *Client) {
+		c.hooks.After = a
+	}
+}
+
+// WithArgs sets the arguments the Before and After actions of the client consume.
+func WithArgs(args ...interface{}) Option {
+	return func(c *Client) {
+		c.hooks.Args = args
+	}
+}
+
+// WithLogger sets the logger the client reports its progress to. By default, nothing is logged.
+func WithLogger(logger Logger) Option {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
+
+// WithProvider registers a Provisioner for the given provider type in the client only. It panics if the factory is nil.
+func WithProvider(providerType types.ProviderType, factory ProvisionerFactory) Option {
+	return func(c *Client) {
+		c.registry.register(providerType, factory)
+	}
+}
+
+// WithStateStore sets the store the client persists the ClusterInfo of the clusters in. Provision, Update and Import save the state, even if they fail halfway, and Deprovision deletes it.
+// Operations which need the ClusterInfo of an existing cluster load it from the store if the given cluster has none, so a cluster can be managed by name only.
+func WithStateStore(store state.Store) Option {
+	return func(c *Client) {
+		c.store = store
+	}
+}
+
+// WithLocker sets the locker the client uses to prevent concurrent operations on the same cluster. Provision, Update, Deprovision, Import, Plan, PlanDeprovision and Drift, which read or write the cluster state, hold the lock of the cluster while they run.
+// If the cluster is locked by someone else, the operations fail with a *lock.ErrLocked reporting the current holder.
+func WithLocker(locker lock.Locker) Option {
+	return func(c *Client) {
+		c.locker = locker
+	}
+}
+
+// WithLockTimeout sets how long the operations wait for the lock of a cluster held by someone else. By default, they fail immediately.
+func WithLockTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.lockTimeout = timeout
+	}
+}
+
+// WithMachineTypeCatalog makes the built-in provider of the given type list the machine types from the catalog, and check the machine types of the clusters against it, instead of asking the provider.
+// With a catalog.Static, machine types can be listed and checked offline, for example in tests.
+func WithMachineTypeCatalog(providerType types.ProviderType, machineTypes catalog.Catalog) Option {
+	return func(c *Client) {
+		if c.catalogs == nil {
+			c.catalogs = map[types.ProviderType]catalog.Catalog{}
+		}
+		c.catalogs[providerType] = machineTypes
+	}
+}
+
+// New creates a Client configured with the given options. The client starts with the providers registered with RegisterProvider at the time of the call.
+func New(opts ...Option) *Client {
+	c := &Client{
+		operatorType: TerraformOperator,
+		registry:     defaultRegistry.copy(),
+		logger:       log.New(ioutil.Discard, "", 0),
+		hooks:        &action.Hooks{},
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// defaultClient backs the package-level functions. It uses the global provider registry and the package-level actions.
+var defaultClient = &Client{
+	operatorType: TerraformOperator,
+	registry:     defaultRegistry,
+	logger:       log.New(ioutil.Discard, "", 0),
+}
+
+// Provision creates a new cluster for a given provider based on specific cluster and provider parameters. See the package-level Provision function for details.
+// Cancelling the context stops the ongoing provisioning. In such case, the returned cluster still holds the state reached so far.
+func (c *Client) Provision(ctx context.Context, cluster *types.Cluster, provider *types.Provider) (*types.Cluster, error) {
+	var cl *types.Cluster
+	err := c.run(ctx, "provision", cluster, provider, func(p Provisioner) (err error) {
+		defaulted, provider := withDefaults(p, cluster, provider)
+		cl, err = p.Provision(ctx, defaulted, provider)
+		keepClusterInfo(cluster, cl)
+		return c.saveState(ctx, cl, provider, err)
+	})
+	return cl, err
+}
+
+// Status returns the cluster status for a given provider, or an error if providing the status is not possible.
+func (c *Client) Status(ctx context.Context, cluster *types.Cluster, provider *types.Provider) (*types.ClusterStatus, error) {
+	var cs *types.ClusterStatus
+	err := c.run(ctx, "status", cluster, provider, func(p Provisioner) (err error) {
+		cluster, provider := withDefaults(p, cluster, provider)
+		cs, err = p.Status(ctx, cluster, provider)
+		return err
+	})
+	return cs, err
+}
+
+// Credentials returns the kubeconfig for a specific cluster as a byte array.
+func (c *Client) Credentials(ctx context.Context, cluster *types.Cluster, provider *types.Provider) ([]byte, error) {
+	var cr []byte
+	err := c.run(ctx, "credentials", cluster, provider, func(p Provisioner) (err error) {
+		cluster, provider := withDefaults(p, cluster, provider)
+		if err := c.loadState(ctx, cluster, provider); err != nil {
+			return err
+		}
+
+		cr, err = p.Credentials(ctx, cluster, provider)
+		return err
+	})
+	return cr, err
+}
+
+// Deprovision removes an existing cluster or returns an error if removing the cluster is not possible.
+// Cancelling the context stops the ongoing deprovisioning.
+func (c *Client) Deprovision(ctx context.Context, cluster *types.Cluster, provider *types.Provider) error {
+	return c.run(ctx, "deprovision", cluster, provider, func(p Provisioner) error {
+		cluster, provider := withDefaults(p, cluster, provider)
+		if err := c.loadState(ctx, cluster, provider); err != nil {
+			return err
+		}
+
+		if err := p.Deprovision(ctx, cluster, provider); err != nil {
+			return err
+		}
+		return c.deleteState(ctx, cluster, provider)
+	})
+}
+
+// Update applies changes of the cluster specification to an existing cluster. See the package-level Update function for details.
+func (c *Client) Update(ctx context.Context, cluster *types.Cluster, provider *types.Provider, opts ...UpdateOption) (*types.Cluster, error) {
+	options := &updateOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	var cl *types.Cluster
+	err := c.run(ctx, "update", cluster, provider, func(p Provisioner) error {
+		updater, ok := p.(Updater)
+		if !ok {
+			return &UnsupportedOperationError{Operation: "update", Type: provider.Type}
+		}
+
+		defaulted, provider := withDefaults(p, cluster, provider)
+		if err := c.loadState(ctx, defaulted, provider); err != nil {
+			return err
+		}
+
+		var err error
+		cl, err = updater.Update(ctx, defaulted, provider, options.allowReplacement)
+		keepClusterInfo(cluster, cl)
+		return c.saveState(ctx, cl, provider, err)
+	})
+	return cl, err
+}
+
+// Plan returns the changes Provision would make for the given cluster and provider, without applying them.
+func (c *Client) Plan(ctx context.Context, cluster *types.Cluster, provider *types.Provider) (*types.Plan, error) {
+	var plan *types.Plan
+	err := c.run(ctx, "plan", cluster, provider, func(p Provisioner) error {
+		planner, ok := p.(Planner)
+		if !ok {
+			return &UnsupportedOperationError{Operation: "plan", Type: provider.Type}
+		}
+
+		cluster, provider := withDefaults(p, cluster, provider)
+		if err := c.loadState(ctx, cluster, provider); err != nil {
+			return err
+		}
+
+		var err error
+		plan, err = planner.Plan(ctx, cluster, provider)
+		return err
+	})
+	return plan, err
+}
+
+// PlanDeprovision returns the changes Deprovision would make for the given cluster and provider, without applying them.
+func (c *Client) PlanDeprovision(ctx context.Context, cluster *types.Cluster, provider *types.Provider) (*types.Plan, error) {
+	var plan *types.Plan
+	err := c.run(ctx, "deprovision plan", cluster, provider, func(p Provisioner) error {
+		planner, ok := p.(Planner)
+		if !ok {
+			return &UnsupportedOperationError{Operation: "deprovision plan", Type: provider.Type}
+		}
+
+		cluster, provider := withDefaults(p, cluster, provider)
+		if err := c.loadState(ctx, cluster, provider); err != nil {
+			return err
+		}
+
+		var err error
+		plan, err = planner.PlanDeprovision(ctx, cluster, provider)
+		return err
+	})
+	return plan, err
+}
+
+// Drift reports how the live cluster differs from its specification and its stored state. See the package-level Drift function for details.
+func (c *Client) Drift(ctx context.Context, cluster *types.Cluster, provider *types.Provider) (*types.DriftReport, error) {
+	var report *types.DriftReport
+	err := c.run(ctx, "drift", cluster, provider, func(p Provisioner) error {
+		detector, ok := p.(DriftDetector)
+		if !ok {
+			return &UnsupportedOperationError{Operation: "drift", Type: provider.Type}
+		}
+
+		cluster, provider := withDefaults(p, cluster, provider)
+		if err := c.loadState(ctx, cluster, provider); err != nil {
+			return err
+		}
+
+		var err error
+		report, err = detector.Drift(ctx, cluster, provider)
+		return err
+	})
+	return report, err
+}
+
+// Import adopts an existing cluster that was not created with Hydroform. See the package-level Import function for details.
+func (c *Client) Import(ctx context.Context, cluster *types.Cluster, provider *types.Provider) (*types.Cluster, error) {
+	var cl *types.Cluster
+	err := c.run(ctx, "import", cluster, provider, func(p Provisioner) error {
+		importer, ok := p.(Importer)
+		if !ok {
+			return &UnsupportedOperationError{Operation: "import", Type: provider.Type}
+		}
+
+		defaulted, provider := withDefaults(p, cluster, provider)
+		var err error
+		cl, err = importer.Import(ctx, defaulted, provider)
+		keepClusterInfo(cluster, cl)
+		return c.saveState(ctx, cl, provider, err)
+	})
+	return cl, err
+}
+
+// List returns the clusters of the provider project. See the package-level List function for details.
+func (c *Client) List(ctx context.Context, provider *types.Provider, opts ...ListOption) ([]*types.Cluster, error) {
+	options := &listOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	var clusters []*types.Cluster
+	err := c.run(ctx, "list", nil, provider, func(p Provisioner) error {
+		lister, ok := p.(Lister)
+		if !ok {
+			return &UnsupportedOperationError{Operation: "list", Type: provider.Type}
+		}
+
+		var err error
+		clusters, err = lister.List(ctx, provider, options.labels)
+		return err
+	})
+	return clusters, err
+}
+
+// MachineTypes returns the machine types the provider offers in the location. See the package-level MachineTypes function for details.
+func (c *Client) MachineTypes(ctx context.Context, provider *types.Provider, location string) ([]types.MachineType, error) {
+	var machineTypes []types.MachineType
+	err := c.run(ctx, "machine types", nil, provider, func(p Provisioner) error {
+		lister, ok := p.(MachineTypeLister)
+		if !ok {
+			return &UnsupportedOperationError{Operation: "machine types", Type: provider.Type}
+		}
+
+		var err error
+		machineTypes, err = lister.MachineTypes(ctx, provider, location)
+		return err
+	})
+	return machineTypes, err
+}
+
+// Validate checks the cluster and provider specification without calling the provider. See the package-level Validate function for details.
+func (c *Client) Validate(cluster *types.Cluster, provider *types.Provider) error {
+	p, err := c.provisioner(provider.Type)
+	if err != nil {
+		return err
+	}
+
+	validator, ok := p.(Validator)
+	if !ok {
+		return &UnsupportedOperationError{Operation: "validate", Type: provider.Type}
+	}
+	return validator.Validate(withDefaults(p, cluster, provider))
+}
+
+// Default returns copies of the cluster and provider with the defaults of the provider applied. See the package-level Default function for details.
+func (c *Client) Default(cluster *types.Cluster, provider *types.Provider) (*types.Cluster, *types.Provider, error) {
+	p, err := c.provisioner(provider.Type)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cluster, provider = withDefaults(p, cluster, provider)
+	return cluster, provider, nil
+}
+
+// withDefaults returns copies of the cluster and provider with the defaults of the provisioner applied. Provisioners without defaults get unchanged copies.
+func withDefaults(p Provisioner, cluster *types.Cluster, provider *types.Provider) (*types.Cluster, *types.Provider) {
+	cluster, provider = cluster.Copy(), provider.Copy()
+	if defaulter, ok := p.(Defaulter); ok {
+		defaulter.Default(cluster, provider)
+	}
+	return cluster, provider
+}
+
+// keepClusterInfo sets the ClusterInfo and the resolved Kubernetes version of the cluster returned by an operation on the cluster given by the caller, which the operation only used a defaulted copy of.
+// Callers which keep using the cluster they passed, instead of the returned one, can then still manage the cluster.
+func keepClusterInfo(cluster, result *types.Cluster) {
+	if result == nil || result.ClusterInfo == nil {
+		return
+	}
+	cluster.ClusterInfo = result.ClusterInfo
+	cluster.KubernetesVersion = result.KubernetesVersion
+}
+
+// Describe returns the schema of the custom configurations the provider accepts. See the package-level DescribeProvider function for details.
+func (c *Client) Describe(providerType types.ProviderType) (*types.ProviderSchema, error) {
+	p, err := c.provisioner(providerType)
+	if err != nil {
+		return nil, err
+	}
+
+	describer, ok := p.(Describer)
+	if !ok {
+		return nil, &UnsupportedOperationError{Operation: "describe", Type: providerType}
+	}
+	return describer.Describe(), nil
+}
+
+// ForceUnlock releases the lock of the cluster regardless of its holder. Use it to clean up after a process which crashed while running an operation on the cluster.
+func (c *Client) ForceUnlock(ctx context.Context, cluster *types.Cluster, provider *types.Provider) error {
+	if c.locker == nil {
+		return errors.New("the client has no locker")
+	}
+	return c.locker.ForceUnlock(ctx, lock.KeyFor(cluster, provider))
+}
+
+// lockedOperations are the operations which read or write the cluster state, and hold the lock of the cluster while they run.
+var lockedOperations = map[string]bool{
+	"provision":        true,
+	"update":           true,
+	"deprovision":      true,
+	"import":           true,
+	"plan":             true,
+	"deprovision plan": true,
+	"drift":            true,
+}
+
+// run executes an operation with the Provisioner registered for the provider. The before action runs first, the after action only runs if the operation succeeded.
+func (c *Client) run(ctx context.Context, operation string, cluster *types.Cluster, provider *types.Provider, f func(p Provisioner) error) (err error) {
+	if err := c.before(); err != nil {
+		return err
+	}
+
+	p, err := c.provisioner(provider.Type)
+	if err != nil {
+		return err
+	}
+
+	if c.locker != nil && lockedOperations[operation] {
+		unlock, lockErr := c.lock(ctx, operation, cluster, provider)
+		if lockErr != nil {
+			return lockErr
+		}
+		defer unlock(&err)
+	}
+
+	// operations on all clusters of a provider, such as list, have no cluster
+	target := fmt.Sprintf("clusters on %s", provider.Type)
+	if cluster != nil {
+		target = fmt.Sprintf("cluster %q on %s", cluster.Name, provider.Type)
+	}
+
+	c.logger.Printf("hydroform: %s of %s started", operation, target)
+	if err := f(p); err != nil {
+		c.logger.Printf("hydroform: %s of %s failed: %s", operation, target, err)
+		return err
+	}
+	c.logger.Printf("hydroform: %s of %s finished", operation, target)
+
+	return c.after()
+}
+
+// catalogSetter is implemented by the built-in provisioners, which can use a machine type catalog set with WithMachineTypeCatalog.
+type catalogSetter interface {
+	SetMachineTypeCatalog(machineTypes catalog.Catalog)
+}
+
+// provisioner creates the Provisioner of the provider type, using the machine type catalog of the client if it has one for the type.
+func (c *Client) provisioner(providerType types.ProviderType) (Provisioner, error) {
+	p, err := c.registry.provisioner(providerType, c.operatorType)
+	if err != nil {
+		return nil, err
+	}
+	if machineTypes, ok := c.catalogs[providerType]; ok {
+		if setter, ok := p.(catalogSetter); ok {
+			setter.SetMachineTypeCatalog(machineTypes)
+		}
+	}
+	return p, nil
+}
+
+// lock acquires the lock of the cluster. The returned function releases it, and sets the error of the operation if releasing fails while the operation succeeded.
+func (c *Client) lock(ctx context.Context, operation string, cluster *types.Cluster, provider *types.Provider) (func(opErr *error), error) {
+	key := lock.KeyFor(cluster, provider)
+	holder, err := lock.NewHolder(operation)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create the lock holder")
+	}
+	if err := lock.LockWithTimeout(ctx, c.locker, key, holder, c.lockTimeout); err != nil {
+		return nil, err
+	}
+
+	return func(opErr *error) {
+		// the lock must be released even if the operation was cancelled
+		err := c.locker.Unlock(context.Background(), key, holder)
+		if err == nil {
+			return
+		}
+		c.logger.Printf("hydroform: unable to unlock cluster %q on %s: %s", cluster.Name, provider.Type, err)
+		if *opErr == nil {
+			*opErr = errors.Wrap(err, "unable to unlock the cluster")
+		}
+	}, nil
+}
+
+// loadState sets the ClusterInfo of the cluster from the state store, if the client has one and the cluster does not hold any internal state yet, for example because it was returned by List.
+func (c *Client) loadState(ctx context.Context, cluster *types.Cluster, provider *types.Provider) error {
+	if c.store == nil || (cluster.ClusterInfo != nil && cluster.ClusterInfo.InternalState != nil) {
+		return nil
+	}
+
+	info, err := c.store.Load(ctx, state.KeyFor(cluster, provider))
+	if err == state.ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "unable to load the cluster state")
+	}
+	cluster.ClusterInfo = info
+	return nil
+}
+
+// saveState saves the ClusterInfo of the cluster returned by an operation in the state store, if the client has one. The state is saved even if the operation failed, so that a partially provisioned cluster can still be deprovisioned.
+// It returns the error of the operation, if any, or the error of saving the state otherwise.
+func (c *Client) saveState(ctx context.Context, cluster *types.Cluster, provider *types.Provider, opErr error) error {
+	if c.store == nil || cluster == nil || cluster.ClusterInfo == nil {
+		return opErr
+	}
+
+	// a cancelled operation still leaves resources behind, so its state must be saved regardless of the context
+	if ctx.Err() != nil {
+		ctx = context.Background()
+	}
+
+	err := c.store.Save(ctx, state.KeyFor(cluster, provider), cluster.ClusterInfo)
+	if opErr != nil {
+		if err != nil {
+			c.logger.Printf("hydroform: unable to save the state of cluster %q on %s: %s", cluster.Name, provider.Type, err)
+		}
+		return opErr
+	}
+	return errors.Wrap(err, "unable to save the cluster state")
+}
+
+// deleteState removes the state of a deprovisioned cluster from the state store, if the client has one.
+func (c *Client) deleteState(ctx context.Context, cluster *types.Cluster, provider *types.Provider) error {
+	if c.store == nil {
+		return nil
+	}
+	return errors.Wrap(c.store.Delete(ctx, state.KeyFor(cluster, provider)), "unable to delete the cluster state")
+}
+
+func (c *Client) before() error {
+	if c.hooks == nil {
+		return action.Before()
+	}
+	return c.hooks.RunBefore()
+}
+
+func (c *Client) after() error {
+	if c.hooks == nil {
+		return action.After()
+	}
+	return c.hooks.RunAfter()
+}
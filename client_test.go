@@ -0,0 +1,428 @@
+package hydroform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kyma-incubator/hydroform/action"
+	"github.com/kyma-incubator/hydroform/catalog"
+	"github.com/kyma-incubator/hydroform/internal/errs"
+	"github.com/kyma-incubator/hydroform/lock"
+	"github.com/kyma-incubator/hydroform/state"
+	"github.com/kyma-incubator/hydroform/types"
+	"github.com/stretchr/testify/require"
+)
+
+const fakeProvider types.ProviderType = "fake"
+
+func TestClientConcurrentHooks(t *testing.T) {
+	const clients = 10
+
+	wg := sync.WaitGroup{}
+	errCh := make(chan error, clients)
+	for i := 0; i < clients; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			var before, after []interface{}
+			c := New(
+				WithProvider(fakeProvider, func(OperatorType) Provisioner { return &fakeProvisioner{} }),
+				WithArgs(i),
+				WithBefore(action.FuncAction(func(args ...interface{}) (interface{}, error) {
+					before = append(before, args...)
+					return nil, nil
+				})),
+				WithAfter(action.FuncAction(func(args ...interface{}) (interface{}, error) {
+					after = append(after, args...)
+					return nil, nil
+				})),
+			)
+
+			cluster := &types.Cluster{Name: fmt.Sprintf("cluster-%d", i)}
+			provider := &types.Provider{Type: fakeProvider}
+			if _, err := c.Provision(context.Background(), cluster, provider); err != nil {
+				errCh <- err
+				return
+			}
+			if err := c.Deprovision(context.Background(), cluster, provider); err != nil {
+				errCh <- err
+				return
+			}
+
+			// each client only runs its own hooks, every time
+			if len(before) != 2 || before[0] != i || before[1] != i {
+				errCh <- fmt.Errorf("client %d ran unexpected before hooks: %v", i, before)
+			}
+			if len(after) != 2 || after[0] != i || after[1] != i {
+				errCh <- fmt.Errorf("client %d ran unexpected after hooks: %v", i, after)
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errCh)
+
+	for err := range errCh {
+		require.NoError(t, err)
+	}
+}
+
+func TestClientHookErrors(t *testing.T) {
+	fake := &fakeProvisioner{}
+	failing := action.FuncAction(func(args ...interface{}) (interface{}, error) {
+		return nil, errors.New("This action always fails")
+	})
+	provider := &types.Provider{Type: fakeProvider}
+
+	c := New(
+		WithProvider(fakeProvider, func(OperatorType) Provisioner { return fake }),
+		WithBefore(failing),
+	)
+	_, err := c.Status(context.Background(), &types.Cluster{}, provider)
+	require.Error(t, err, "A failing before action should stop the operation")
+	require.Empty(t, fake.calls, "The operation should not run when the before action fails")
+
+	c = New(
+		WithProvider(fakeProvider, func(OperatorType) Provisioner { return fake }),
+		WithAfter(failing),
+	)
+	_, err = c.Status(context.Background(), &types.Cluster{}, provider)
+	require.Error(t, err, "Errors of the after action should be returned")
+	require.Equal(t, []string{"Status"}, fake.calls)
+}
+
+func TestClientRegistry(t *testing.T) {
+	c := New(WithProvider(fakeProvider, func(OperatorType) Provisioner { return &fakeProvisioner{} }))
+
+	_, err := c.Credentials(context.Background(), &types.Cluster{}, &types.Provider{Type: fakeProvider})
+	require.NoError(t, err)
+
+	// providers registered in a client are not visible to others
+	_, err = Credentials(&types.Cluster{}, &types.Provider{Type: fakeProvider})
+	var unsupported *UnsupportedProviderError
+	require.True(t, errors.As(err, &unsupported), "The provider should only be registered in the client")
+	_, err = New().Credentials(context.Background(), &types.Cluster{}, &types.Provider{Type: fakeProvider})
+	require.True(t, errors.As(err, &unsupported), "The provider should only be registered in the client")
+}
+
+func TestClientUnsupportedOperation(t *testing.T) {
+	c := New(WithProvider(fakeProvider, func(OperatorType) Provisioner { return &fakeProvisioner{} }))
+
+	_, err := c.Plan(context.Background(), &types.Cluster{}, &types.Provider{Type: fakeProvider})
+	var unsupported *UnsupportedOperationError
+	require.True(t, errors.As(err, &unsupported), "Plan should not be supported by a provisioner that is not a Planner")
+	require.Equal(t, fakeProvider, unsupported.Type)
+
+	_, err = c.Import(context.Background(), &types.Cluster{}, &types.Provider{Type: fakeProvider})
+	require.True(t, errors.As(err, &unsupported), "Import should not be supported by a provisioner that is not an Importer")
+	require.Equal(t, "import", unsupported.Operation)
+
+	_, err = c.Drift(context.Background(), &types.Cluster{}, &types.Provider{Type: fakeProvider})
+	require.True(t, errors.As(err, &unsupported), "Drift should not be supported by a provisioner that is not a DriftDetector")
+	require.Equal(t, "drift", unsupported.Operation)
+
+	_, err = c.List(context.Background(), &types.Provider{Type: fakeProvider})
+	require.True(t, errors.As(err, &unsupported), "List should not be supported by a provisioner that is not a Lister")
+	require.Equal(t, "list", unsupported.Operation)
+
+	_, err = c.MachineTypes(context.Background(), &types.Provider{Type: fakeProvider}, "europe-west3")
+	require.True(t, errors.As(err, &unsupported), "MachineTypes should not be supported by a provisioner that is not a MachineTypeLister")
+	require.Equal(t, "machine types", unsupported.Operation)
+}
+
+func TestValidate(t *testing.T) {
+	cluster := &types.Cluster{
+		KubernetesVersion: "1.14",
+		Name:              "hydro-cluster",
+		DiskSizeGB:        30,
+		NodeCount:         0,
+		Location:          "europe-west3",
+	}
+	provider := &types.Provider{
+		Type:                types.GCP,
+		ProjectName:         "my-project",
+		CredentialsFilePath: "/path/to/credentials",
+	}
+
+	err := Validate(cluster, provider)
+	var validation *types.ValidationError
+	require.True(t, errors.As(err, &validation), "The error should be a ValidationError")
+	require.Equal(t, []string{"Cluster.NodeCount", "Cluster.MachineType"}, validation.Fields())
+	require.Equal(t, 0, validation.Errors[0].Value)
+
+	cluster.NodeCount = 3
+	cluster.MachineType = "n1-standard-4"
+	require.NoError(t, Validate(cluster, provider))
+
+	c := New(WithProvider(fakeProvider, func(OperatorType) Provisioner { return &fakeProvisioner{} }))
+	var unsupported *UnsupportedOperationError
+	require.True(t, errors.As(c.Validate(cluster, &types.Provider{Type: fakeProvider}), &unsupported), "Validate should not be supported by a provisioner that is not a Validator")
+}
+
+func TestDescribeProvider(t *testing.T) {
+	schema, err := DescribeProvider(types.Gardener)
+	require.NoError(t, err)
+	require.Equal(t, types.Gardener, schema.Type)
+	require.Equal(t, "target_provider", schema.CustomConfigurations[0].Key)
+	require.True(t, schema.CustomConfigurations[0].Required)
+
+	schema, err = DescribeProvider(types.GCP)
+	require.NoError(t, err)
+	require.Empty(t, schema.CustomConfigurations, "GCP should not accept custom configurations")
+
+	c := New(WithProvider(fakeProvider, func(OperatorType) Provisioner { return &fakeProvisioner{} }))
+	_, err = c.Describe(fakeProvider)
+	var unsupported *UnsupportedOperationError
+	require.True(t, errors.As(err, &unsupported), "Describe should not be supported by a provisioner that is not a Describer")
+}
+
+func TestClientMachineTypeCatalog(t *testing.T) {
+	static := catalog.Static{"europe-west3-a": {{Name: "n1-standard-4", CPUs: 4, MemoryMB: 15360}}}
+	c := New(WithMachineTypeCatalog(types.GCP, static))
+	provider := &types.Provider{
+		Type:                types.GCP,
+		ProjectName:         "my-project",
+		CredentialsFilePath: "/path/to/credentials",
+	}
+
+	machineTypes, err := c.MachineTypes(context.Background(), provider, "europe-west3-a")
+	require.NoError(t, err, "The machine types should be listed from the catalog without calling GCP")
+	require.Equal(t, static["europe-west3-a"], machineTypes)
+	_, err = c.MachineTypes(context.Background(), provider, "us-east1-b")
+	require.Error(t, err, "Locations the catalog does not know should fail")
+}
+
+func TestDefault(t *testing.T) {
+	cluster := &types.Cluster{
+		Name:        "hydro-cluster",
+		NodeCount:   3,
+		Location:    "europe-west3",
+		MachineType: "n1-standard-4",
+		NodePools:   []types.NodePool{{Name: "cpu", MachineType: "n1-standard-4", NodeCount: 2}},
+	}
+	provider := &types.Provider{
+		Type:                types.GCP,
+		ProjectName:         "my-project",
+		CredentialsFilePath: "/path/to/credentials",
+	}
+
+	defaulted, _, err := Default(cluster, provider)
+	require.NoError(t, err)
+	require.NotEmpty(t, defaulted.KubernetesVersion)
+	require.NotZero(t, defaulted.DiskSizeGB)
+	require.Equal(t, defaulted.DiskSizeGB, defaulted.NodePools[0].DiskSizeGB, "The node pools should get the disk size of the cluster")
+	require.Empty(t, cluster.KubernetesVersion, "The given cluster should not be changed")
+	require.Zero(t, cluster.NodePools[0].DiskSizeGB, "The node pools of the given cluster should not be changed")
+	require.NoError(t, Validate(cluster, provider), "Validate should apply the defaults")
+
+	c := New(WithProvider(fakeProvider, func(OperatorType) Provisioner { return &fakeProvisioner{} }))
+	defaulted, _, err = c.Default(cluster, &types.Provider{Type: fakeProvider})
+	require.NoError(t, err)
+	require.Equal(t, cluster, defaulted, "A provisioner that is not a Defaulter should get an unchanged copy")
+
+	_, _, err = c.Default(cluster, &types.Provider{Type: "nimbus"})
+	var unsupported *UnsupportedProviderError
+	require.True(t, errors.As(err, &unsupported))
+}
+
+// statefulProvisioner fails provisioning halfway if failProvision is set, and records the cluster info it deprovisions.
+type statefulProvisioner struct {
+	fakeProvisioner
+	failProvision bool
+	deprovisioned *types.ClusterInfo
+}
+
+func (s *statefulProvisioner) Provision(ctx context.Context, cluster *types.Cluster, provider *types.Provider) (*types.Cluster, error) {
+	if s.failProvision {
+		cluster.ClusterInfo = &types.ClusterInfo{Status: &types.ClusterStatus{Phase: types.Errored}}
+		return cluster, errors.New("quota exceeded")
+	}
+	return s.fakeProvisioner.Provision(ctx, cluster, provider)
+}
+
+func (s *statefulProvisioner) Deprovision(ctx context.Context, cluster *types.Cluster, provider *types.Provider) error {
+	s.deprovisioned = cluster.ClusterInfo
+	return nil
+}
+
+func TestClientStateStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hydroform-state")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ctx := context.Background()
+	store := state.NewFileStore(dir)
+	provisioner := &statefulProvisioner{}
+	c := New(
+		WithProvider(fakeProvider, func(OperatorType) Provisioner { return provisioner }),
+		WithStateStore(store),
+	)
+	provider := &types.Provider{Type: fakeProvider}
+	key := state.Key{Provider: fakeProvider, Name: "hydro-cluster"}
+
+	_, err = c.Provision(ctx, &types.Cluster{Name: "hydro-cluster"}, provider)
+	require.NoError(t, err)
+	info, err := store.Load(ctx, key)
+	require.NoError(t, err, "Provision should save the state")
+	require.Equal(t, types.Provisioned, info.Status.Phase)
+
+	require.NoError(t, c.Deprovision(ctx, &types.Cluster{Name: "hydro-cluster"}, provider))
+	require.NotNil(t, provisioner.deprovisioned, "Deprovision should load the state of a cluster given by name")
+	require.Equal(t, types.Provisioned, provisioner.deprovisioned.Status.Phase)
+	_, err = store.Load(ctx, key)
+	require.Equal(t, state.ErrNotFound, err, "Deprovision should delete the state")
+
+	provisioner.failProvision = true
+	_, err = c.Provision(ctx, &types.Cluster{Name: "hydro-cluster"}, provider)
+	require.Error(t, err)
+	info, err = store.Load(ctx, key)
+	require.NoError(t, err, "A failed provisioning should save the state reached so far")
+	require.Equal(t, types.Errored, info.Status.Phase)
+}
+
+func TestClientKeepsClusterInfo(t *testing.T) {
+	ctx := context.Background()
+	provisioner := &statefulProvisioner{}
+	c := New(WithProvider(fakeProvider, func(OperatorType) Provisioner { return provisioner }))
+	provider := &types.Provider{Type: fakeProvider}
+
+	cluster := &types.Cluster{Name: "hydro-cluster"}
+	returned, err := c.Provision(ctx, cluster, provider)
+	require.NoError(t, err)
+	require.Equal(t, returned.ClusterInfo, cluster.ClusterInfo, "Provision should set the cluster info on the given cluster")
+
+	require.NoError(t, c.Deprovision(ctx, cluster, provider), "The given cluster should be usable after Provision")
+	require.Equal(t, returned.ClusterInfo, provisioner.deprovisioned)
+
+	provisioner.failProvision = true
+	cluster = &types.Cluster{Name: "hydro-cluster"}
+	_, err = c.Provision(ctx, cluster, provider)
+	require.Error(t, err)
+	require.NotNil(t, cluster.ClusterInfo, "A failed provisioning should set the state reached so far on the given cluster")
+}
+
+// minimalGardenerSpec returns a Gardener cluster and provider which rely on the defaults of the provider for everything they do not set.
+func minimalGardenerSpec(credentialsFilePath string) (*types.Cluster, *types.Provider) {
+	cluster := &types.Cluster{
+		Name:        "hydro-cluster",
+		NodeCount:   2,
+		Location:    "europe-west3",
+		MachineType: "n1-standard-4",
+	}
+	provider := &types.Provider{
+		Type:                types.Gardener,
+		ProjectName:         "my-project",
+		CredentialsFilePath: credentialsFilePath,
+		CustomConfigurations: map[string]interface{}{
+			"target_provider": "gcp",
+			"target_seed":     "gcp-eu1",
+			"target_secret":   "secret-name",
+			"zone":            "europe-west3-b",
+			"workercidr":      "10.250.0.0/19",
+		},
+	}
+	return cluster, provider
+}
+
+func TestClientDeprovisionWithoutState(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hydroform-state")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	c := New(WithStateStore(state.NewFileStore(dir)))
+	cluster, provider := minimalGardenerSpec("/path/to/credentials")
+
+	err = c.Deprovision(context.Background(), cluster, provider)
+	require.EqualError(t, err, errs.EmptyClusterInfo, "Deprovision of a cluster given by name should fail if the store has no state for it")
+}
+
+func TestClientDefaultsGardenerSpec(t *testing.T) {
+	// the Gardener cluster only knows the Shoot of the cluster
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/apis/garden.sapcloud.io/v1beta1/namespaces/garden-my-project/shoots/hydro-cluster" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"apiVersion": "garden.sapcloud.io/v1beta1", "kind": "Shoot", "metadata": {"name": "hydro-cluster", "namespace": "garden-my-project"},
+			"status": {"lastOperation": {"type": "Reconcile", "state": "Succeeded"}}}`)
+	}))
+	defer server.Close()
+
+	kubeconfig, err := ioutil.TempFile("", "kubeconfig")
+	require.NoError(t, err)
+	defer os.Remove(kubeconfig.Name())
+	_, err = fmt.Fprintf(kubeconfig, `apiVersion: v1
+kind: Config
+clusters:
+- name: garden
+  cluster:
+    server: %s
+contexts:
+- name: garden
+  context:
+    cluster: garden
+current-context: garden
+`, server.URL)
+	require.NoError(t, err)
+	require.NoError(t, kubeconfig.Close())
+
+	ctx := context.Background()
+	cluster, provider := minimalGardenerSpec(kubeconfig.Name())
+	require.NoError(t, Validate(cluster, provider), "Provision should accept the specification")
+
+	status, err := Status(cluster, provider)
+	require.NoError(t, err, "Status should apply the defaults like Provision")
+	require.Equal(t, types.Provisioned, status.Phase)
+
+	status, err = WaitFor(ctx, cluster, provider, types.Provisioned, PollInterval(time.Millisecond))
+	require.NoError(t, err, "WaitFor should apply the defaults like Provision")
+	require.Equal(t, types.Provisioned, status.Phase)
+
+	_, err = Import(cluster, provider)
+	var validation *types.ValidationError
+	require.False(t, errors.As(err, &validation), "Import should apply the defaults like Provision, but failed with: %v", err)
+	require.Contains(t, err.Error(), "cloud profile", "Import should go on to resolve the Kubernetes version")
+}
+
+func TestClientLocker(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hydroform-lock")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ctx := context.Background()
+	locker := lock.NewFileLocker(dir)
+	c := New(
+		WithProvider(fakeProvider, func(OperatorType) Provisioner { return &fakeProvisioner{} }),
+		WithLocker(locker),
+	)
+	cluster := &types.Cluster{Name: "hydro-cluster"}
+	provider := &types.Provider{Type: fakeProvider, ProjectName: "my-project"}
+
+	_, err = c.Provision(ctx, cluster, provider)
+	require.NoError(t, err)
+
+	other, err := lock.NewHolder("deprovision")
+	require.NoError(t, err)
+	require.NoError(t, locker.Lock(ctx, lock.KeyFor(cluster, provider), other), "The lock should be released after the operation")
+
+	_, err = c.Provision(ctx, cluster, provider)
+	var locked *lock.ErrLocked
+	require.True(t, errors.As(err, &locked), "Operations on a locked cluster should fail")
+	require.Equal(t, other, locked.Holder)
+
+	_, err = c.Status(ctx, cluster, provider)
+	require.NoError(t, err, "Operations which do not use the cluster state should not be locked")
+
+	require.NoError(t, c.ForceUnlock(ctx, cluster, provider))
+	_, err = c.Provision(ctx, cluster, provider)
+	require.NoError(t, err, "Operations should succeed once the cluster is force-unlocked")
+}
@@ -0,0 +1,212 @@
+// Package encryption encrypts serialized cluster state with AES-GCM envelope encryption.
+//
+// Every blob is encrypted with its own random data key. The data key is encrypted with a key encryption key from a KeyProvider and stored in the blob, along with the ID of the key encryption key.
+// Rotating the key encryption key only re-encrypts the data keys of existing blobs.
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// blobVersion is the version of the format of encrypted blobs.
+const blobVersion = 1
+
+// dataKeySize is the size of the random data keys, which makes them AES-256 keys.
+const dataKeySize = 32
+
+// blob is an encrypted blob, serialized as JSON.
+type blob struct {
+	Version int    `json:"version"`
+	KeyID   string `json:"keyID"`
+	// EncryptedKey is the data key encrypted with the key encryption key, prefixed with the nonce.
+	EncryptedKey []byte `json:"encryptedKey"`
+	// Ciphertext is the data encrypted with the data key, prefixed with the nonce.
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// Encrypt encrypts the plaintext with a new data key, which is encrypted with the current key of the provider.
+func Encrypt(keys KeyProvider, plaintext []byte) ([]byte, error) {
+	kek, err := keys.CurrentKey()
+	if err != nil {
+		return nil, err
+	}
+
+	dataKey := make([]byte, dataKeySize)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return nil, err
+	}
+
+	ciphertext, err := seal(dataKey, plaintext, nil)
+	if err != nil {
+		return nil, err
+	}
+	encryptedKey, err := seal(kek.Material, dataKey, []byte(kek.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(blob{
+		Version:      blobVersion,
+		KeyID:        kek.ID,
+		EncryptedKey: encryptedKey,
+		Ciphertext:   ciphertext,
+	})
+}
+
+// Decrypt decrypts a blob encrypted with Encrypt, using the key of the provider the blob was encrypted with. Failures are returned as a *DecryptionError.
+func Decrypt(keys KeyProvider, data []byte) ([]byte, error) {
+	b, dataKey, err := open(keys, data)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := unseal(dataKey, b.Ciphertext, nil)
+	if err != nil {
+		return nil, &DecryptionError{Reason: AuthenticationFailed, KeyID: b.KeyID, Err: err}
+	}
+	return plaintext, nil
+}
+
+// Rotate re-encrypts the data key of a blob with the current key of the provider. The encrypted data itself is left untouched.
+func Rotate(keys KeyProvider, data []byte) ([]byte, error) {
+	b, dataKey, err := open(keys, data)
+	if err != nil {
+		return nil, err
+	}
+
+	kek, err := keys.CurrentKey()
+	if err != nil {
+		return nil, err
+	}
+	if kek.ID == b.KeyID {
+		return data, nil
+	}
+
+	b.KeyID = kek.ID
+	b.EncryptedKey, err = seal(kek.Material, dataKey, []byte(kek.ID))
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(b)
+}
+
+// KeyID returns the ID of the key a blob was encrypted with.
+func KeyID(data []byte) (string, error) {
+	b, err := parse(data)
+	if err != nil {
+		return "", err
+	}
+	return b.KeyID, nil
+}
+
+// open parses a blob and decrypts its data key.
+func open(keys KeyProvider, data []byte) (*blob, []byte, error) {
+	b, err := parse(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	kek, err := keys.Key(b.KeyID)
+	if err != nil {
+		return nil, nil, &DecryptionError{Reason: UnknownKey, KeyID: b.KeyID, Err: err}
+	}
+	dataKey, err := unseal(kek.Material, b.EncryptedKey, []byte(b.KeyID))
+	if err != nil {
+		return nil, nil, &DecryptionError{Reason: AuthenticationFailed, KeyID: b.KeyID, Err: err}
+	}
+	return b, dataKey, nil
+}
+
+func parse(data []byte) (*blob, error) {
+	b := &blob{}
+	if err := json.Unmarshal(data, b); err != nil {
+		return nil, &DecryptionError{Reason: MalformedBlob, Err: err}
+	}
+	if b.Version == 0 {
+		return nil, &DecryptionError{Reason: MalformedBlob, Err: fmt.Errorf("the data is not an encrypted blob")}
+	}
+	if b.Version != blobVersion {
+		return nil, &DecryptionError{Reason: UnsupportedVersion, KeyID: b.KeyID, Err: fmt.Errorf("blob version %d is not supported", b.Version)}
+	}
+	if b.KeyID == "" || len(b.EncryptedKey) == 0 || len(b.Ciphertext) == 0 {
+		return nil, &DecryptionError{Reason: MalformedBlob, KeyID: b.KeyID, Err: fmt.Errorf("the blob is incomplete")}
+	}
+	return b, nil
+}
+
+// seal encrypts the plaintext with AES-GCM, and prefixes the result with the random nonce.
+func seal(key, plaintext, additionalData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// unseal decrypts data encrypted with seal.
+func unseal(key, ciphertext, additionalData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, fmt.Errorf("the ciphertext is too short")
+	}
+	nonce, ciphertext := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, additionalData)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// FailureReason tells why a blob could not be decrypted.
+type FailureReason string
+
+const (
+	// MalformedBlob indicates that the data is not an encrypted blob.
+	MalformedBlob FailureReason = "malformed blob"
+	// UnsupportedVersion indicates that the blob was encrypted by a newer version of Hydroform.
+	UnsupportedVersion FailureReason = "unsupported version"
+	// UnknownKey indicates that the key provider does not have the key the blob was encrypted with.
+	UnknownKey FailureReason = "unknown key"
+	// AuthenticationFailed indicates that the blob was encrypted with a different key material, or was tampered with.
+	AuthenticationFailed FailureReason = "authentication failed"
+)
+
+// DecryptionError is returned when a blob cannot be decrypted.
+type DecryptionError struct {
+	// Reason tells why the blob could not be decrypted.
+	Reason FailureReason
+	// KeyID is the ID of the key the blob was encrypted with, if known.
+	KeyID string
+	// Err is the underlying error.
+	Err error
+}
+
+func (e *DecryptionError) Error() string {
+	if e.KeyID == "" {
+		return fmt.Sprintf("unable to decrypt: %s: %s", e.Reason, e.Err)
+	}
+	return fmt.Sprintf("unable to decrypt with key %q: %s: %s", e.KeyID, e.Reason, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *DecryptionError) Unwrap() error {
+	return e.Err
+}
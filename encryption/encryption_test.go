@@ -0,0 +1,128 @@
+package encryption
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	oldKey = Key{ID: "old", Material: bytes.Repeat([]byte{1}, 32)}
+	newKey = Key{ID: "new", Material: bytes.Repeat([]byte{2}, 16)}
+)
+
+func newKeys(t *testing.T, current Key, previous ...Key) KeyProvider {
+	keys, err := NewStaticKeyProvider(current, previous...)
+	require.NoError(t, err)
+	return keys
+}
+
+func requireDecryptionError(t *testing.T, err error, reason FailureReason) {
+	var decryptionErr *DecryptionError
+	require.True(t, errors.As(err, &decryptionErr), "Expected a DecryptionError, got %v", err)
+	require.Equal(t, reason, decryptionErr.Reason)
+}
+
+func TestEncrypt(t *testing.T) {
+	keys := newKeys(t, oldKey)
+	plaintext := []byte(`{"endpoint":"35.1.2.3"}`)
+
+	data, err := Encrypt(keys, plaintext)
+	require.NoError(t, err)
+	require.False(t, bytes.Contains(data, plaintext), "The plaintext should not be readable from the blob")
+
+	id, err := KeyID(data)
+	require.NoError(t, err)
+	require.Equal(t, "old", id)
+
+	again, err := Encrypt(keys, plaintext)
+	require.NoError(t, err)
+	require.NotEqual(t, data, again, "Every blob should use its own data key")
+
+	decrypted, err := Decrypt(keys, data)
+	require.NoError(t, err)
+	require.Equal(t, plaintext, decrypted)
+}
+
+func TestDecryptErrors(t *testing.T) {
+	data, err := Encrypt(newKeys(t, oldKey), []byte("secret"))
+	require.NoError(t, err)
+
+	t.Run("unknown key", func(t *testing.T) {
+		_, err := Decrypt(newKeys(t, newKey), data)
+		requireDecryptionError(t, err, UnknownKey)
+	})
+
+	t.Run("wrong key material", func(t *testing.T) {
+		_, err := Decrypt(newKeys(t, Key{ID: "old", Material: newKey.Material}), data)
+		requireDecryptionError(t, err, AuthenticationFailed)
+	})
+
+	t.Run("tampered ciphertext", func(t *testing.T) {
+		var b blob
+		require.NoError(t, json.Unmarshal(data, &b))
+		b.Ciphertext[len(b.Ciphertext)-1] ^= 1
+		tampered, err := json.Marshal(b)
+		require.NoError(t, err)
+
+		_, err = Decrypt(newKeys(t, oldKey), tampered)
+		requireDecryptionError(t, err, AuthenticationFailed)
+	})
+
+	t.Run("tampered key ID", func(t *testing.T) {
+		var b blob
+		require.NoError(t, json.Unmarshal(data, &b))
+		b.KeyID = "new"
+		tampered, err := json.Marshal(b)
+		require.NoError(t, err)
+
+		_, err = Decrypt(newKeys(t, Key{ID: "new", Material: oldKey.Material}), tampered)
+		requireDecryptionError(t, err, AuthenticationFailed)
+	})
+
+	t.Run("plaintext", func(t *testing.T) {
+		_, err := Decrypt(newKeys(t, oldKey), []byte(`{"endpoint":"35.1.2.3"}`))
+		requireDecryptionError(t, err, MalformedBlob)
+
+		_, err = Decrypt(newKeys(t, oldKey), []byte("secret"))
+		requireDecryptionError(t, err, MalformedBlob)
+	})
+
+	t.Run("newer version", func(t *testing.T) {
+		var b blob
+		require.NoError(t, json.Unmarshal(data, &b))
+		b.Version = blobVersion + 1
+		newer, err := json.Marshal(b)
+		require.NoError(t, err)
+
+		_, err = Decrypt(newKeys(t, oldKey), newer)
+		requireDecryptionError(t, err, UnsupportedVersion)
+	})
+}
+
+func TestRotate(t *testing.T) {
+	data, err := Encrypt(newKeys(t, oldKey), []byte("secret"))
+	require.NoError(t, err)
+
+	keys := newKeys(t, newKey, oldKey)
+	rotated, err := Rotate(keys, data)
+	require.NoError(t, err)
+
+	id, err := KeyID(rotated)
+	require.NoError(t, err)
+	require.Equal(t, "new", id)
+
+	decrypted, err := Decrypt(newKeys(t, newKey), rotated)
+	require.NoError(t, err, "The rotated blob should not need the previous key anymore")
+	require.Equal(t, []byte("secret"), decrypted)
+
+	again, err := Rotate(keys, rotated)
+	require.NoError(t, err)
+	require.Equal(t, rotated, again, "A blob encrypted with the current key should be left as is")
+
+	_, err = Rotate(newKeys(t, newKey), data)
+	requireDecryptionError(t, err, UnknownKey)
+}
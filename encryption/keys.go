@@ -0,0 +1,147 @@
+package encryption
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+)
+
+// KeyProvider provides the key encryption keys.
+type KeyProvider interface {
+	// CurrentKey returns the key new blobs are encrypted with.
+	CurrentKey() (Key, error)
+	// Key returns the key with the given ID, to decrypt blobs encrypted with it.
+	Key(id string) (Key, error)
+}
+
+// Key is a key encryption key.
+type Key struct {
+	// ID identifies the key. It is stored in the blobs encrypted with the key, so it must not be secret.
+	ID string
+	// Material is the AES key. It must be 16, 24, or 32 bytes long.
+	Material []byte
+}
+
+func (k Key) validate() error {
+	if k.ID == "" {
+		return errors.New("the key ID cannot be empty")
+	}
+	switch len(k.Material) {
+	case 16, 24, 32:
+		return nil
+	default:
+		return errors.Errorf("the key %q must be 16, 24, or 32 bytes long, not %d", k.ID, len(k.Material))
+	}
+}
+
+// StaticKeyProvider is a KeyProvider with a fixed set of keys.
+type StaticKeyProvider struct {
+	current Key
+	keys    map[string]Key
+}
+
+// NewStaticKeyProvider creates a StaticKeyProvider encrypting with the current key. The previous keys are only used to decrypt the blobs encrypted before a rotation.
+func NewStaticKeyProvider(current Key, previous ...Key) (*StaticKeyProvider, error) {
+	p := &StaticKeyProvider{
+		current: current,
+		keys:    map[string]Key{},
+	}
+	for _, k := range append(previous, current) {
+		if err := k.validate(); err != nil {
+			return nil, err
+		}
+		p.keys[k.ID] = k
+	}
+	return p, nil
+}
+
+// CurrentKey returns the current key.
+func (p *StaticKeyProvider) CurrentKey() (Key, error) {
+	return p.current, nil
+}
+
+// Key returns the key with the given ID.
+func (p *StaticKeyProvider) Key(id string) (Key, error) {
+	k, ok := p.keys[id]
+	if !ok {
+		return Key{}, fmt.Errorf("key %q not found", id)
+	}
+	return k, nil
+}
+
+// FileKeyProvider is a KeyProvider reading the keys from a JSON file, for example a mounted Kubernetes Secret:
+//
+//	{
+//	  "current": "2019-11",
+//	  "keys": {
+//	    "2019-10": "<base64-encoded key>",
+//	    "2019-11": "<base64-encoded key>"
+//	  }
+//	}
+//
+// The file is read on every call, so keys can be rotated by updating the file, without restarting the process.
+type FileKeyProvider struct {
+	path string
+}
+
+type keyFile struct {
+	Current string            `json:"current"`
+	Keys    map[string]string `json:"keys"`
+}
+
+// NewFileKeyProvider creates a FileKeyProvider reading the keys from the given file.
+func NewFileKeyProvider(path string) *FileKeyProvider {
+	return &FileKeyProvider{path: path}
+}
+
+// CurrentKey returns the key marked as current in the file.
+func (p *FileKeyProvider) CurrentKey() (Key, error) {
+	keys, err := p.read()
+	if err != nil {
+		return Key{}, err
+	}
+	return keys.CurrentKey()
+}
+
+// Key returns the key with the given ID from the file.
+func (p *FileKeyProvider) Key(id string) (Key, error) {
+	keys, err := p.read()
+	if err != nil {
+		return Key{}, err
+	}
+	return keys.Key(id)
+}
+
+func (p *FileKeyProvider) read() (*StaticKeyProvider, error) {
+	data, err := ioutil.ReadFile(p.path)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read the key file")
+	}
+
+	var f keyFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, errors.Wrap(err, "unable to parse the key file")
+	}
+
+	var current Key
+	var previous []Key
+	for id, encoded := range f.Keys {
+		material, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to decode the key %q", id)
+		}
+		if id == f.Current {
+			current = Key{ID: id, Material: material}
+			continue
+		}
+		previous = append(previous, Key{ID: id, Material: material})
+	}
+	if current.ID == "" {
+		return nil, errors.Errorf("the current key %q is not in the key file", f.Current)
+	}
+
+	return NewStaticKeyProvider(current, previous...)
+}
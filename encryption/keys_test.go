@@ -0,0 +1,70 @@
+package encryption
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewStaticKeyProvider(t *testing.T) {
+	_, err := NewStaticKeyProvider(Key{ID: "short", Material: []byte("too short")})
+	require.Error(t, err)
+
+	_, err = NewStaticKeyProvider(oldKey, Key{Material: oldKey.Material})
+	require.Error(t, err, "Keys without an ID should be refused")
+
+	keys, err := NewStaticKeyProvider(newKey, oldKey)
+	require.NoError(t, err)
+
+	current, err := keys.CurrentKey()
+	require.NoError(t, err)
+	require.Equal(t, newKey, current)
+
+	previous, err := keys.Key("old")
+	require.NoError(t, err)
+	require.Equal(t, oldKey, previous)
+
+	_, err = keys.Key("missing")
+	require.Error(t, err)
+}
+
+func TestFileKeyProvider(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hydroform-keys")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "keys.json")
+	writeKeys := func(current string) {
+		content := fmt.Sprintf(`{"current": %q, "keys": {"old": %q, "new": %q}}`, current,
+			base64.StdEncoding.EncodeToString(oldKey.Material), base64.StdEncoding.EncodeToString(newKey.Material))
+		require.NoError(t, ioutil.WriteFile(path, []byte(content), 0600))
+	}
+
+	keys := NewFileKeyProvider(path)
+	_, err = keys.CurrentKey()
+	require.Error(t, err, "A missing key file should fail")
+
+	writeKeys("old")
+	data, err := Encrypt(keys, []byte("secret"))
+	require.NoError(t, err)
+
+	writeKeys("new")
+	current, err := keys.CurrentKey()
+	require.NoError(t, err)
+	require.Equal(t, newKey, current, "Changes to the file should be picked up")
+
+	rotated, err := Rotate(keys, data)
+	require.NoError(t, err)
+	decrypted, err := Decrypt(keys, rotated)
+	require.NoError(t, err)
+	require.Equal(t, []byte("secret"), decrypted)
+
+	writeKeys("missing")
+	_, err = keys.CurrentKey()
+	require.Error(t, err, "A current key which is not in the file should fail")
+}
@@ -34,17 +34,17 @@ func main() {
 		Type:                types.Gardener,
 		ProjectName:         *projectName,
 		CredentialsFilePath: *credentials,
-		CustomConfigurations: map[string]interface{}{
-			"target_provider": "gcp",
-			"target_seed":     "gcp-eu1",
-			"target_secret":   *secret,
-			"disk_type":       "pd-standard",
-			"zone":            "europe-west4-b",
-			"workercidr":      "10.250.0.0/19",
-			"autoscaler_min":  2,
-			"autoscaler_max":  4,
-			"max_surge":       4,
-			"max_unavailable": 1,
+		Gardener: &types.GardenerConfig{
+			TargetProvider: types.GCP,
+			TargetSeed:     "gcp-eu1",
+			TargetSecret:   *secret,
+			DiskType:       "pd-standard",
+			WorkerCIDR:     "10.250.0.0/19",
+			AutoscalerMin:  2,
+			AutoscalerMax:  4,
+			MaxSurge:       4,
+			MaxUnavailable: 1,
+			GCP:            &types.GardenerGCPConfig{Zone: "europe-west4-b"},
 		},
 	}
 
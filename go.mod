@@ -12,11 +12,12 @@ require (
 	github.com/gardener/gardener v0.0.0-20190906111529-f9ad04069615
 	github.com/hashicorp/terraform v0.11.14
 	github.com/kyma-incubator/terraform-provider-gardener v0.0.0-20191024084317-100e0f88e4cf
-	github.com/pkg/errors v0.8.1
+	github.com/pkg/errors v0.9.1
 	github.com/stretchr/testify v1.4.0
 	github.com/terraform-providers/terraform-provider-google v1.20.1-0.20190430222256-f9a9636be7cd
 	github.com/terraform-providers/terraform-provider-null v1.0.0
 	google.golang.org/api v0.11.0
+	k8s.io/api v0.0.0-20190409021203-6e4e0e4f393b
 	k8s.io/apimachinery v0.0.0-20190404173353-6a84e37a896d
 	k8s.io/client-go v11.0.1-0.20190409021438-1a26190bd76a+incompatible
 )
@@ -1,147 +1,242 @@
 package hydroform
 
 import (
-	"errors"
+	"context"
+	"encoding/json"
 
-	"github.com/kyma-incubator/hydroform/action"
-
-	"github.com/kyma-incubator/hydroform/internal/gardener"
-
-	"github.com/kyma-incubator/hydroform/internal/gcp"
-	"github.com/kyma-incubator/hydroform/internal/operator"
 	"github.com/kyma-incubator/hydroform/types"
 )
 
-const provisioningOperator = operator.TerraformOperator
-
 // Provisioner is the Hydroform interface that groups Provision, Status, Credentials, and Deprovision functions used to create and manage a cluster.
+// Every function receives a context which, once cancelled or expired, aborts the ongoing calls to the provider.
 type Provisioner interface {
-	Provision(cluster *types.Cluster, provider *types.Provider) (*types.Cluster, error)
-	Status(cluster *types.Cluster, provider *types.Provider) (*types.ClusterStatus, error)
-	Credentials(cluster *types.Cluster, provider *types.Provider) ([]byte, error)
-	Deprovision(cluster *types.Cluster, provider *types.Provider) error
+	Provision(ctx context.Context, cluster *types.Cluster, provider *types.Provider) (*types.Cluster, error)
+	Status(ctx context.Context, cluster *types.Cluster, provider *types.Provider) (*types.ClusterStatus, error)
+	Credentials(ctx context.Context, cluster *types.Cluster, provider *types.Provider) ([]byte, error)
+	Deprovision(ctx context.Context, cluster *types.Cluster, provider *types.Provider) error
 }
 
-// Provision creates a new cluster for a given provider based on specific cluster and provider parameters. It returns a cluster object enriched with information from the provider, such as the IP address or the connection endpoint. This object is necessary for the other operations, such as retrieving the cluster status or deprovisioning the cluster. If the cluster cannot be created, the function returns an error.
-func Provision(cluster *types.Cluster, provider *types.Provider) (*types.Cluster, error) {
-	var err error
-	var cl *types.Cluster
+// Updater is implemented by provisioners that can change existing clusters in place. Changes that would recreate the cluster must be refused with a ReplacementError unless allowReplacement is true.
+type Updater interface {
+	Update(ctx context.Context, cluster *types.Cluster, provider *types.Provider, allowReplacement bool) (*types.Cluster, error)
+}
 
-	if err = action.Before(); err != nil {
-		return cl, err
-	}
+// Planner is implemented by provisioners that can preview the changes of Provision and Deprovision without applying them.
+type Planner interface {
+	Plan(ctx context.Context, cluster *types.Cluster, provider *types.Provider) (*types.Plan, error)
+	PlanDeprovision(ctx context.Context, cluster *types.Cluster, provider *types.Provider) (*types.Plan, error)
+}
 
-	switch provider.Type {
-	case types.GCP:
-		cl, err = newGCPProvisioner(provisioningOperator).Provision(cluster, provider)
-	case types.Gardener:
-		cl, err = newGardenerProvisioner(provisioningOperator).Provision(cluster, provider)
-	case types.AWS:
-		err = errors.New("aws not supported yet")
-	case types.Azure:
-		err = errors.New("azure not supported yet")
-	default:
-		err = errors.New("unknown provider")
-	}
+// DriftDetector is implemented by provisioners that can compare a cluster with its specification and its stored state without changing anything.
+type DriftDetector interface {
+	Drift(ctx context.Context, cluster *types.Cluster, provider *types.Provider) (*types.DriftReport, error)
+}
 
-	if err != nil {
-		return cl, err
-	}
-	return cl, action.After()
+// Importer is implemented by provisioners that can adopt existing clusters that were not provisioned by Hydroform.
+type Importer interface {
+	Import(ctx context.Context, cluster *types.Cluster, provider *types.Provider) (*types.Cluster, error)
 }
 
-// Status returns the cluster status for a given provider, or an error if providing the status is not possible. The possible status values are defined in the ClusterStatus type.
-func Status(cluster *types.Cluster, provider *types.Provider) (*types.ClusterStatus, error) {
-	var err error
-	var cs *types.ClusterStatus
+// Lister is implemented by provisioners that can enumerate the clusters of a provider project. If labels are given, only the clusters carrying all of them must be returned.
+type Lister interface {
+	List(ctx context.Context, provider *types.Provider, labels map[string]string) ([]*types.Cluster, error)
+}
 
-	if err = action.Before(); err != nil {
-		return cs, err
-	}
+// Validator is implemented by provisioners that can check the cluster and provider specification without calling the provider. Invalid specifications must be reported with a ValidationError.
+type Validator interface {
+	Validate(cluster *types.Cluster, provider *types.Provider) error
+}
 
-	switch provider.Type {
-	case types.GCP:
-		cs, err = newGCPProvisioner(provisioningOperator).Status(cluster, provider)
-	case types.Gardener:
-		cs, err = newGardenerProvisioner(provisioningOperator).Status(cluster, provider)
-	case types.AWS:
-		err = errors.New("aws not supported yet")
-	case types.Azure:
-		err = errors.New("azure not supported yet")
-	default:
-		err = errors.New("unknown provider")
-	}
+// Describer is implemented by provisioners that declare the custom configurations they accept.
+type Describer interface {
+	Describe() *types.ProviderSchema
+}
 
-	if err != nil {
-		return cs, err
-	}
-	return cs, action.After()
+// MachineTypeLister is implemented by provisioners that can list the machine types offered in a location.
+type MachineTypeLister interface {
+	MachineTypes(ctx context.Context, provider *types.Provider, location string) ([]types.MachineType, error)
+}
+
+// Defaulter is implemented by provisioners that fill in the unset fields of the specification with the defaults of the provider. Default changes the given cluster and provider, which are copies owned by the caller.
+type Defaulter interface {
+	Default(cluster *types.Cluster, provider *types.Provider)
+}
+
+// Provision creates a new cluster for a given provider based on specific cluster and provider parameters. It returns a cluster object enriched with information from the provider, such as the IP address or the connection endpoint. This object is necessary for the other operations, such as retrieving the cluster status or deprovisioning the cluster. The ClusterInfo and the resolved Kubernetes version are also set on the given cluster, so it can be used the same way. If the cluster cannot be created, the function returns an error.
+func Provision(cluster *types.Cluster, provider *types.Provider) (*types.Cluster, error) {
+	return ProvisionContext(context.Background(), cluster, provider)
+}
+
+// ProvisionContext works like Provision. Cancelling the context stops the ongoing provisioning. In such case, the returned cluster still holds the state reached so far.
+func ProvisionContext(ctx context.Context, cluster *types.Cluster, provider *types.Provider) (*types.Cluster, error) {
+	return defaultClient.Provision(ctx, cluster, provider)
+}
+
+// Status returns the cluster status for a given provider, or an error if providing the status is not possible. The possible status values are defined in the ClusterStatus type.
+func Status(cluster *types.Cluster, provider *types.Provider) (*types.ClusterStatus, error) {
+	return StatusContext(context.Background(), cluster, provider)
+}
+
+// StatusContext works like Status. The context can be used to set a deadline for, or cancel, the call to the provider.
+func StatusContext(ctx context.Context, cluster *types.Cluster, provider *types.Provider) (*types.ClusterStatus, error) {
+	return defaultClient.Status(ctx, cluster, provider)
 }
 
 // Credentials returns the kubeconfig for a specific cluster as a byte array.
 func Credentials(cluster *types.Cluster, provider *types.Provider) ([]byte, error) {
-	var err error
-	var cr []byte
-
-	if err = action.Before(); err != nil {
-		return cr, err
-	}
-	switch provider.Type {
-	case types.GCP:
-		cr, err = newGCPProvisioner(provisioningOperator).Credentials(cluster, provider)
-	case types.Gardener:
-		cr, err = newGardenerProvisioner(provisioningOperator).Credentials(cluster, provider)
-	case types.AWS:
-		err = errors.New("aws not supported yet")
-	case types.Azure:
-		err = errors.New("azure not supported yet")
-	default:
-		err = errors.New("unknown provider")
-	}
+	return CredentialsContext(context.Background(), cluster, provider)
+}
 
-	if err != nil {
-		return cr, err
-	}
-	return cr, action.After()
+// CredentialsContext works like Credentials. The context can be used to set a deadline for, or cancel, the call to the provider.
+func CredentialsContext(ctx context.Context, cluster *types.Cluster, provider *types.Provider) ([]byte, error) {
+	return defaultClient.Credentials(ctx, cluster, provider)
 }
 
 // Deprovision removes an existing cluster along or returns an error if removing the cluster is not possible.
 func Deprovision(cluster *types.Cluster, provider *types.Provider) error {
-	var err error
+	return DeprovisionContext(context.Background(), cluster, provider)
+}
 
-	if err = action.Before(); err != nil {
-		return err
-	}
-	switch provider.Type {
-	case types.GCP:
-		err = newGCPProvisioner(provisioningOperator).Deprovision(cluster, provider)
-	case types.Gardener:
-		err = newGardenerProvisioner(provisioningOperator).Deprovision(cluster, provider)
-	case types.AWS:
-		err = errors.New("aws not supported yet")
-	case types.Azure:
-		err = errors.New("azure not supported yet")
-	default:
-		err = errors.New("unknown provider")
+// DeprovisionContext works like Deprovision. Cancelling the context stops the ongoing deprovisioning.
+func DeprovisionContext(ctx context.Context, cluster *types.Cluster, provider *types.Provider) error {
+	return defaultClient.Deprovision(ctx, cluster, provider)
+}
+
+// UpdateOption configures an Update.
+type UpdateOption func(o *updateOptions)
+
+type updateOptions struct {
+	allowReplacement bool
+}
+
+// AllowReplacement lets Update apply changes that destroy and recreate the cluster, for example changing the location of a GKE cluster.
+func AllowReplacement() UpdateOption {
+	return func(o *updateOptions) {
+		o.allowReplacement = true
 	}
-	if err != nil {
-		return err
+}
+
+// Update applies changes of the cluster specification, such as NodeCount, MachineType or KubernetesVersion, to a cluster returned by Provision. It returns the cluster with updated ClusterInfo, which is also set on the given cluster.
+// Changes that would recreate the cluster are refused with a ReplacementError before anything is applied, unless the AllowReplacement option is given.
+func Update(cluster *types.Cluster, provider *types.Provider, opts ...UpdateOption) (*types.Cluster, error) {
+	return UpdateContext(context.Background(), cluster, provider, opts...)
+}
+
+// UpdateContext works like Update. Cancelling the context stops the ongoing update. In such case, the returned cluster still holds the state reached so far.
+func UpdateContext(ctx context.Context, cluster *types.Cluster, provider *types.Provider, opts ...UpdateOption) (*types.Cluster, error) {
+	return defaultClient.Update(ctx, cluster, provider, opts...)
+}
+
+// Plan returns the changes Provision would make for the given cluster and provider, without applying them. If the cluster holds the state of a previous provisioning, the changes are computed against it.
+func Plan(cluster *types.Cluster, provider *types.Provider) (*types.Plan, error) {
+	return PlanContext(context.Background(), cluster, provider)
+}
+
+// PlanContext works like Plan. The context can be used to set a deadline for, or cancel, the planning.
+func PlanContext(ctx context.Context, cluster *types.Cluster, provider *types.Provider) (*types.Plan, error) {
+	return defaultClient.Plan(ctx, cluster, provider)
+}
+
+// PlanDeprovision returns the changes Deprovision would make for the given cluster and provider, without applying them.
+func PlanDeprovision(cluster *types.Cluster, provider *types.Provider) (*types.Plan, error) {
+	return PlanDeprovisionContext(context.Background(), cluster, provider)
+}
+
+// PlanDeprovisionContext works like PlanDeprovision. The context can be used to set a deadline for, or cancel, the planning.
+func PlanDeprovisionContext(ctx context.Context, cluster *types.Cluster, provider *types.Provider) (*types.Plan, error) {
+	return defaultClient.PlanDeprovision(ctx, cluster, provider)
+}
+
+// Import adopts an existing cluster that was not created with Hydroform. The cluster is looked up with the provider by its name and, depending on the provider, its location or project. It returns the cluster with ClusterInfo built from the live cluster, which is also set on the given cluster, so that it can be used with Status, Credentials, Update or Deprovision as if it was returned by Provision.
+// The cluster specification should describe the existing cluster. Any difference is reported by Plan and applied by the next Update.
+func Import(cluster *types.Cluster, provider *types.Provider) (*types.Cluster, error) {
+	return ImportContext(context.Background(), cluster, provider)
+}
+
+// ImportContext works like Import. The context can be used to set a deadline for, or cancel, the calls to the provider.
+func ImportContext(ctx context.Context, cluster *types.Cluster, provider *types.Provider) (*types.Cluster, error) {
+	return defaultClient.Import(ctx, cluster, provider)
+}
+
+// Drift refreshes the state of a cluster returned by Provision from the live cluster, and reports the attributes that differ between the cluster specification, the stored state and the live cluster, for example a node pool resized in the console of the provider.
+// Nothing is applied, and the state held by the cluster is left untouched. Use Update to bring the live cluster back to the specification.
+func Drift(cluster *types.Cluster, provider *types.Provider) (*types.DriftReport, error) {
+	return DriftContext(context.Background(), cluster, provider)
+}
+
+// DriftContext works like Drift. The context can be used to set a deadline for, or cancel, the calls to the provider.
+func DriftContext(ctx context.Context, cluster *types.Cluster, provider *types.Provider) (*types.DriftReport, error) {
+	return defaultClient.Drift(ctx, cluster, provider)
+}
+
+// ListOption configures a List.
+type ListOption func(o *listOptions)
+
+type listOptions struct {
+	labels map[string]string
+}
+
+// MatchLabels only lists the clusters that carry all of the given labels. For GCP, these are the resource labels of the cluster. For Gardener, these are the labels of the Shoot. It can be given several times to add more labels.
+func MatchLabels(labels map[string]string) ListOption {
+	return func(o *listOptions) {
+		if o.labels == nil {
+			o.labels = map[string]string{}
+		}
+		for k, v := range labels {
+			o.labels[k] = v
+		}
 	}
-	return action.After()
 }
 
-func newGCPProvisioner(operatorType operator.Type) Provisioner {
-	return gcp.New(operatorType)
+// List returns the clusters of the provider project, with their Name, Location, KubernetesVersion, NodeCount and status filled in. GCP clusters are listed across all locations of the project, Gardener clusters are the Shoots in the `garden-<project>` namespace.
+// The listed clusters hold no internal state. To deprovision them, load their state from a state store, or import them first.
+// If some locations cannot be listed, the clusters found in the others are returned along with an *types.IncompleteListError.
+func List(provider *types.Provider, opts ...ListOption) ([]*types.Cluster, error) {
+	return ListContext(context.Background(), provider, opts...)
+}
+
+// ListContext works like List. The context can be used to set a deadline for, or cancel, the calls to the provider.
+func ListContext(ctx context.Context, provider *types.Provider, opts ...ListOption) ([]*types.Cluster, error) {
+	return defaultClient.List(ctx, provider, opts...)
 }
 
-func newGardenerProvisioner(operatorType operator.Type) Provisioner {
-	return gardener.New(operatorType)
+// MachineTypes returns the machine types the provider offers in the location, for example to check a machine type before provisioning. GCP lists the machine types of the compute API in the zone, or in all zones of the region. Gardener lists the usable machine types of the cloud profile of the target provider, which are the same in all locations.
+// The machine types are cached for an hour. The operations which apply the specification reject machine types that are not offered in the location of the cluster with a ValidationError. Clients created with WithMachineTypeCatalog use their catalog instead.
+func MachineTypes(provider *types.Provider, location string) ([]types.MachineType, error) {
+	return MachineTypesContext(context.Background(), provider, location)
 }
 
-func newAWSProvisioner(operatorType operator.Type) Provisioner {
-	return nil
+// MachineTypesContext works like MachineTypes. The context can be used to set a deadline for, or cancel, the calls to the provider.
+func MachineTypesContext(ctx context.Context, provider *types.Provider, location string) ([]types.MachineType, error) {
+	return defaultClient.MachineTypes(ctx, provider, location)
 }
 
-func newAzureProvisioner(operatorType operator.Type) Provisioner {
-	return nil
+// Default returns copies of the cluster and provider with the defaults of the provider applied to the fields that are not set, such as the disk size, the disk type of the Gardener target provider, the surge settings, and the Kubernetes version. The given cluster and provider are not changed.
+// Validate and every operation on a cluster apply the defaults themselves, so Default is only needed to see what would actually be provisioned.
+func Default(cluster *types.Cluster, provider *types.Provider) (*types.Cluster, *types.Provider, error) {
+	return defaultClient.Default(cluster, provider)
+}
+
+// Validate checks the cluster and provider specification with the rules of the provider, without calling the provider or running any actions. The defaults of the provider are applied first. As the provider is not called, the Kubernetes version and the machine types are only checked by the operations which apply the specification. If the specification is not valid, the returned error is a ValidationError listing every invalid field.
+func Validate(cluster *types.Cluster, provider *types.Provider) error {
+	return defaultClient.Validate(cluster, provider)
+}
+
+// DescribeProvider returns the schema of the custom configurations the provider accepts, with the type, default, allowed values, and description of every key, for example to render a form for them.
+func DescribeProvider(providerType types.ProviderType) (*types.ProviderSchema, error) {
+	return defaultClient.Describe(providerType)
+}
+
+// MarshalClusterInfo encodes the ClusterInfo returned by Provision as JSON, so that the cluster can be managed by another process or after a restart. The internal state is encoded in a versioned format, which UnmarshalClusterInfo reads back in later versions of Hydroform.
+func MarshalClusterInfo(info *types.ClusterInfo) ([]byte, error) {
+	return json.Marshal(info)
+}
+
+// UnmarshalClusterInfo decodes a ClusterInfo encoded with MarshalClusterInfo. An internal state saved by an older version of Hydroform is upgraded to the current format.
+func UnmarshalClusterInfo(data []byte) (*types.ClusterInfo, error) {
+	info := &types.ClusterInfo{}
+	if err := json.Unmarshal(data, info); err != nil {
+		return nil, err
+	}
+	return info, nil
 }
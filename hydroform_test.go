@@ -0,0 +1,38 @@
+package hydroform
+
+import (
+	"testing"
+
+	"github.com/kyma-incubator/hydroform/internal/terraform"
+	"github.com/kyma-incubator/hydroform/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshalClusterInfo(t *testing.T) {
+	info := &types.ClusterInfo{
+		Endpoint:                 "35.1.2.3",
+		CertificateAuthorityData: []byte("My cert"),
+		InternalState: &types.InternalState{
+			Operator:       string(TerraformOperator),
+			Provider:       types.GCP,
+			TerraformState: terraform.NewState(),
+		},
+		Status:  &types.ClusterStatus{Phase: types.Provisioned},
+		Outputs: map[string]interface{}{"master_version": "1.14.8-gke.12"},
+	}
+
+	data, err := MarshalClusterInfo(info)
+	require.NoError(t, err)
+
+	decoded, err := UnmarshalClusterInfo(data)
+	require.NoError(t, err)
+	require.Equal(t, info.Endpoint, decoded.Endpoint)
+	require.Equal(t, info.CertificateAuthorityData, decoded.CertificateAuthorityData)
+	require.Equal(t, info.Status, decoded.Status)
+	require.Equal(t, info.Outputs, decoded.Outputs)
+	require.Equal(t, info.InternalState.Provider, decoded.InternalState.Provider)
+	require.True(t, info.InternalState.TerraformState.Equal(decoded.InternalState.TerraformState))
+
+	_, err = UnmarshalClusterInfo([]byte(`{"internalState": {"version": 99}}`))
+	require.Error(t, err, "States from newer versions of Hydroform should be refused")
+}
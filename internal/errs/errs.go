@@ -1,8 +1,8 @@
 package errs
 
 const (
-	CannotBeEmpty    = "\n - %s cannot be empty"
-	CannotBeLess     = "\n - %s cannot be less than %v"
-	Custom           = "\n - %v"
+	CannotBeEmpty    = "cannot be empty"
+	CannotBeLess     = "cannot be less than %v"
+	MustBeOneOf      = "has to be one of: %s"
 	EmptyClusterInfo = "Cluster.ClusterInfo cannot be empty. Please provide the Cluster object returned from the Provision function."
 )
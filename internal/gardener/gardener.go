@@ -1,24 +1,49 @@
 package gardener
 
 import (
+	"context"
 	"fmt"
+	"net/http"
 	"regexp"
+	"sort"
+	"strings"
+	"time"
 
 	gardener_core "github.com/gardener/gardener/pkg/apis/core/v1alpha1"
 	gardener_types "github.com/gardener/gardener/pkg/apis/garden/v1beta1"
 	gardener_api "github.com/gardener/gardener/pkg/client/garden/clientset/versioned/typed/garden/v1beta1"
 	"k8s.io/client-go/tools/clientcmd"
 
+	"github.com/kyma-incubator/hydroform/catalog"
 	"github.com/kyma-incubator/hydroform/internal/errs"
 	"github.com/kyma-incubator/hydroform/internal/operator"
+	"github.com/kyma-incubator/hydroform/internal/versions"
 	"github.com/kyma-incubator/hydroform/types"
 	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	apivalidation "k8s.io/apimachinery/pkg/api/validation"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	metav1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+	k8slabels "k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 )
 
 type gardenerProvisioner struct {
 	operator operator.Operator
+	// newClients creates the clients of the Gardener cluster. If nil, the clients are created from the kubeconfig file of the provider.
+	newClients func(ctx context.Context, kubeconfigPath string) (*clients, error)
+	// catalog lists the machine types offered in a location. If nil, they are read from the cloud profile of the target provider and cached.
+	catalog catalog.Catalog
+}
+
+// clients groups the clients needed to talk to the Gardener cluster.
+type clients struct {
+	gardener   gardener_api.GardenV1beta1Interface
+	kubernetes kubernetes.Interface
 }
 
 func New(operatorType operator.Type) *gardenerProvisioner {
@@ -34,171 +59,808 @@ func New(operatorType operator.Type) *gardenerProvisioner {
 	}
 }
 
-func (g *gardenerProvisioner) Provision(cluster *types.Cluster, provider *types.Provider) (*types.Cluster, error) {
+// SetMachineTypeCatalog makes the provisioner list the machine types from the catalog instead of the cloud profiles.
+func (g *gardenerProvisioner) SetMachineTypeCatalog(machineTypes catalog.Catalog) {
+	g.catalog = machineTypes
+}
+
+func (g *gardenerProvisioner) Provision(ctx context.Context, cluster *types.Cluster, provider *types.Provider) (*types.Cluster, error) {
 	if err := g.validate(cluster, provider); err != nil {
 		return nil, err
 	}
+	if err := g.resolveKubernetesVersion(ctx, cluster, provider); err != nil {
+		return nil, err
+	}
+	if err := g.validateMachineTypes(ctx, cluster, provider); err != nil {
+		return nil, err
+	}
 
 	config := g.loadConfigurations(cluster, provider)
 
-	clusterInfo, err := g.operator.Create(provider.Type, config)
+	clusterInfo, err := g.operator.Create(ctx, provider.Type, config)
+	// keep the state reached so far, so that a failed provisioning can be cleaned up
+	if clusterInfo != nil {
+		cluster.ClusterInfo = clusterInfo
+		versions.Store(cluster)
+	}
 	if err != nil {
 		return cluster, errors.Wrap(err, "unable to provision gardener cluster")
 	}
-	cluster.ClusterInfo = clusterInfo
+	if err := g.readLiveOutputs(ctx, cluster, provider); err != nil {
+		return cluster, errors.Wrap(err, "unable to read the outputs of gardener cluster")
+	}
 	return cluster, nil
 }
 
-func (g *gardenerProvisioner) Status(cluster *types.Cluster, provider *types.Provider) (*types.ClusterStatus, error) {
+func (g *gardenerProvisioner) Status(ctx context.Context, cluster *types.Cluster, provider *types.Provider) (*types.ClusterStatus, error) {
 	if err := g.validate(cluster, provider); err != nil {
 		return nil, err
 	}
 
-	c, err := clientcmd.BuildConfigFromFlags("", provider.CredentialsFilePath)
+	shoot, err := g.shoot(ctx, cluster, provider)
 	if err != nil {
 		return nil, err
 	}
 
-	gardenerClient, err := gardener_api.NewForConfig(c)
+	return &types.ClusterStatus{
+		Phase:       convertGardenertatus(shoot.Status),
+		Labels:      shoot.Labels,
+		Annotations: shoot.Annotations,
+	}, nil
+}
+
+func (g *gardenerProvisioner) Credentials(ctx context.Context, cluster *types.Cluster, provider *types.Provider) ([]byte, error) {
+	if err := g.validate(cluster, provider); err != nil {
+		return nil, err
+	}
+
+	return g.kubeconfig(ctx, cluster, provider)
+}
+
+func (g *gardenerProvisioner) Deprovision(ctx context.Context, cluster *types.Cluster, provider *types.Provider) error {
+	if err := g.validate(cluster, provider); err != nil {
+		return err
+	}
+	if cluster.ClusterInfo == nil || cluster.ClusterInfo.InternalState == nil {
+		return errors.New(errs.EmptyClusterInfo)
+	}
+
+	config := g.loadConfigurations(cluster, provider)
+
+	err := g.operator.Delete(ctx, cluster.ClusterInfo.InternalState, provider.Type, config)
 	if err != nil {
+		return errors.Wrap(err, "unable to deprovision gardener cluster")
+	}
+
+	return nil
+}
+
+func (g *gardenerProvisioner) Update(ctx context.Context, cluster *types.Cluster, provider *types.Provider, allowReplacement bool) (*types.Cluster, error) {
+	if err := g.validate(cluster, provider); err != nil {
+		return nil, err
+	}
+	if cluster.ClusterInfo == nil || cluster.ClusterInfo.InternalState == nil {
+		return nil, errors.New(errs.EmptyClusterInfo)
+	}
+	if err := g.resolveKubernetesVersion(ctx, cluster, provider); err != nil {
+		return nil, err
+	}
+	if err := g.validateMachineTypes(ctx, cluster, provider); err != nil {
 		return nil, err
 	}
 
-	shoot, err := gardenerClient.Shoots(fmt.Sprintf("garden-%s", provider.ProjectName)).Get(cluster.Name, metav1.GetOptions{})
+	config := g.loadConfigurations(cluster, provider)
+
+	clusterInfo, err := g.operator.Update(ctx, cluster.ClusterInfo.InternalState, provider.Type, config, allowReplacement)
+	if clusterInfo != nil {
+		cluster.ClusterInfo = clusterInfo
+		versions.Store(cluster)
+	}
 	if err != nil {
+		return cluster, errors.Wrap(err, "unable to update gardener cluster")
+	}
+	if err := g.readLiveOutputs(ctx, cluster, provider); err != nil {
+		return cluster, errors.Wrap(err, "unable to read the outputs of gardener cluster")
+	}
+	return cluster, nil
+}
+
+func (g *gardenerProvisioner) Import(ctx context.Context, cluster *types.Cluster, provider *types.Provider) (*types.Cluster, error) {
+	if err := g.validate(cluster, provider); err != nil {
 		return nil, err
 	}
 
-	return &types.ClusterStatus{
+	shoot, err := g.shoot(ctx, cluster, provider)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to find gardener cluster")
+	}
+	// the shoot keeps its version if the specification matches it, instead of being upgraded to the newest match
+	if versions.Matches(cluster.KubernetesVersion, shoot.Spec.Kubernetes.Version) {
+		cluster.KubernetesVersion = shoot.Spec.Kubernetes.Version
+	} else if err := g.resolveKubernetesVersion(ctx, cluster, provider); err != nil {
+		return nil, err
+	}
+	if err := g.validateMachineTypes(ctx, cluster, provider); err != nil {
+		return nil, err
+	}
+	kubeconfig, err := g.kubeconfig(ctx, cluster, provider)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to get gardener cluster credentials")
+	}
+	endpoint, certificateData, err := clusterAccess(kubeconfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read gardener cluster credentials")
+	}
+
+	config := g.loadConfigurations(cluster, provider)
+
+	clusterInfo, err := g.operator.Import(ctx, provider.Type, config)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to import gardener cluster")
+	}
+	clusterInfo.Endpoint = endpoint
+	clusterInfo.CertificateAuthorityData = certificateData
+	clusterInfo.Status = &types.ClusterStatus{
 		Phase: convertGardenertatus(shoot.Status),
-	}, nil
+	}
+	setLiveOutputs(clusterInfo, shoot)
+
+	cluster.ClusterInfo = clusterInfo
+	versions.Store(cluster)
+	return cluster, nil
+}
+
+func (g *gardenerProvisioner) Plan(ctx context.Context, cluster *types.Cluster, provider *types.Provider) (*types.Plan, error) {
+	if err := g.validate(cluster, provider); err != nil {
+		return nil, err
+	}
+	if err := g.resolveKubernetesVersion(ctx, cluster, provider); err != nil {
+		return nil, err
+	}
+	if err := g.validateMachineTypes(ctx, cluster, provider); err != nil {
+		return nil, err
+	}
+
+	var state *types.InternalState
+	if cluster.ClusterInfo != nil {
+		state = cluster.ClusterInfo.InternalState
+	}
+	config := g.loadConfigurations(cluster, provider)
+
+	plan, err := g.operator.Plan(ctx, state, provider.Type, config, false)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to plan gardener cluster provisioning")
+	}
+	return plan, nil
 }
 
-func (g *gardenerProvisioner) Credentials(cluster *types.Cluster, provider *types.Provider) ([]byte, error) {
+func (g *gardenerProvisioner) PlanDeprovision(ctx context.Context, cluster *types.Cluster, provider *types.Provider) (*types.Plan, error) {
 	if err := g.validate(cluster, provider); err != nil {
 		return nil, err
 	}
+	if cluster.ClusterInfo == nil || cluster.ClusterInfo.InternalState == nil {
+		return nil, errors.New(errs.EmptyClusterInfo)
+	}
 
-	config, err := clientcmd.BuildConfigFromFlags("", provider.CredentialsFilePath)
+	config := g.loadConfigurations(cluster, provider)
+
+	plan, err := g.operator.Plan(ctx, cluster.ClusterInfo.InternalState, provider.Type, config, true)
 	if err != nil {
+		return nil, errors.Wrap(err, "unable to plan gardener cluster deprovisioning")
+	}
+	return plan, nil
+}
+
+// Drift compares the cluster specification, its stored state and the live Shoot, without changing anything.
+func (g *gardenerProvisioner) Drift(ctx context.Context, cluster *types.Cluster, provider *types.Provider) (*types.DriftReport, error) {
+	if err := g.validate(cluster, provider); err != nil {
+		return nil, err
+	}
+	if cluster.ClusterInfo == nil || cluster.ClusterInfo.InternalState == nil {
+		return nil, errors.New(errs.EmptyClusterInfo)
+	}
+	if err := g.resolveKubernetesVersion(ctx, cluster, provider); err != nil {
+		return nil, err
+	}
+	if err := g.validateMachineTypes(ctx, cluster, provider); err != nil {
 		return nil, err
 	}
 
+	config := g.loadConfigurations(cluster, provider)
+
+	report, err := g.operator.Drift(ctx, cluster.ClusterInfo.InternalState, provider.Type, config)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to detect gardener cluster drift")
+	}
+	return report, nil
+}
+
+// List returns the Shoots in the namespace of the project. If labels are given, only the Shoots with all of these labels are returned.
+func (g *gardenerProvisioner) List(ctx context.Context, provider *types.Provider, labels map[string]string) ([]*types.Cluster, error) {
+	if fieldErrs := validateProvider(provider); len(fieldErrs) > 0 {
+		return nil, &types.ValidationError{Errors: fieldErrs}
+	}
+
+	c, err := g.clients(ctx, provider)
+	if err != nil {
+		return nil, err
+	}
+
+	shoots, err := c.gardener.Shoots(fmt.Sprintf("garden-%s", provider.ProjectName)).List(metav1.ListOptions{
+		LabelSelector: k8slabels.SelectorFromSet(labels).String(),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list gardener clusters")
+	}
+
+	clusters := make([]*types.Cluster, 0, len(shoots.Items))
+	for i := range shoots.Items {
+		clusters = append(clusters, convertShoot(&shoots.Items[i]))
+	}
+	return clusters, nil
+}
+
+// clients returns the clients of the Gardener cluster bound to the given context.
+func (g *gardenerProvisioner) clients(ctx context.Context, provider *types.Provider) (*clients, error) {
+	if g.newClients != nil {
+		return g.newClients(ctx, provider.CredentialsFilePath)
+	}
+
+	config, err := newRestConfig(ctx, provider.CredentialsFilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	gardenerClient, err := gardener_api.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
 	k8s, err := kubernetes.NewForConfig(config)
 	if err != nil {
 		return nil, err
 	}
 
-	s, err := k8s.CoreV1().Secrets(fmt.Sprintf("garden-%s", provider.ProjectName)).Get(fmt.Sprintf("%s.kubeconfig", cluster.Name), metav1.GetOptions{})
+	return &clients{
+		gardener:   gardenerClient,
+		kubernetes: k8s,
+	}, nil
+}
+
+// shoot returns the Gardener Shoot of the cluster.
+func (g *gardenerProvisioner) shoot(ctx context.Context, cluster *types.Cluster, provider *types.Provider) (*gardener_types.Shoot, error) {
+	c, err := g.clients(ctx, provider)
 	if err != nil {
 		return nil, err
 	}
 
-	return s.Data["kubeconfig"], nil
+	shoot, err := c.gardener.Shoots(fmt.Sprintf("garden-%s", provider.ProjectName)).Get(cluster.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, &types.NotFoundError{Name: cluster.Name}
+	}
+	return shoot, err
 }
 
-func (g *gardenerProvisioner) Deprovision(cluster *types.Cluster, provider *types.Provider) error {
-	if err := g.validate(cluster, provider); err != nil {
+// readLiveOutputs adds the outputs of the live Shoot the Terraform provider does not expose to the cluster info.
+func (g *gardenerProvisioner) readLiveOutputs(ctx context.Context, cluster *types.Cluster, provider *types.Provider) error {
+	shoot, err := g.shoot(ctx, cluster, provider)
+	if err != nil {
 		return err
 	}
+	setLiveOutputs(cluster.ClusterInfo, shoot)
+	return nil
+}
+
+// setLiveOutputs adds the technical ID of the Shoot, which the Terraform provider does not expose, to the outputs of the cluster info.
+func setLiveOutputs(info *types.ClusterInfo, shoot *gardener_types.Shoot) {
+	if info.Outputs == nil {
+		info.Outputs = map[string]interface{}{}
+	}
+	info.Outputs["technical_id"] = shoot.Status.TechnicalID
+}
 
-	config := g.loadConfigurations(cluster, provider)
+// kubeconfig returns the kubeconfig Gardener generated for the cluster.
+func (g *gardenerProvisioner) kubeconfig(ctx context.Context, cluster *types.Cluster, provider *types.Provider) ([]byte, error) {
+	c, err := g.clients(ctx, provider)
+	if err != nil {
+		return nil, err
+	}
 
-	err := g.operator.Delete(cluster.ClusterInfo.InternalState, provider.Type, config)
+	s, err := c.kubernetes.CoreV1().Secrets(fmt.Sprintf("garden-%s", provider.ProjectName)).Get(fmt.Sprintf("%s.kubeconfig", cluster.Name), metav1.GetOptions{})
 	if err != nil {
-		return errors.Wrap(err, "unable to deprovision gardener cluster")
+		return nil, err
+	}
+
+	return s.Data["kubeconfig"], nil
+}
+
+// cloudProfile returns the Kubernetes versions and the machine types of the cloud profile of the target provider.
+func (g *gardenerProvisioner) cloudProfile(ctx context.Context, provider *types.Provider, targetProvider string) (gardener_types.KubernetesConstraints, []gardener_types.MachineType, error) {
+	c, err := g.clients(ctx, provider)
+	if err != nil {
+		return gardener_types.KubernetesConstraints{}, nil, err
+	}
+	profile, err := c.gardener.CloudProfiles().Get(profiles[targetProvider], metav1.GetOptions{})
+	if err != nil {
+		return gardener_types.KubernetesConstraints{}, nil, errors.Wrapf(err, "unable to get the cloud profile of %s", targetProvider)
+	}
+
+	switch {
+	case profile.Spec.GCP != nil:
+		return profile.Spec.GCP.Constraints.Kubernetes, profile.Spec.GCP.Constraints.MachineTypes, nil
+	case profile.Spec.AWS != nil:
+		return profile.Spec.AWS.Constraints.Kubernetes, profile.Spec.AWS.Constraints.MachineTypes, nil
+	case profile.Spec.Azure != nil:
+		return profile.Spec.Azure.Constraints.Kubernetes, profile.Spec.Azure.Constraints.MachineTypes, nil
+	}
+	return gardener_types.KubernetesConstraints{}, nil, errors.Errorf("the cloud profile of %s has no constraints for it", targetProvider)
+}
+
+// kubernetesVersions returns the versions the cloud profile of the target provider offers for new clusters. Expired versions are left out, as no clusters can be created with them.
+func (g *gardenerProvisioner) kubernetesVersions(ctx context.Context, provider *types.Provider, targetProvider string) (*versions.Supported, error) {
+	constraints, _, err := g.cloudProfile(ctx, provider, targetProvider)
+	if err != nil {
+		return nil, err
+	}
+
+	offered := map[string]bool{}
+	for _, v := range constraints.Versions {
+		offered[v] = true
+	}
+	for _, v := range constraints.OfferedVersions {
+		offered[v.Version] = v.ExpirationDate == nil || v.ExpirationDate.After(time.Now())
+	}
+
+	supported := &versions.Supported{}
+	for v, ok := range offered {
+		if ok {
+			supported.Versions = append(supported.Versions, v)
+		}
 	}
+	return supported, nil
+}
 
+// resolveKubernetesVersion replaces the Kubernetes version of the cluster, which can be an alias such as `1.15` or `latest`, with a version the cloud profile of the target provider offers. As cloud profiles have no default version, `default` stands for the newest one. An unsupported version is reported with a ValidationError.
+// The version stored for an existing cluster is kept as long as the specification matches it, see versions.Stored.
+func (g *gardenerProvisioner) resolveKubernetesVersion(ctx context.Context, cluster *types.Cluster, provider *types.Provider) error {
+	if version, ok := versions.Stored(cluster); ok {
+		cluster.KubernetesVersion = version
+		return nil
+	}
+	_, config, _ := customConfigurations(provider)
+	targetProvider, _ := config["target_provider"].(string)
+	supported, err := g.kubernetesVersions(ctx, provider, targetProvider)
+	if err != nil {
+		return err
+	}
+	version, ok := supported.Resolve(cluster.KubernetesVersion)
+	if !ok {
+		return &types.ValidationError{Errors: []types.FieldError{supported.FieldError(cluster.KubernetesVersion)}}
+	}
+	cluster.KubernetesVersion = version
 	return nil
 }
 
+// machineTypeCache keeps the machine types read from the cloud profiles for all provisioners.
+var machineTypeCache = catalog.NewCache(time.Hour)
+
+// MachineTypes returns the usable machine types of the cloud profile of the target provider. The cloud profile offers the same machine types in all locations.
+// Unless the provisioner has its own catalog, the machine types are cached for an hour.
+func (g *gardenerProvisioner) MachineTypes(ctx context.Context, provider *types.Provider, location string) ([]types.MachineType, error) {
+	fieldErrs := validateProvider(provider)
+	_, config, _ := customConfigurations(provider)
+	targetProvider, _ := config["target_provider"].(string)
+	if profiles[targetProvider] == "" {
+		fieldErrs = append(fieldErrs, types.FieldError{Field: "Provider.CustomConfigurations['target_provider']", Reason: fmt.Sprintf(errs.MustBeOneOf, "gcp, azure, aws"), Value: config["target_provider"]})
+	}
+	if len(fieldErrs) > 0 {
+		return nil, &types.ValidationError{Errors: fieldErrs}
+	}
+	if g.catalog != nil {
+		return g.catalog.MachineTypes(ctx, provider, location)
+	}
+
+	key := fmt.Sprintf("%s/%s", provider.CredentialsFilePath, profiles[targetProvider])
+	return machineTypeCache.Get(ctx, key, func(ctx context.Context) ([]types.MachineType, error) {
+		_, profileTypes, err := g.cloudProfile(ctx, provider, targetProvider)
+		if err != nil {
+			return nil, err
+		}
+		machineTypes := make([]types.MachineType, 0, len(profileTypes))
+		for _, machineType := range profileTypes {
+			if machineType.Usable != nil && !*machineType.Usable {
+				continue
+			}
+			machineTypes = append(machineTypes, types.MachineType{
+				Name:     machineType.Name,
+				CPUs:     int(machineType.CPU.Value()),
+				MemoryMB: int(machineType.Memory.Value() / (1 << 20)),
+				GPUs:     int(machineType.GPU.Value()),
+			})
+		}
+		return machineTypes, nil
+	})
+}
+
+// validateMachineTypes checks that the machine types of the cluster are offered by the target provider. Unknown machine types are reported with a ValidationError.
+func (g *gardenerProvisioner) validateMachineTypes(ctx context.Context, cluster *types.Cluster, provider *types.Provider) error {
+	machineTypes, err := g.MachineTypes(ctx, provider, cluster.Location)
+	if err != nil {
+		return err
+	}
+	if fieldErrs := catalog.Validate(cluster, machineTypes); len(fieldErrs) > 0 {
+		return &types.ValidationError{Errors: fieldErrs}
+	}
+	return nil
+}
+
+// clusterAccess reads the API server endpoint and the certificate authority data of the current context of a kubeconfig.
+func clusterAccess(kubeconfig []byte) (string, []byte, error) {
+	config, err := clientcmd.Load(kubeconfig)
+	if err != nil {
+		return "", nil, err
+	}
+
+	currentContext, ok := config.Contexts[config.CurrentContext]
+	if !ok {
+		return "", nil, errors.Errorf("context %q not found in the kubeconfig", config.CurrentContext)
+	}
+	currentCluster, ok := config.Clusters[currentContext.Cluster]
+	if !ok {
+		return "", nil, errors.Errorf("cluster %q not found in the kubeconfig", currentContext.Cluster)
+	}
+
+	return currentCluster.Server, currentCluster.CertificateAuthorityData, nil
+}
+
+// newRestConfig builds the Gardener client configuration from the kubeconfig file.
+// Every request sent with the returned configuration is bound to the given context, so cancelling it aborts in-flight calls.
+func newRestConfig(ctx context.Context, kubeconfigPath string) (*rest.Config, error) {
+	config, err := clientcmd.BuildConfigFromFlags("", kubeconfigPath)
+	if err != nil {
+		return nil, err
+	}
+
+	config.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return &contextRoundTripper{ctx: ctx, next: rt}
+	})
+	return config, nil
+}
+
+// contextRoundTripper attaches a context to each outgoing request.
+type contextRoundTripper struct {
+	ctx  context.Context
+	next http.RoundTripper
+}
+
+func (c *contextRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	return c.next.RoundTrip(req.WithContext(c.ctx))
+}
+
+// schema describes the custom configurations of Gardener. The keys that are only needed for some clusters are checked by validate.
+var schema = &types.ProviderSchema{
+	Type: types.Gardener,
+	CustomConfigurations: []types.ConfigurationSchema{
+		{Key: "target_provider", Type: types.StringConfiguration, Required: true, AllowedValues: []interface{}{string(types.GCP), string(types.Azure), string(types.AWS)},
+			Description: "Cloud provider Gardener creates the cluster on."},
+		{Key: "target_seed", Type: types.StringConfiguration, Required: true, Description: "Seed cluster hosting the control plane of the cluster."},
+		{Key: "target_secret", Type: types.StringConfiguration, Required: true, Description: "Secret binding holding the credentials of the target provider."},
+		{Key: "disk_type", Type: types.StringConfiguration,
+			Description: "Type of the node disks on the target provider. Defaults to `pd-standard` on GCP, `gp2` on AWS, and `standard` on Azure."},
+		{Key: "zone", Type: types.StringConfiguration, Description: "Zone of the nodes. Needed on GCP and AWS."},
+		{Key: "workercidr", Type: types.StringConfiguration, Description: "IP range of the worker subnet. Needed unless Cluster.Networking.NodeCIDR is set."},
+		{Key: "vnetcidr", Type: types.StringConfiguration, Description: "IP range of the virtual network. Needed on Azure unless Cluster.Networking.Azure.VNetCIDR is set."},
+		{Key: "vpccidr", Type: types.StringConfiguration, Description: "IP range of the VPC. Needed on AWS unless Cluster.Networking.AWS.VPCCIDR is set."},
+		{Key: "publicscidr", Type: types.StringConfiguration, Description: "IP range of the public subnet. Needed on AWS unless Cluster.Networking.AWS.PublicCIDR is set."},
+		{Key: "internalscidr", Type: types.StringConfiguration, Description: "IP range of the internal subnet. Needed on AWS unless Cluster.Networking.AWS.InternalCIDR is set."},
+		{Key: "autoscaler_min", Type: types.IntegerConfiguration,
+			Description: "Minimum size of the default worker group. Needed for clusters without node pools and autoscaling. If neither bound is set, both default to Cluster.NodeCount."},
+		{Key: "autoscaler_max", Type: types.IntegerConfiguration,
+			Description: "Maximum size of the default worker group. Needed for clusters without node pools and autoscaling. If neither bound is set, both default to Cluster.NodeCount."},
+		{Key: "max_surge", Type: types.IntegerConfiguration, Default: 1,
+			Description: "Number of nodes added to the default worker group while its nodes are replaced. Needed for clusters without node pools and autoscaling."},
+		{Key: "max_unavailable", Type: types.IntegerConfiguration, Default: 0,
+			Description: "Number of nodes of the default worker group that can be unavailable while its nodes are replaced. Needed for clusters without node pools and autoscaling."},
+	},
+}
+
+// Describe returns the schema of the custom configurations of Gardener.
+func (g *gardenerProvisioner) Describe() *types.ProviderSchema {
+	return schema
+}
+
+// defaultDiskSizeGB is the disk size of the nodes of clusters without one.
+const defaultDiskSizeGB = 50
+
+// defaultDiskTypes are the types of the node disks on each target provider, used if the `disk_type` custom configuration is not set.
+var defaultDiskTypes = map[string]string{
+	string(types.GCP):   "pd-standard",
+	string(types.AWS):   "gp2",
+	string(types.Azure): "standard",
+}
+
+// Default fills in the Kubernetes version, the disk sizes and types, the surge settings, and the bounds of the default worker group, if they are not set.
+// The defaults of the custom configurations are applied to the typed configuration if the provider has one.
+func (g *gardenerProvisioner) Default(cluster *types.Cluster, provider *types.Provider) {
+	if cluster.KubernetesVersion == "" {
+		cluster.KubernetesVersion = versions.Default
+	}
+	if cluster.DiskSizeGB == 0 {
+		cluster.DiskSizeGB = defaultDiskSizeGB
+	}
+
+	// the default worker group scales between the autoscaling custom configurations
+	defaultPool := len(cluster.NodePools) == 0 && cluster.Autoscaling == nil
+	var diskType string
+	if config := provider.Gardener; config != nil {
+		if config.DiskType == "" {
+			config.DiskType = defaultDiskTypes[string(config.TargetProvider)]
+		}
+		if defaultPool && config.AutoscalerMin == 0 && config.AutoscalerMax == 0 {
+			config.AutoscalerMin, config.AutoscalerMax = cluster.NodeCount, cluster.NodeCount
+		}
+		if config.MaxSurge == 0 && config.MaxUnavailable == 0 {
+			config.MaxSurge = 1
+		}
+		diskType = config.DiskType
+	} else {
+		if provider.CustomConfigurations == nil {
+			provider.CustomConfigurations = map[string]interface{}{}
+		}
+		config := provider.CustomConfigurations
+		schema.ApplyDefaults(config)
+		if _, ok := config["disk_type"]; !ok {
+			if targetProvider, ok := config["target_provider"].(string); ok && defaultDiskTypes[targetProvider] != "" {
+				config["disk_type"] = defaultDiskTypes[targetProvider]
+			}
+		}
+		_, hasMin := config["autoscaler_min"]
+		_, hasMax := config["autoscaler_max"]
+		if defaultPool && !hasMin && !hasMax {
+			config["autoscaler_min"], config["autoscaler_max"] = cluster.NodeCount, cluster.NodeCount
+		}
+		diskType, _ = config["disk_type"].(string)
+	}
+
+	defaultSurge(cluster.Autoscaling)
+	for i := range cluster.NodePools {
+		pool := &cluster.NodePools[i]
+		if pool.DiskSizeGB == 0 {
+			pool.DiskSizeGB = cluster.DiskSizeGB
+		}
+		if pool.DiskType == "" {
+			pool.DiskType = diskType
+		}
+		defaultSurge(pool.Autoscaling)
+	}
+}
+
+// defaultSurge adds one node at a time while the nodes are replaced, if neither surge setting is set.
+func defaultSurge(autoscaling *types.Autoscaling) {
+	if autoscaling != nil && autoscaling.MaxSurge == 0 && autoscaling.MaxUnavailable == 0 {
+		autoscaling.MaxSurge = 1
+	}
+}
+
+// Validate checks the cluster and provider specification for Gardener without calling the provider.
+func (g *gardenerProvisioner) Validate(cluster *types.Cluster, provider *types.Provider) error {
+	return g.validate(cluster, provider)
+}
+
 func (g *gardenerProvisioner) validate(cluster *types.Cluster, provider *types.Provider) error {
-	var errMessage string
+	var fieldErrs []types.FieldError
 
 	// Cluster
-	if cluster.NodeCount < 1 {
-		errMessage += fmt.Sprintf(errs.CannotBeLess, "Cluster.NodeCount", 1)
+	if len(cluster.NodePools) == 0 && cluster.NodeCount < 1 {
+		fieldErrs = append(fieldErrs, types.FieldError{Field: "Cluster.NodeCount", Reason: fmt.Sprintf(errs.CannotBeLess, 1), Value: cluster.NodeCount})
 	}
 	// Matches the regex for a Gardener cluster name.
 	if match, _ := regexp.MatchString(`^(?:[a-z](?:[-a-z0-9]{0,19}[a-z0-9])?)$`, cluster.Name); !match {
-		errMessage += fmt.Sprintf(errs.Custom, "Cluster.Name must start with a lowercase letter followed by up to 19 lowercase letters, "+
-			"numbers, or hyphens, and cannot end with a hyphen")
+		fieldErrs = append(fieldErrs, types.FieldError{Field: "Cluster.Name", Reason: "must start with a lowercase letter followed by up to 19 lowercase letters, " +
+			"numbers, or hyphens, and cannot end with a hyphen", Value: cluster.Name})
 	}
 	if cluster.Location == "" {
-		errMessage += fmt.Sprintf(errs.CannotBeEmpty, "Cluster.Location")
+		fieldErrs = append(fieldErrs, types.FieldError{Field: "Cluster.Location", Reason: errs.CannotBeEmpty})
 	}
-	if cluster.MachineType == "" {
-		errMessage += fmt.Sprintf(errs.CannotBeEmpty, "Cluster.MachineType")
+	if len(cluster.NodePools) == 0 && cluster.MachineType == "" {
+		fieldErrs = append(fieldErrs, types.FieldError{Field: "Cluster.MachineType", Reason: errs.CannotBeEmpty})
 	}
 	if cluster.KubernetesVersion == "" {
-		errMessage += fmt.Sprintf(errs.CannotBeEmpty, "Cluster.KubernetesVersion")
+		fieldErrs = append(fieldErrs, types.FieldError{Field: "Cluster.KubernetesVersion", Reason: errs.CannotBeEmpty})
 	}
 	if cluster.DiskSizeGB <= 0 {
-		errMessage += fmt.Sprintf(errs.CannotBeLess, "Cluster.DiskSizeGB", 0)
+		fieldErrs = append(fieldErrs, types.FieldError{Field: "Cluster.DiskSizeGB", Reason: fmt.Sprintf(errs.CannotBeLess, 0), Value: cluster.DiskSizeGB})
 	}
 
+	fieldErrs = append(fieldErrs, validateMetadata(cluster)...)
+	gardenerConfig, config, configErrs := customConfigurations(provider)
+	fieldErrs = append(fieldErrs, validateNodePools(cluster.NodePools, config["target_provider"])...)
+	fieldErrs = append(fieldErrs, types.ValidateAutoscaling(cluster)...)
+	fieldErrs = append(fieldErrs, validateNetworking(cluster.Networking, config["target_provider"])...)
+
 	// Provider
-	if provider.CredentialsFilePath == "" {
-		errMessage += fmt.Sprintf(errs.CannotBeEmpty, "Provider.CredentialsFilePath")
-	}
-	if provider.ProjectName == "" {
-		errMessage += fmt.Sprintf(errs.CannotBeEmpty, "Provider.ProjectName")
-	}
+	fieldErrs = append(fieldErrs, validateProvider(provider)...)
 
 	// Custom gardener configuration
-	targetProvider, ok := provider.CustomConfigurations["target_provider"]
-	if ok {
-		if targetProvider != string(types.GCP) && targetProvider != string(types.AWS) && targetProvider != string(types.Azure) {
-			errMessage += fmt.Sprintf(errs.Custom, "Provider.CustomConfigurations['target_provider'] has to be one of: gcp, azure, aws")
+	fieldErrs = append(fieldErrs, configErrs...)
+	targetProvider := config["target_provider"]
+	// the autoscaling custom configurations are only used for the default node pool without typed settings
+	defaultPool := len(cluster.NodePools) == 0 && cluster.Autoscaling == nil
+	if _, ok := config["autoscaler_min"]; !ok && defaultPool {
+		fieldErrs = append(fieldErrs, types.FieldError{Field: "Provider.CustomConfigurations['autoscaler_min']", Reason: errs.CannotBeEmpty})
+	}
+	if _, ok := config["autoscaler_max"]; !ok && defaultPool {
+		fieldErrs = append(fieldErrs, types.FieldError{Field: "Provider.CustomConfigurations['autoscaler_max']", Reason: errs.CannotBeEmpty})
+	}
+	if _, ok := config["autoscaler_max"]; ok && defaultPool {
+		if gardenerConfig.AutoscalerMax < 1 {
+			fieldErrs = append(fieldErrs, types.FieldError{Field: "Provider.CustomConfigurations['autoscaler_max']", Reason: fmt.Sprintf(errs.CannotBeLess, 1), Value: gardenerConfig.AutoscalerMax})
+		} else if gardenerConfig.AutoscalerMax < gardenerConfig.AutoscalerMin {
+			fieldErrs = append(fieldErrs, types.FieldError{Field: "Provider.CustomConfigurations['autoscaler_max']", Reason: fmt.Sprintf(errs.CannotBeLess, "Provider.CustomConfigurations['autoscaler_min']"), Value: gardenerConfig.AutoscalerMax})
 		}
-	} else {
-		errMessage += fmt.Sprintf(errs.CannotBeEmpty, "Provider.CustomConfigurations['target_provider']")
 	}
-	if _, ok := provider.CustomConfigurations["target_seed"]; !ok {
-		errMessage += fmt.Sprintf(errs.CannotBeEmpty, "Provider.CustomConfigurations['target_seed']")
+	if _, ok := config["max_surge"]; !ok && defaultPool {
+		fieldErrs = append(fieldErrs, types.FieldError{Field: "Provider.CustomConfigurations['max_surge']", Reason: errs.CannotBeEmpty})
+	}
+	if _, ok := config["max_unavailable"]; !ok && defaultPool {
+		fieldErrs = append(fieldErrs, types.FieldError{Field: "Provider.CustomConfigurations['max_unavailable']", Reason: errs.CannotBeEmpty})
+	}
+	// Default sets the disk type of the supported target providers
+	if _, ok := config["disk_type"]; !ok {
+		fieldErrs = append(fieldErrs, types.FieldError{Field: "Provider.CustomConfigurations['disk_type']", Reason: errs.CannotBeEmpty})
 	}
-	if _, ok := provider.CustomConfigurations["target_secret"]; !ok {
-		errMessage += fmt.Sprintf(errs.CannotBeEmpty, "Provider.CustomConfigurations['target_secret']")
+	// the CIDR custom configurations are only used for the networks the cluster does not specify
+	networks := networkConfigurations(cluster.Networking)
+	if _, ok := config["workercidr"]; !ok && networks["workercidr"] == "" {
+		fieldErrs = append(fieldErrs, types.FieldError{Field: "Provider.CustomConfigurations['workercidr']", Reason: errs.CannotBeEmpty})
+	}
+	if _, ok := config["zone"]; !ok && (targetProvider == string(types.GCP) || targetProvider == string(types.AWS)) {
+		fieldErrs = append(fieldErrs, types.FieldError{Field: "Provider.CustomConfigurations['zone']", Reason: errs.CannotBeEmpty})
+	}
+	if _, ok := config["publicscidr"]; !ok && networks["publicscidr"] == "" && targetProvider == string(types.AWS) {
+		fieldErrs = append(fieldErrs, types.FieldError{Field: "Provider.CustomConfigurations['publicscidr']", Reason: errs.CannotBeEmpty})
+	}
+	if _, ok := config["vpccidr"]; !ok && networks["vpccidr"] == "" && targetProvider == string(types.AWS) {
+		fieldErrs = append(fieldErrs, types.FieldError{Field: "Provider.CustomConfigurations['vpccidr']", Reason: errs.CannotBeEmpty})
+	}
+	if _, ok := config["internalscidr"]; !ok && networks["internalscidr"] == "" && targetProvider == string(types.AWS) {
+		fieldErrs = append(fieldErrs, types.FieldError{Field: "Provider.CustomConfigurations['internalscidr']", Reason: errs.CannotBeEmpty})
+	}
+	if _, ok := config["vnetcidr"]; !ok && networks["vnetcidr"] == "" && targetProvider == string(types.Azure) {
+		fieldErrs = append(fieldErrs, types.FieldError{Field: "Provider.CustomConfigurations['vnetcidr']", Reason: errs.CannotBeEmpty})
 	}
 
-	if _, ok := provider.CustomConfigurations["disk_type"]; !ok {
-		errMessage += fmt.Sprintf(errs.CannotBeEmpty, "Provider.CustomConfigurations['disk_type']")
+	if len(fieldErrs) > 0 {
+		return &types.ValidationError{Errors: fieldErrs}
 	}
-	if _, ok := provider.CustomConfigurations["autoscaler_min"]; !ok {
-		errMessage += fmt.Sprintf(errs.CannotBeEmpty, "Provider.CustomConfigurations['autoscaler_min']")
+	return nil
+}
+
+// customConfigurations returns the typed Gardener configuration of the provider, and the custom configurations the templates use. Without a typed configuration, the custom configurations of the provider are used as they are.
+// The errors report the custom configurations not matching the schema, and typed configurations of other target providers than the chosen one.
+func customConfigurations(provider *types.Provider) (*types.GardenerConfig, map[string]interface{}, []types.FieldError) {
+	config := provider.Gardener
+	if config == nil {
+		// the schema reports the values of the wrong type
+		config, _ := types.ParseGardenerConfig(provider.CustomConfigurations)
+		return config, provider.CustomConfigurations, schema.Validate(provider.CustomConfigurations)
 	}
-	if _, ok := provider.CustomConfigurations["autoscaler_max"]; !ok {
-		errMessage += fmt.Sprintf(errs.CannotBeEmpty, "Provider.CustomConfigurations['autoscaler_max']")
+
+	var fieldErrs []types.FieldError
+	if config.GCP != nil && config.TargetProvider != types.GCP {
+		fieldErrs = append(fieldErrs, types.FieldError{Field: "Provider.Gardener.GCP", Reason: "is only used with the gcp target provider"})
 	}
-	if _, ok := provider.CustomConfigurations["max_surge"]; !ok {
-		errMessage += fmt.Sprintf(errs.CannotBeEmpty, "Provider.CustomConfigurations['max_surge']")
+	if config.AWS != nil && config.TargetProvider != types.AWS {
+		fieldErrs = append(fieldErrs, types.FieldError{Field: "Provider.Gardener.AWS", Reason: "is only used with the aws target provider"})
 	}
-	if _, ok := provider.CustomConfigurations["max_unavailable"]; !ok {
-		errMessage += fmt.Sprintf(errs.CannotBeEmpty, "Provider.CustomConfigurations['max_unavailable']")
+	if config.Azure != nil && config.TargetProvider != types.Azure {
+		fieldErrs = append(fieldErrs, types.FieldError{Field: "Provider.Gardener.Azure", Reason: "is only used with the azure target provider"})
 	}
-	if _, ok := provider.CustomConfigurations["workercidr"]; !ok {
-		errMessage += fmt.Sprintf(errs.CannotBeEmpty, "Provider.CustomConfigurations['workercidr']")
+	customConfig := config.CustomConfigurations()
+	return config, customConfig, append(fieldErrs, schema.Validate(customConfig)...)
+}
+
+// validateMetadata checks the labels and annotations of the cluster with the rules Kubernetes applies to the metadata of the Shoot.
+func validateMetadata(cluster *types.Cluster) []types.FieldError {
+	errList := metav1validation.ValidateLabels(cluster.Labels, field.NewPath("Cluster", "Labels"))
+	errList = append(errList, apivalidation.ValidateAnnotations(cluster.Annotations, field.NewPath("Cluster", "Annotations"))...)
+	return fieldErrors(errList)
+}
+
+func validateNetworking(networking *types.Networking, targetProvider interface{}) []types.FieldError {
+	if networking == nil {
+		return nil
 	}
-	if _, ok := provider.CustomConfigurations["zone"]; !ok && (targetProvider == string(types.GCP) || targetProvider == string(types.AWS)) {
-		errMessage += fmt.Sprintf(errs.CannotBeEmpty, "Provider.CustomConfigurations['zone']")
+	fieldErrs := types.ValidateNetworking(networking)
+	if networking.Network != "" {
+		fieldErrs = append(fieldErrs, types.FieldError{Field: "Cluster.Networking.Network", Reason: "is not supported by Gardener", Value: networking.Network})
 	}
-	if _, ok := provider.CustomConfigurations["publicscidr"]; !ok && targetProvider == string(types.AWS) {
-		errMessage += fmt.Sprintf(errs.CannotBeEmpty, "Provider.CustomConfigurations['publicscidr']")
+	if networking.Subnetwork != "" {
+		fieldErrs = append(fieldErrs, types.FieldError{Field: "Cluster.Networking.Subnetwork", Reason: "is not supported by Gardener", Value: networking.Subnetwork})
 	}
-	if _, ok := provider.CustomConfigurations["vpccidr"]; !ok && targetProvider == string(types.AWS) {
-		errMessage += fmt.Sprintf(errs.CannotBeEmpty, "Provider.CustomConfigurations['vpccidr']")
+	if networking.AWS != nil && targetProvider != string(types.AWS) {
+		fieldErrs = append(fieldErrs, types.FieldError{Field: "Cluster.Networking.AWS", Reason: "is only supported by the aws target provider"})
 	}
-	if _, ok := provider.CustomConfigurations["internalscidr"]; !ok && targetProvider == string(types.AWS) {
-		errMessage += fmt.Sprintf(errs.CannotBeEmpty, "Provider.CustomConfigurations['internalscidr']")
+	if networking.Azure != nil && targetProvider != string(types.Azure) {
+		fieldErrs = append(fieldErrs, types.FieldError{Field: "Cluster.Networking.Azure", Reason: "is only supported by the azure target provider"})
 	}
-	if _, ok := provider.CustomConfigurations["vnetcidr"]; !ok && targetProvider == string(types.Azure) {
-		errMessage += fmt.Sprintf(errs.CannotBeEmpty, "Provider.CustomConfigurations['vnetcidr']")
+	return fieldErrs
+}
+
+// workerNamePattern matches the names Gardener accepts for worker groups.
+var workerNamePattern = regexp.MustCompile(`^[a-z0-9](?:[-a-z0-9]{0,13}[a-z0-9])?$`)
+
+func validateNodePools(pools []types.NodePool, targetProvider interface{}) []types.FieldError {
+	var fieldErrs []types.FieldError
+	names := map[string]bool{}
+	for i, pool := range pools {
+		path := field.NewPath("Cluster", "NodePools").Index(i)
+		if !workerNamePattern.MatchString(pool.Name) {
+			fieldErrs = append(fieldErrs, types.FieldError{Field: path.Child("Name").String(), Reason: "must consist of up to 15 lowercase letters, numbers, or hyphens, " +
+				"and cannot start or end with a hyphen", Value: pool.Name})
+		}
+		if names[pool.Name] {
+			fieldErrs = append(fieldErrs, types.FieldError{Field: path.Child("Name").String(), Reason: "must be unique", Value: pool.Name})
+		}
+		names[pool.Name] = true
+		if pool.MachineType == "" {
+			fieldErrs = append(fieldErrs, types.FieldError{Field: path.Child("MachineType").String(), Reason: errs.CannotBeEmpty})
+		}
+		if pool.NodeCount < 0 {
+			fieldErrs = append(fieldErrs, types.FieldError{Field: path.Child("NodeCount").String(), Reason: fmt.Sprintf(errs.CannotBeLess, 0), Value: pool.NodeCount})
+		}
+		if pool.DiskSizeGB < 0 {
+			fieldErrs = append(fieldErrs, types.FieldError{Field: path.Child("DiskSizeGB").String(), Reason: fmt.Sprintf(errs.CannotBeLess, 0), Value: pool.DiskSizeGB})
+		}
+		fieldErrs = append(fieldErrs, fieldErrors(metav1validation.ValidateLabels(pool.Labels, path.Child("Labels")))...)
+		for j, taint := range pool.Taints {
+			fieldErrs = append(fieldErrs, validateTaint(taint, path.Child("Taints").Index(j))...)
+		}
+		if len(pool.Zones) > 0 && targetProvider == string(types.Azure) {
+			fieldErrs = append(fieldErrs, types.FieldError{Field: path.Child("Zones").String(), Reason: "are not supported by Azure"})
+		}
 	}
+	return fieldErrs
+}
 
-	if errMessage != "" {
-		return errors.New("input validation failed with the following information: " + errMessage)
+func validateTaint(taint types.Taint, path *field.Path) []types.FieldError {
+	var fieldErrs []types.FieldError
+	for _, msg := range validation.IsQualifiedName(taint.Key) {
+		fieldErrs = append(fieldErrs, types.FieldError{Field: path.Child("Key").String(), Reason: msg, Value: taint.Key})
 	}
-	return nil
+	for _, msg := range validation.IsValidLabelValue(taint.Value) {
+		fieldErrs = append(fieldErrs, types.FieldError{Field: path.Child("Value").String(), Reason: msg, Value: taint.Value})
+	}
+	switch taint.Effect {
+	case types.NoSchedule, types.PreferNoSchedule, types.NoExecute:
+	default:
+		fieldErrs = append(fieldErrs, types.FieldError{Field: path.Child("Effect").String(),
+			Reason: fmt.Sprintf(errs.MustBeOneOf, strings.Join([]string{string(types.NoSchedule), string(types.PreferNoSchedule), string(types.NoExecute)}, ", ")), Value: taint.Effect})
+	}
+	return fieldErrs
+}
+
+// fieldErrors converts the errors of the Kubernetes validation.
+func fieldErrors(errList field.ErrorList) []types.FieldError {
+	fieldErrs := make([]types.FieldError, 0, len(errList))
+	for _, err := range errList {
+		fieldErrs = append(fieldErrs, types.FieldError{Field: err.Field, Reason: err.Detail, Value: err.BadValue})
+	}
+	// the validation walks maps in random order
+	sort.SliceStable(fieldErrs, func(i, j int) bool {
+		return fmt.Sprint(fieldErrs[i].Value) < fmt.Sprint(fieldErrs[j].Value)
+	})
+	return fieldErrs
+}
+
+func validateProvider(provider *types.Provider) []types.FieldError {
+	var fieldErrs []types.FieldError
+	if provider.CredentialsFilePath == "" {
+		fieldErrs = append(fieldErrs, types.FieldError{Field: "Provider.CredentialsFilePath", Reason: errs.CannotBeEmpty})
+	}
+	if provider.ProjectName == "" {
+		fieldErrs = append(fieldErrs, types.FieldError{Field: "Provider.ProjectName", Reason: errs.CannotBeEmpty})
+	}
+	return fieldErrs
 }
 
 func (*gardenerProvisioner) loadConfigurations(cluster *types.Cluster, provider *types.Provider) map[string]interface{} {
@@ -211,21 +873,158 @@ func (*gardenerProvisioner) loadConfigurations(cluster *types.Cluster, provider
 	config["kubernetes_version"] = cluster.KubernetesVersion
 	config["location"] = cluster.Location
 	config["namespace"] = fmt.Sprintf("garden-%s", provider.ProjectName)
+	config["labels"] = stringMap(cluster.Labels)
+	config["annotations"] = stringMap(cluster.Annotations)
+	config["node_pools"] = nodePools(cluster)
+
+	config["networking"] = cluster.Networking
 
-	for k, v := range provider.CustomConfigurations {
+	_, customConfig, _ := customConfigurations(provider)
+	for k, v := range customConfig {
 		config[k] = v
 	}
-	switch config["target_provider"] {
-	case string(types.GCP):
-		config["target_profile"] = gcpProfile
-	case string(types.AWS):
-		config["target_profile"] = awsProfile
-	case string(types.Azure):
-		config["target_profile"] = azureProfile
+	for k, v := range networkConfigurations(cluster.Networking) {
+		config[k] = v
+	}
+	if targetProvider, ok := config["target_provider"].(string); ok && profiles[targetProvider] != "" {
+		config["target_profile"] = profiles[targetProvider]
 	}
 	return config
 }
 
+// networkConfigurations maps the networks the cluster specifies to the CIDR custom configurations they replace.
+func networkConfigurations(networking *types.Networking) map[string]string {
+	config := map[string]string{}
+	if networking == nil {
+		return config
+	}
+	set := func(key, cidr string) {
+		if cidr != "" {
+			config[key] = cidr
+		}
+	}
+	set("workercidr", networking.NodeCIDR)
+	if aws := networking.AWS; aws != nil {
+		set("vpccidr", aws.VPCCIDR)
+		set("publicscidr", aws.PublicCIDR)
+		set("internalscidr", aws.InternalCIDR)
+	}
+	if azure := networking.Azure; azure != nil {
+		set("vnetcidr", azure.VNetCIDR)
+	}
+	return config
+}
+
+// defaultWorker is the name of the worker group of clusters without node pools.
+const defaultWorker = "cpu-worker-0"
+
+// nodePools returns the node pools of the cluster, with the autoscaling settings of the cluster applied to the pools without their own. An autoscaled cluster without node pools gets a pool in place of the default worker group, which is autoscaled with the custom configurations.
+func nodePools(cluster *types.Cluster) []types.NodePool {
+	if len(cluster.NodePools) == 0 {
+		if cluster.Autoscaling == nil {
+			return nil
+		}
+		return []types.NodePool{{
+			Name:        defaultWorker,
+			MachineType: cluster.MachineType,
+			NodeCount:   cluster.NodeCount,
+			Autoscaling: cluster.Autoscaling,
+		}}
+	}
+
+	pools := make([]types.NodePool, 0, len(cluster.NodePools))
+	for _, pool := range cluster.NodePools {
+		if pool.Autoscaling == nil {
+			pool.Autoscaling = cluster.Autoscaling
+		}
+		pools = append(pools, pool)
+	}
+	return pools
+}
+
+// convertShoot maps a Shoot to a Hydroform cluster. Every worker group is a node pool. The node count is the minimum number of nodes of all worker groups, and the machine type and disk size are those of the first one.
+func convertShoot(shoot *gardener_types.Shoot) *types.Cluster {
+	cluster := &types.Cluster{
+		Name:              shoot.Name,
+		Location:          shoot.Spec.Cloud.Region,
+		KubernetesVersion: shoot.Spec.Kubernetes.Version,
+		Labels:            shoot.Labels,
+		Annotations:       shoot.Annotations,
+		ClusterInfo: &types.ClusterInfo{
+			Status: &types.ClusterStatus{
+				Phase:       convertGardenertatus(shoot.Status),
+				Labels:      shoot.Labels,
+				Annotations: shoot.Annotations,
+			},
+		},
+	}
+
+	switch cloud := shoot.Spec.Cloud; {
+	case cloud.GCP != nil:
+		for _, w := range cloud.GCP.Workers {
+			cluster.NodePools = append(cluster.NodePools, convertWorker(w.Worker, w.VolumeSize, w.VolumeType, cloud.GCP.Zones))
+		}
+	case cloud.AWS != nil:
+		for _, w := range cloud.AWS.Workers {
+			cluster.NodePools = append(cluster.NodePools, convertWorker(w.Worker, w.VolumeSize, w.VolumeType, cloud.AWS.Zones))
+		}
+	case cloud.Azure != nil:
+		for _, w := range cloud.Azure.Workers {
+			cluster.NodePools = append(cluster.NodePools, convertWorker(w.Worker, w.VolumeSize, w.VolumeType, nil))
+		}
+	}
+
+	for _, pool := range cluster.NodePools {
+		cluster.NodeCount += pool.NodeCount
+	}
+	if len(cluster.NodePools) > 0 {
+		cluster.MachineType = cluster.NodePools[0].MachineType
+		cluster.DiskSizeGB = cluster.NodePools[0].DiskSizeGB
+	}
+	return cluster
+}
+
+// convertWorker maps a worker group to a node pool. Gardener starts the worker groups with their minimum size, so that is their node count.
+func convertWorker(worker gardener_types.Worker, volumeSize, volumeType string, zones []string) types.NodePool {
+	pool := types.NodePool{
+		Name:        worker.Name,
+		MachineType: worker.MachineType,
+		NodeCount:   worker.AutoScalerMin,
+		DiskType:    volumeType,
+		Labels:      worker.Labels,
+		Zones:       zones,
+	}
+	// worker groups with equal bounds are not autoscaled
+	if worker.AutoScalerMax != worker.AutoScalerMin {
+		pool.Autoscaling = &types.Autoscaling{
+			Min: worker.AutoScalerMin,
+			Max: worker.AutoScalerMax,
+		}
+		if worker.MaxSurge != nil {
+			pool.Autoscaling.MaxSurge = worker.MaxSurge.IntValue()
+		}
+		if worker.MaxUnavailable != nil {
+			pool.Autoscaling.MaxUnavailable = worker.MaxUnavailable.IntValue()
+		}
+	}
+	if size, err := resource.ParseQuantity(volumeSize); err == nil {
+		pool.DiskSizeGB = int(size.Value() / (1 << 30))
+	}
+	for _, taint := range worker.Taints {
+		pool.Taints = append(pool.Taints, types.Taint{Key: taint.Key, Value: taint.Value, Effect: types.TaintEffect(taint.Effect)})
+	}
+	return pool
+}
+
+// stringMap converts a map to the type Terraform expects for map variables.
+func stringMap(m map[string]string) map[string]interface{} {
+	converted := map[string]interface{}{}
+	for k, v := range m {
+		converted[k] = v
+	}
+	return converted
+}
+
 // Possible values for the Gardener Cluster Status:
 // Processing - indicates the cluster is being created.
 // Succeeded - indicates the cluster has been created and is fully usable.
@@ -257,3 +1056,10 @@ const (
 	awsProfile   string = "aws"
 	azureProfile string = "az"
 )
+
+// profiles are the cloud profiles of the target providers.
+var profiles = map[string]string{
+	string(types.GCP):   gcpProfile,
+	string(types.AWS):   awsProfile,
+	string(types.Azure): azureProfile,
+}
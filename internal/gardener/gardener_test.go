@@ -1,23 +1,75 @@
 package gardener
 
 import (
+	"context"
+	stderrors "errors"
 	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
 	"testing"
+	"time"
 
 	"github.com/kyma-incubator/hydroform/internal/terraform"
 
+	"github.com/kyma-incubator/hydroform/catalog"
+	"github.com/kyma-incubator/hydroform/internal/errs"
 	"github.com/kyma-incubator/hydroform/internal/operator/mocks"
+	"github.com/kyma-incubator/hydroform/internal/versions"
 	"github.com/pkg/errors"
 
 	"github.com/kyma-incubator/hydroform/types"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
 	gardener_core "github.com/gardener/gardener/pkg/apis/core/v1alpha1"
 	gardener_types "github.com/gardener/gardener/pkg/apis/garden/v1beta1"
+	gardener_fake "github.com/gardener/gardener/pkg/client/garden/clientset/versioned/fake"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	k8s_fake "k8s.io/client-go/kubernetes/fake"
 )
 
 const convertError = "Status [%s] should be converted to [%s]"
 
+// gcpCloudProfile returns the cloud profile of the gcp target provider the fake Gardener clients hold.
+func gcpCloudProfile() *gardener_types.CloudProfile {
+	expired := metav1.NewTime(time.Now().Add(-time.Hour))
+	unusable := false
+	return &gardener_types.CloudProfile{
+		ObjectMeta: metav1.ObjectMeta{Name: gcpProfile},
+		Spec: gardener_types.CloudProfileSpec{
+			GCP: &gardener_types.GCPProfile{
+				Constraints: gardener_types.GCPConstraints{
+					Kubernetes: gardener_types.KubernetesConstraints{
+						OfferedVersions: []gardener_types.KubernetesVersion{
+							{Version: "1.15.4"},
+							{Version: "1.15.2"},
+							{Version: "1.14.6"},
+							{Version: "1.13.10", ExpirationDate: &expired},
+						},
+					},
+					MachineTypes: []gardener_types.MachineType{
+						{Name: "n1-standard-4", CPU: resource.MustParse("4"), Memory: resource.MustParse("15Gi")},
+						{Name: "n1-highmem-8", CPU: resource.MustParse("8"), Memory: resource.MustParse("52Gi")},
+						{Name: "n1-standard-1", CPU: resource.MustParse("1"), Memory: resource.MustParse("3840Mi"), Usable: &unusable},
+					},
+				},
+			},
+		},
+	}
+}
+
+// offlineCatalog returns the machine types of the static catalog file, so that the tests do not need the cloud profiles.
+func offlineCatalog(t *testing.T) catalog.Static {
+	static, err := catalog.LoadFile("testdata/machine_types.json")
+	require.NoError(t, err)
+	return static
+}
+
 func TestConvertGardenerState(t *testing.T) {
 	status := gardener_types.ShootStatus{
 		LastOperation: &gardener_core.LastOperation{
@@ -83,6 +135,16 @@ func TestValidate(t *testing.T) {
 		delete(provider.CustomConfigurations, "zone")
 		require.Error(t, g.validate(cluster, provider), "Validation should fail when zone is empty")
 		provider.CustomConfigurations["zone"] = "europe-west3-b"
+
+		delete(provider.CustomConfigurations, "workercidr")
+		provider.CustomConfigurations["target_provider"] = "nimbus"
+		err := g.validate(cluster, provider)
+		var validation *types.ValidationError
+		require.True(t, stderrors.As(err, &validation), "The error should be a ValidationError")
+		require.Equal(t, []types.FieldError{
+			{Field: "Provider.CustomConfigurations['target_provider']", Reason: "has to be one of: gcp, azure, aws", Value: "nimbus"},
+			{Field: "Provider.CustomConfigurations['workercidr']", Reason: "cannot be empty"},
+		}, validation.Errors, "Every invalid field should be reported")
 	})
 
 	t.Run("Validate Azure config", func(t *testing.T) {
@@ -244,6 +306,7 @@ func performBasicValidation(t *testing.T, g gardenerProvisioner, cluster *types.
 	provider.CustomConfigurations["target_secret"] = "secret_name"
 
 	delete(provider.CustomConfigurations, "disk_type")
+	require.Empty(t, schema.Validate(provider.CustomConfigurations), "The schema should not require the disk type, which Default sets")
 	require.Error(t, g.validate(cluster, provider), "Validation should fail when disk type is empty")
 	provider.CustomConfigurations["disk_type"] = "pd-standard"
 
@@ -268,6 +331,426 @@ func performBasicValidation(t *testing.T, g gardenerProvisioner, cluster *types.
 	provider.CustomConfigurations["max_unavailable"] = 1
 }
 
+func TestValidateMetadata(t *testing.T) {
+	cluster := &types.Cluster{
+		Labels:      map[string]string{"team": "hydro", "hydroform.kyma-project.io/owner": "hydro-team"},
+		Annotations: map[string]string{"hydroform.kyma-project.io/description": "Cluster of the hydro team, with any characters!"},
+	}
+	require.Empty(t, validateMetadata(cluster), "Valid labels and annotations should pass")
+
+	cluster.Labels = map[string]string{"team!": "hydro", "owner": "Hydro Team"}
+	cluster.Annotations = map[string]string{"-invalid": "value"}
+	fieldErrs := validateMetadata(cluster)
+	require.Len(t, fieldErrs, 3, "Invalid keys and values should fail")
+	for _, fieldErr := range fieldErrs {
+		require.Contains(t, []string{"Cluster.Labels", "Cluster.Annotations"}, fieldErr.Field)
+	}
+}
+
+func TestValidateNodePools(t *testing.T) {
+	g := gardenerProvisioner{}
+
+	cluster := &types.Cluster{
+		KubernetesVersion: "1.15.4",
+		Name:              "hydro-cluster",
+		DiskSizeGB:        30,
+		Location:          "europe-west3",
+		NodePools: []types.NodePool{
+			{Name: "cpu", MachineType: "n1-standard-4", NodeCount: 2, Zones: []string{"europe-west3-a"}},
+			{
+				Name:        "gpu",
+				MachineType: "n1-highmem-8",
+				NodeCount:   1,
+				Autoscaling: &types.Autoscaling{Min: 1, Max: 4},
+				Labels:      map[string]string{"hydroform.kyma-project.io/pool": "gpu"},
+				Taints:      []types.Taint{{Key: "nvidia.com/gpu", Effect: types.NoSchedule}, {Key: "team", Value: "hydro", Effect: types.NoExecute}},
+			},
+		},
+	}
+	provider := &types.Provider{
+		Type:                types.Gardener,
+		ProjectName:         "my-project",
+		CredentialsFilePath: "/path/to/credentials",
+		CustomConfigurations: map[string]interface{}{
+			"target_provider": "gcp",
+			"target_seed":     "gcp-eu1",
+			"target_secret":   "secret-name",
+			"disk_type":       "pd-standard",
+			"zone":            "europe-west3-b",
+			"workercidr":      "10.250.0.0/19",
+		},
+	}
+	require.NoError(t, g.validate(cluster, provider), "Validation should pass without the node count, machine type, and autoscaling custom configurations")
+
+	cluster.NodePools = []types.NodePool{
+		{Name: "cpu", MachineType: "n1-standard-4", NodeCount: 2, Taints: []types.Taint{{Key: "-gpu", Value: "a b", Effect: "NoWay"}}},
+		{Name: "cpu", NodeCount: -1, Autoscaling: &types.Autoscaling{Min: 3, Max: 2}, Labels: map[string]string{"-pool": "gpu"}, Zones: []string{"westeurope-1"}},
+		{Name: "worker-name-too-long", MachineType: "n1-standard-4"},
+	}
+	provider.CustomConfigurations["target_provider"] = "azure"
+	provider.CustomConfigurations["vnetcidr"] = "10.250.0.0/16"
+	err := g.validate(cluster, provider)
+	var validationErr *types.ValidationError
+	require.True(t, stderrors.As(err, &validationErr), "Validation should fail for invalid node pools")
+	require.Equal(t, []string{
+		"Cluster.NodePools[0].Taints[0].Key",
+		"Cluster.NodePools[0].Taints[0].Value",
+		"Cluster.NodePools[0].Taints[0].Effect",
+		"Cluster.NodePools[1].Name",
+		"Cluster.NodePools[1].MachineType",
+		"Cluster.NodePools[1].NodeCount",
+		"Cluster.NodePools[1].Labels",
+		"Cluster.NodePools[1].Zones",
+		"Cluster.NodePools[2].Name",
+		"Cluster.NodePools[1].Autoscaling.Max",
+		"Cluster.NodePools[1].NodeCount",
+	}, validationErr.Fields())
+}
+
+func TestAutoscaling(t *testing.T) {
+	g := gardenerProvisioner{}
+
+	cluster := &types.Cluster{
+		KubernetesVersion: "1.15.4",
+		Name:              "hydro-cluster",
+		DiskSizeGB:        30,
+		NodeCount:         2,
+		Location:          "europe-west3",
+		MachineType:       "n1-standard-4",
+		Autoscaling:       &types.Autoscaling{Min: 1, Max: 3, MaxSurge: 2},
+	}
+	provider := &types.Provider{
+		Type:                types.Gardener,
+		ProjectName:         "my-project",
+		CredentialsFilePath: "/path/to/credentials",
+		CustomConfigurations: map[string]interface{}{
+			"target_provider": "gcp",
+			"target_seed":     "gcp-eu1",
+			"target_secret":   "secret-name",
+			"disk_type":       "pd-standard",
+			"zone":            "europe-west3-b",
+			"workercidr":      "10.250.0.0/19",
+		},
+	}
+	require.NoError(t, g.validate(cluster, provider), "Validation should pass without the autoscaling custom configurations")
+	require.Equal(t, []types.NodePool{
+		{Name: "cpu-worker-0", MachineType: "n1-standard-4", NodeCount: 2, Autoscaling: cluster.Autoscaling},
+	}, g.loadConfigurations(cluster, provider)["node_pools"], "The default worker group should be autoscaled with the settings of the cluster")
+
+	cluster.NodeCount = 5
+	err := g.validate(cluster, provider)
+	var validationErr *types.ValidationError
+	require.True(t, stderrors.As(err, &validationErr))
+	require.Equal(t, []string{"Cluster.NodeCount"}, validationErr.Fields(), "The node count should be within the autoscaling bounds")
+
+	cluster.Autoscaling = nil
+	cluster.NodeCount = 2
+	err = g.validate(cluster, provider)
+	require.True(t, stderrors.As(err, &validationErr))
+	require.Equal(t, []string{
+		"Provider.CustomConfigurations['autoscaler_min']",
+		"Provider.CustomConfigurations['autoscaler_max']",
+		"Provider.CustomConfigurations['max_surge']",
+		"Provider.CustomConfigurations['max_unavailable']",
+	}, validationErr.Fields(), "The default worker group without autoscaling should need the custom configurations")
+}
+
+func TestNetworking(t *testing.T) {
+	g := gardenerProvisioner{}
+
+	cluster := &types.Cluster{
+		KubernetesVersion: "1.15.4",
+		Name:              "hydro-cluster",
+		DiskSizeGB:        30,
+		NodeCount:         2,
+		Location:          "eu-central-1",
+		MachineType:       "m5.xlarge",
+		Networking: &types.Networking{
+			NodeCIDR:    "10.250.0.0/19",
+			PodCIDR:     "100.96.0.0/11",
+			ServiceCIDR: "100.64.0.0/13",
+			AWS: &types.AWSSubnets{
+				VPCCIDR:      "10.250.0.0/16",
+				PublicCIDR:   "10.250.96.0/22",
+				InternalCIDR: "10.250.112.0/22",
+			},
+		},
+	}
+	provider := &types.Provider{
+		Type:                types.Gardener,
+		ProjectName:         "my-project",
+		CredentialsFilePath: "/path/to/credentials",
+		CustomConfigurations: map[string]interface{}{
+			"target_provider": "aws",
+			"target_seed":     "aws-eu1",
+			"target_secret":   "secret-name",
+			"disk_type":       "gp2",
+			"zone":            "eu-central-1a",
+			"workercidr":      "10.1.0.0/19",
+			"autoscaler_min":  2,
+			"autoscaler_max":  4,
+			"max_surge":       1,
+			"max_unavailable": 0,
+		},
+	}
+	require.NoError(t, g.validate(cluster, provider), "Validation should pass without the CIDR custom configurations")
+
+	config := g.loadConfigurations(cluster, provider)
+	require.Equal(t, cluster.Networking, config["networking"])
+	require.Equal(t, "10.250.0.0/19", config["workercidr"], "The networks of the cluster should replace the custom configurations")
+	require.Equal(t, "10.250.0.0/16", config["vpccidr"])
+	require.Equal(t, "10.250.96.0/22", config["publicscidr"])
+	require.Equal(t, "10.250.112.0/22", config["internalscidr"])
+
+	cluster.Networking = &types.Networking{
+		Network:  "hydro-network",
+		NodeCIDR: "10.250.0.0/19",
+		PodCIDR:  "10.250.0.0/11",
+		Azure:    &types.AzureSubnets{VNetCIDR: "10.250.0.0/16"},
+	}
+	err := g.validate(cluster, provider)
+	var validationErr *types.ValidationError
+	require.True(t, stderrors.As(err, &validationErr))
+	require.Equal(t, []string{
+		"Cluster.Networking.PodCIDR",
+		"Cluster.Networking.Network",
+		"Cluster.Networking.Azure",
+		"Provider.CustomConfigurations['publicscidr']",
+		"Provider.CustomConfigurations['vpccidr']",
+		"Provider.CustomConfigurations['internalscidr']",
+	}, validationErr.Fields())
+}
+
+func TestGardenerConfig(t *testing.T) {
+	g := gardenerProvisioner{}
+
+	cluster := &types.Cluster{
+		KubernetesVersion: "1.15.4",
+		Name:              "hydro-cluster",
+		DiskSizeGB:        30,
+		NodeCount:         2,
+		Location:          "europe-west3",
+		MachineType:       "n1-standard-4",
+	}
+	provider := &types.Provider{
+		Type:                types.Gardener,
+		ProjectName:         "my-project",
+		CredentialsFilePath: "/path/to/credentials",
+		Gardener: &types.GardenerConfig{
+			TargetProvider: types.GCP,
+			TargetSeed:     "gcp-eu1",
+			TargetSecret:   "secret-name",
+			DiskType:       "pd-standard",
+			WorkerCIDR:     "10.250.0.0/19",
+			AutoscalerMin:  2,
+			AutoscalerMax:  4,
+			GCP:            &types.GardenerGCPConfig{Zone: "europe-west3-b"},
+		},
+	}
+	require.NoError(t, g.validate(cluster, provider), "Validation should pass with the typed configuration only")
+
+	config := g.loadConfigurations(cluster, provider)
+	require.Equal(t, "gcp", config["target_provider"])
+	require.Equal(t, gcpProfile, config["target_profile"])
+	require.Equal(t, "europe-west3-b", config["zone"])
+	require.Equal(t, 4, config["autoscaler_max"])
+	require.Equal(t, 1, config["max_surge"])
+
+	provider.Gardener.AutoscalerMax = 1
+	provider.Gardener.GCP = nil
+	provider.Gardener.AWS = &types.GardenerAWSConfig{Zone: "eu-west-1b"}
+	err := g.validate(cluster, provider)
+	var validationErr *types.ValidationError
+	require.True(t, stderrors.As(err, &validationErr))
+	require.Equal(t, []string{
+		"Provider.Gardener.AWS",
+		"Provider.CustomConfigurations['autoscaler_max']",
+		"Provider.CustomConfigurations['zone']",
+	}, validationErr.Fields())
+
+	provider.Gardener.AWS = nil
+	provider.Gardener.GCP = &types.GardenerGCPConfig{Zone: "europe-west3-b"}
+	provider.Gardener.AutoscalerMin, provider.Gardener.AutoscalerMax = 0, 0
+	err = g.validate(cluster, provider)
+	require.True(t, stderrors.As(err, &validationErr))
+	require.Equal(t, []string{
+		"Provider.CustomConfigurations['autoscaler_min']",
+		"Provider.CustomConfigurations['autoscaler_max']",
+	}, validationErr.Fields(), "Unset typed bounds should be reported as missing")
+
+	provider.Gardener.AutoscalerMin = 3
+	err = g.validate(cluster, provider)
+	require.True(t, stderrors.As(err, &validationErr))
+	require.Equal(t, []string{"Provider.CustomConfigurations['autoscaler_max']"}, validationErr.Fields(), "A typed minimum without maximum should be refused")
+
+	provider.Gardener = nil
+	provider.CustomConfigurations = map[string]interface{}{
+		"target_provider": "gcp",
+		"target_seed":     "gcp-eu1",
+		"target_secret":   "secret-name",
+		"disk_type":       "pd-standard",
+		"zone":            "europe-west3-b",
+		"workercidr":      "10.250.0.0/19",
+		"autoscaler_min":  "2",
+		"autoscaler_max":  4,
+		"autoscaller_max": 4,
+		"max_surge":       1,
+		"max_unavailable": 0,
+	}
+	err = g.validate(cluster, provider)
+	require.True(t, stderrors.As(err, &validationErr))
+	require.Equal(t, []types.FieldError{
+		{Field: "Provider.CustomConfigurations['autoscaler_min']", Reason: "has to be an integer", Value: "2"},
+		{Field: "Provider.CustomConfigurations['autoscaller_max']", Reason: "is not a custom configuration of gardener", Value: 4},
+	}, validationErr.Errors, "Unknown custom configurations and those of the wrong type should be reported")
+}
+
+func TestDefault(t *testing.T) {
+	g := gardenerProvisioner{}
+
+	cluster := &types.Cluster{
+		Name:        "hydro-cluster",
+		NodeCount:   3,
+		Location:    "eu-west-1",
+		MachineType: "m4.2xlarge",
+	}
+	provider := &types.Provider{
+		Type:                types.Gardener,
+		ProjectName:         "my-project",
+		CredentialsFilePath: "/path/to/credentials",
+		CustomConfigurations: map[string]interface{}{
+			"target_provider": "aws",
+			"target_seed":     "aws-eu1",
+			"target_secret":   "secret-name",
+			"zone":            "eu-west-1b",
+			"workercidr":      "10.250.0.0/19",
+			"vpccidr":         "10.250.0.0/16",
+			"publicscidr":     "10.250.96.0/22",
+			"internalscidr":   "10.250.112.0/22",
+			"max_unavailable": 2,
+		},
+	}
+	g.Default(cluster, provider)
+	require.Equal(t, versions.Default, cluster.KubernetesVersion)
+	require.Equal(t, defaultDiskSizeGB, cluster.DiskSizeGB)
+	require.Equal(t, "gp2", provider.CustomConfigurations["disk_type"], "The disk type should be the one of the target provider")
+	require.Equal(t, 1, provider.CustomConfigurations["max_surge"])
+	require.Equal(t, 2, provider.CustomConfigurations["max_unavailable"], "Custom configurations should be kept if they are set")
+	require.Equal(t, 3, provider.CustomConfigurations["autoscaler_min"], "The default worker group should keep the node count")
+	require.Equal(t, 3, provider.CustomConfigurations["autoscaler_max"], "The default worker group should keep the node count")
+	require.NoError(t, g.validate(cluster, provider), "The defaults should make the specification valid")
+
+	cluster = &types.Cluster{
+		Name:        "hydro-cluster",
+		DiskSizeGB:  30,
+		Autoscaling: &types.Autoscaling{Min: 1, Max: 3},
+		NodePools: []types.NodePool{
+			{Name: "cpu", MachineType: "n1-standard-4", NodeCount: 2},
+			{Name: "gpu", MachineType: "n1-standard-4", NodeCount: 1, DiskType: "pd-ssd", Autoscaling: &types.Autoscaling{Min: 1, Max: 2, MaxUnavailable: 1}},
+		},
+	}
+	provider = &types.Provider{
+		Type:     types.Gardener,
+		Gardener: &types.GardenerConfig{TargetProvider: types.GCP},
+	}
+	g.Default(cluster, provider)
+	require.Equal(t, "pd-standard", provider.Gardener.DiskType)
+	require.Equal(t, 1, provider.Gardener.MaxSurge)
+	require.Zero(t, provider.Gardener.AutoscalerMax, "The bounds of the default worker group should not be set for clusters with node pools")
+	require.Equal(t, 1, cluster.Autoscaling.MaxSurge, "One node should be added at a time if no surge is set")
+	require.Equal(t, []types.NodePool{
+		{Name: "cpu", MachineType: "n1-standard-4", NodeCount: 2, DiskSizeGB: 30, DiskType: "pd-standard"},
+		{Name: "gpu", MachineType: "n1-standard-4", NodeCount: 1, DiskSizeGB: 30, DiskType: "pd-ssd", Autoscaling: &types.Autoscaling{Min: 1, Max: 2, MaxUnavailable: 1}},
+	}, cluster.NodePools, "Node pools should get the disk size and type of the cluster")
+}
+
+func TestResolveKubernetesVersion(t *testing.T) {
+	g := gardenerProvisioner{
+		newClients: func(context.Context, string) (*clients, error) {
+			return &clients{gardener: gardener_fake.NewSimpleClientset(gcpCloudProfile()).GardenV1beta1()}, nil
+		},
+	}
+	provider := &types.Provider{
+		Type:     types.Gardener,
+		Gardener: &types.GardenerConfig{TargetProvider: types.GCP},
+	}
+
+	for version, expected := range map[string]string{
+		"1.15":    "1.15.4",
+		"1.14":    "1.14.6",
+		"1.15.2":  "1.15.2",
+		"latest":  "1.15.4",
+		"default": "1.15.4",
+	} {
+		cluster := &types.Cluster{KubernetesVersion: version}
+		require.NoError(t, g.resolveKubernetesVersion(context.Background(), cluster, provider))
+		require.Equal(t, expected, cluster.KubernetesVersion, "Version %s should be resolved", version)
+	}
+
+	cluster := &types.Cluster{KubernetesVersion: "1.13"}
+	err := g.resolveKubernetesVersion(context.Background(), cluster, provider)
+	var validationErr *types.ValidationError
+	require.True(t, stderrors.As(err, &validationErr), "Expired versions should not be supported")
+	require.Equal(t, []types.FieldError{{
+		Field:  "Cluster.KubernetesVersion",
+		Reason: "is not supported, use latest, default, a minor version such as 1.15, or one of: 1.15.4, 1.15.2, 1.14.6",
+		Value:  "1.13",
+	}}, validationErr.Errors)
+
+	cluster = &types.Cluster{
+		KubernetesVersion: "latest",
+		ClusterInfo:       &types.ClusterInfo{InternalState: &types.InternalState{KubernetesVersion: "1.15.2"}},
+	}
+	require.NoError(t, g.resolveKubernetesVersion(context.Background(), cluster, provider))
+	require.Equal(t, "1.15.2", cluster.KubernetesVersion, "The stored version should be kept while the specification matches it")
+
+	cluster.KubernetesVersion = "1.14"
+	require.NoError(t, g.resolveKubernetesVersion(context.Background(), cluster, provider))
+	require.Equal(t, "1.14.6", cluster.KubernetesVersion, "A version which does not match the stored one should be resolved")
+
+	provider.Gardener.TargetProvider = types.AWS
+	err = g.resolveKubernetesVersion(context.Background(), &types.Cluster{KubernetesVersion: "1.15"}, provider)
+	require.Error(t, err, "The cloud profile of the target provider should be used")
+}
+
+func TestMachineTypes(t *testing.T) {
+	calls := 0
+	g := gardenerProvisioner{
+		newClients: func(context.Context, string) (*clients, error) {
+			calls++
+			return &clients{gardener: gardener_fake.NewSimpleClientset(gcpCloudProfile()).GardenV1beta1()}, nil
+		},
+	}
+	provider := &types.Provider{
+		Type:                types.Gardener,
+		ProjectName:         "my-project",
+		CredentialsFilePath: "/path/to/machine-types-kubeconfig",
+		Gardener:            &types.GardenerConfig{TargetProvider: types.GCP},
+	}
+
+	machineTypes, err := g.MachineTypes(context.Background(), provider, "europe-west3")
+	require.NoError(t, err)
+	require.Equal(t, []types.MachineType{
+		{Name: "n1-standard-4", CPUs: 4, MemoryMB: 15360},
+		{Name: "n1-highmem-8", CPUs: 8, MemoryMB: 53248},
+	}, machineTypes, "Only the usable machine types should be listed")
+
+	_, err = g.MachineTypes(context.Background(), provider, "us-central1")
+	require.NoError(t, err)
+	require.Equal(t, 1, calls, "The machine types should be cached for all locations")
+
+	cluster := &types.Cluster{Location: "europe-west3", MachineType: "n1-standard-1"}
+	err = g.validateMachineTypes(context.Background(), cluster, provider)
+	var validationErr *types.ValidationError
+	require.True(t, stderrors.As(err, &validationErr), "Machine types which are not usable should be rejected")
+	require.Equal(t, []string{"Cluster.MachineType"}, validationErr.Fields())
+
+	provider.Gardener = nil
+	_, err = g.MachineTypes(context.Background(), provider, "europe-west3")
+	require.True(t, stderrors.As(err, &validationErr), "The target provider should be required")
+	require.Equal(t, []string{"Provider.CustomConfigurations['target_provider']"}, validationErr.Fields())
+}
+
 func TestLoadConfigurations(t *testing.T) {
 
 	g := gardenerProvisioner{}
@@ -280,6 +763,8 @@ func TestLoadConfigurations(t *testing.T) {
 		NodeCount:         2,
 		Location:          "europe-west3",
 		MachineType:       "type1",
+		Labels:            map[string]string{"team": "hydro"},
+		Annotations:       map[string]string{"hydroform.kyma-project.io/owner": "hydro@example.com"},
 	}
 	provider := &types.Provider{
 		Type:                types.Gardener,
@@ -304,6 +789,10 @@ func TestLoadConfigurations(t *testing.T) {
 	require.Equal(t, cluster.KubernetesVersion, config["kubernetes_version"])
 	require.Equal(t, cluster.Location, config["location"])
 	require.Equal(t, fmt.Sprintf("garden-%s", provider.ProjectName), config["namespace"])
+	require.Equal(t, map[string]interface{}{"team": "hydro"}, config["labels"])
+	require.Equal(t, map[string]interface{}{"hydroform.kyma-project.io/owner": "hydro@example.com"}, config["annotations"])
+	require.Empty(t, config["node_pools"])
+	require.Empty(t, config["networking"])
 
 	for k, v := range provider.CustomConfigurations {
 		require.Equal(t, v, config[k], fmt.Sprintf("Custom config %s is incorrect", k))
@@ -312,18 +801,26 @@ func TestLoadConfigurations(t *testing.T) {
 
 func TestProvision(t *testing.T) {
 	mockOp := &mocks.Operator{}
+	shoot := &gardener_types.Shoot{
+		ObjectMeta: metav1.ObjectMeta{Name: "hydro-cluster", Namespace: "garden-my-project"},
+		Status:     gardener_types.ShootStatus{TechnicalID: "shoot--my-project--hydro-cluster"},
+	}
 	g := gardenerProvisioner{
 		operator: mockOp,
+		catalog:  offlineCatalog(t),
+		newClients: func(context.Context, string) (*clients, error) {
+			return &clients{gardener: gardener_fake.NewSimpleClientset(shoot, gcpCloudProfile()).GardenV1beta1()}, nil
+		},
 	}
 
 	cluster := &types.Cluster{
 		CPU:               1,
-		KubernetesVersion: "1.12",
+		KubernetesVersion: "1.15.4",
 		Name:              "hydro-cluster",
 		DiskSizeGB:        30,
 		NodeCount:         2,
 		Location:          "europe-west3",
-		MachineType:       "type1",
+		MachineType:       "n1-standard-4",
 	}
 	provider := &types.Provider{
 		Type:                types.Gardener,
@@ -353,18 +850,20 @@ func TestProvision(t *testing.T) {
 			TerraformState: nil,
 		},
 	}
-	mockOp.On("Create", types.Gardener, g.loadConfigurations(cluster, provider)).Return(result, nil)
+	mockOp.On("Create", mock.Anything, types.Gardener, g.loadConfigurations(cluster, provider)).Return(result, nil)
 
-	cluster, err := g.Provision(cluster, provider)
+	cluster, err := g.Provision(context.Background(), cluster, provider)
 	require.NoError(t, err, "Provision should succeed")
 	require.Equal(t, result, cluster.ClusterInfo, "The cluster info returned from the operator should be in the cluster returned by Provision")
+	require.Equal(t, "shoot--my-project--hydro-cluster", cluster.ClusterInfo.Outputs["technical_id"], "The technical ID should be read from the shoot")
+	require.Equal(t, "1.15.4", cluster.ClusterInfo.InternalState.KubernetesVersion, "The resolved version should be stored")
 
 	badCluster := &types.Cluster{
 		CPU: 1,
 	}
-	mockOp.On("Create", types.Gardener, g.loadConfigurations(badCluster, provider)).Return(badCluster, errors.New("Unable to provision cluster"))
+	mockOp.On("Create", mock.Anything, types.Gardener, g.loadConfigurations(badCluster, provider)).Return(badCluster, errors.New("Unable to provision cluster"))
 
-	_, err = g.Provision(badCluster, provider)
+	_, err = g.Provision(context.Background(), badCluster, provider)
 	require.Error(t, err, "Provision should fail")
 }
 
@@ -405,17 +904,376 @@ func TestDeProvision(t *testing.T) {
 		TerraformState: terraform.NewState(),
 	}
 	cluster.ClusterInfo.InternalState = goodState
-	mockOp.On("Delete", goodState, types.Gardener, g.loadConfigurations(cluster, provider)).Return(nil)
+	mockOp.On("Delete", mock.Anything, goodState, types.Gardener, g.loadConfigurations(cluster, provider)).Return(nil)
 
-	err := g.Deprovision(cluster, provider)
+	err := g.Deprovision(context.Background(), cluster, provider)
 	require.NoError(t, err, "Deprovision should succeed")
 
 	badState := &types.InternalState{
 		TerraformState: nil,
 	}
 	cluster.ClusterInfo.InternalState = badState
-	mockOp.On("Delete", badState, types.Gardener, g.loadConfigurations(cluster, provider)).Return(errors.New("Unable to deprovision cluster"))
+	mockOp.On("Delete", mock.Anything, badState, types.Gardener, g.loadConfigurations(cluster, provider)).Return(errors.New("Unable to deprovision cluster"))
 
-	err = g.Deprovision(cluster, provider)
+	err = g.Deprovision(context.Background(), cluster, provider)
 	require.Error(t, err, "Deprovision should fail")
 }
+
+func TestStatusCancelled(t *testing.T) {
+	// the server only answers once the request is abandoned by the client
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	kubeconfig, err := ioutil.TempFile("", "kubeconfig")
+	require.NoError(t, err)
+	defer os.Remove(kubeconfig.Name())
+	_, err = kubeconfig.WriteString(fmt.Sprintf(`apiVersion: v1
+kind: Config
+clusters:
+- name: garden
+  cluster:
+    server: %s
+contexts:
+- name: garden
+  context:
+    cluster: garden
+current-context: garden
+`, server.URL))
+	require.NoError(t, err)
+	require.NoError(t, kubeconfig.Close())
+
+	g := gardenerProvisioner{}
+	cluster := &types.Cluster{
+		KubernetesVersion: "1.12",
+		Name:              "hydro-cluster",
+		DiskSizeGB:        30,
+		NodeCount:         2,
+		Location:          "europe-west3",
+		MachineType:       "type1",
+	}
+	provider := &types.Provider{
+		Type:                types.Gardener,
+		ProjectName:         "my-project",
+		CredentialsFilePath: kubeconfig.Name(),
+		CustomConfigurations: map[string]interface{}{
+			"target_provider": "gcp",
+			"target_seed":     "gcp-eu1",
+			"target_secret":   "secret-name",
+			"disk_type":       "pd-standard",
+			"zone":            "europe-west3-b",
+			"workercidr":      "10.250.0.0/19",
+			"autoscaler_min":  2,
+			"autoscaler_max":  4,
+			"max_surge":       4,
+			"max_unavailable": 1,
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	_, err = g.Status(ctx, cluster, provider)
+	require.Error(t, err, "Status should fail when the context expires")
+	require.Equal(t, context.DeadlineExceeded, ctx.Err())
+}
+
+func TestPlan(t *testing.T) {
+	mockOp := &mocks.Operator{}
+	g := gardenerProvisioner{
+		operator: mockOp,
+		catalog:  offlineCatalog(t),
+		newClients: func(context.Context, string) (*clients, error) {
+			return &clients{gardener: gardener_fake.NewSimpleClientset(gcpCloudProfile()).GardenV1beta1()}, nil
+		},
+	}
+
+	cluster := &types.Cluster{
+		CPU:               1,
+		KubernetesVersion: "1.15.4",
+		Name:              "hydro-cluster",
+		DiskSizeGB:        30,
+		NodeCount:         2,
+		Location:          "europe-west3",
+		MachineType:       "n1-standard-4",
+	}
+	provider := &types.Provider{
+		Type:                types.Gardener,
+		ProjectName:         "my-project",
+		CredentialsFilePath: "/path/to/credentials",
+		CustomConfigurations: map[string]interface{}{
+			"target_provider": "gcp",
+			"target_seed":     "gcp-eu1",
+			"target_secret":   "secret-name",
+			"disk_type":       "pd-standard",
+			"zone":            "europe-west3-b",
+			"workercidr":      "10.250.0.0/19",
+			"autoscaler_min":  2,
+			"autoscaler_max":  4,
+			"max_surge":       4,
+			"max_unavailable": 1,
+		},
+	}
+
+	createPlan := &types.Plan{Resources: []types.ResourceChange{{Address: "gardener_shoot.test_cluster", Change: types.ChangeCreate}}}
+	mockOp.On("Plan", mock.Anything, (*types.InternalState)(nil), types.Gardener, g.loadConfigurations(cluster, provider), false).Return(createPlan, nil)
+
+	plan, err := g.Plan(context.Background(), cluster, provider)
+	require.NoError(t, err, "Plan should succeed")
+	require.Equal(t, createPlan, plan)
+
+	_, err = g.PlanDeprovision(context.Background(), cluster, provider)
+	require.Error(t, err, "PlanDeprovision should fail without the cluster state")
+
+	state := &types.InternalState{
+		TerraformState: terraform.NewState(),
+	}
+	cluster.ClusterInfo = &types.ClusterInfo{InternalState: state}
+	mockOp.On("Plan", mock.Anything, state, types.Gardener, g.loadConfigurations(cluster, provider), true).Return(nil, errors.New("Unable to plan"))
+
+	_, err = g.PlanDeprovision(context.Background(), cluster, provider)
+	require.Error(t, err, "PlanDeprovision should fail")
+}
+
+func TestStateCheckedFirst(t *testing.T) {
+	g := gardenerProvisioner{
+		newClients: func(context.Context, string) (*clients, error) {
+			return nil, errors.New("the Gardener API should not be called")
+		},
+	}
+	cluster := &types.Cluster{
+		CPU:               1,
+		KubernetesVersion: "latest",
+		Name:              "hydro-cluster",
+		DiskSizeGB:        30,
+		NodeCount:         2,
+		Location:          "europe-west3",
+		MachineType:       "n1-standard-4",
+	}
+	provider := &types.Provider{
+		Type:                types.Gardener,
+		ProjectName:         "my-project",
+		CredentialsFilePath: "/path/to/credentials",
+		CustomConfigurations: map[string]interface{}{
+			"target_provider": "gcp",
+			"target_seed":     "gcp-eu1",
+			"target_secret":   "secret-name",
+			"disk_type":       "pd-standard",
+			"zone":            "europe-west3-b",
+			"workercidr":      "10.250.0.0/19",
+			"autoscaler_min":  2,
+			"autoscaler_max":  4,
+			"max_surge":       4,
+			"max_unavailable": 1,
+		},
+	}
+
+	_, err := g.Update(context.Background(), cluster, provider, false)
+	require.EqualError(t, err, errs.EmptyClusterInfo, "Update should check the cluster state before calling the Gardener API")
+	_, err = g.Drift(context.Background(), cluster, provider)
+	require.EqualError(t, err, errs.EmptyClusterInfo, "Drift should check the cluster state before calling the Gardener API")
+}
+
+func TestImport(t *testing.T) {
+	mockOp := &mocks.Operator{}
+
+	shoot := &gardener_types.Shoot{
+		ObjectMeta: metav1.ObjectMeta{Name: "hydro-cluster", Namespace: "garden-my-project"},
+		Spec: gardener_types.ShootSpec{
+			Kubernetes: gardener_types.Kubernetes{Version: "1.15.2"},
+		},
+		Status: gardener_types.ShootStatus{
+			LastOperation: &gardener_core.LastOperation{
+				Type:  gardener_core.LastOperationTypeReconcile,
+				State: gardener_core.LastOperationStateSucceeded,
+			},
+			TechnicalID: "shoot--my-project--hydro-cluster",
+		},
+	}
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "hydro-cluster.kubeconfig", Namespace: "garden-my-project"},
+		Data: map[string][]byte{
+			"kubeconfig": []byte(`apiVersion: v1
+kind: Config
+current-context: shoot
+contexts:
+- name: shoot
+  context:
+    cluster: shoot
+    user: admin
+clusters:
+- name: shoot
+  cluster:
+    server: https://api.hydro-cluster.fake
+    certificate-authority-data: TXkgY2VydA==
+users:
+- name: admin
+  user:
+    token: token
+`),
+		},
+	}
+
+	g := gardenerProvisioner{
+		operator: mockOp,
+		catalog:  offlineCatalog(t),
+		newClients: func(context.Context, string) (*clients, error) {
+			return &clients{
+				gardener:   gardener_fake.NewSimpleClientset(shoot, gcpCloudProfile()).GardenV1beta1(),
+				kubernetes: k8s_fake.NewSimpleClientset(secret),
+			}, nil
+		},
+	}
+
+	cluster := &types.Cluster{
+		CPU:               1,
+		KubernetesVersion: "1.15.2",
+		Name:              "hydro-cluster",
+		DiskSizeGB:        30,
+		NodeCount:         2,
+		Location:          "europe-west3",
+		MachineType:       "n1-standard-4",
+	}
+	provider := &types.Provider{
+		Type:                types.Gardener,
+		ProjectName:         "my-project",
+		CredentialsFilePath: "/path/to/credentials",
+		CustomConfigurations: map[string]interface{}{
+			"target_provider": "gcp",
+			"target_seed":     "gcp-eu1",
+			"target_secret":   "secret-name",
+			"disk_type":       "pd-standard",
+			"zone":            "europe-west3-b",
+			"workercidr":      "10.250.0.0/19",
+			"autoscaler_min":  2,
+			"autoscaler_max":  4,
+			"max_surge":       4,
+			"max_unavailable": 1,
+		},
+	}
+
+	state := &types.InternalState{
+		TerraformState: terraform.NewState(),
+	}
+	mockOp.On("Import", mock.Anything, types.Gardener, g.loadConfigurations(cluster, provider)).Return(&types.ClusterInfo{InternalState: state}, nil)
+
+	// the shoot runs 1.15.2, which is not the newest 1.15 version
+	cluster.KubernetesVersion = "1.15"
+	cluster, err := g.Import(context.Background(), cluster, provider)
+	require.NoError(t, err, "Import should succeed")
+	require.Equal(t, state, cluster.ClusterInfo.InternalState, "The state built by the operator should be in the imported cluster")
+	require.Equal(t, "1.15.2", cluster.KubernetesVersion, "The live version should be kept if the specification matches it")
+	require.Equal(t, "1.15.2", state.KubernetesVersion, "The live version should be stored")
+	require.Equal(t, "https://api.hydro-cluster.fake", cluster.ClusterInfo.Endpoint)
+	require.Equal(t, []byte("My cert"), cluster.ClusterInfo.CertificateAuthorityData)
+	require.Equal(t, types.Provisioned, cluster.ClusterInfo.Status.Phase)
+	require.Equal(t, "shoot--my-project--hydro-cluster", cluster.ClusterInfo.Outputs["technical_id"], "The technical ID should be read from the shoot")
+
+	missing := &types.Cluster{
+		CPU:               1,
+		KubernetesVersion: "1.15.4",
+		Name:              "missing-cluster",
+		DiskSizeGB:        30,
+		NodeCount:         2,
+		Location:          "europe-west3",
+		MachineType:       "n1-standard-4",
+	}
+	_, err = g.Import(context.Background(), missing, provider)
+	require.Error(t, err, "Import should fail when the shoot does not exist")
+	var notFound *types.NotFoundError
+	require.True(t, stderrors.As(err, &notFound), "A missing cluster should be reported with a NotFoundError")
+	mockOp.AssertNumberOfCalls(t, "Import", 1)
+}
+
+func TestList(t *testing.T) {
+	newShoot := func(name string, labels map[string]string, workers ...string) *gardener_types.Shoot {
+		shoot := &gardener_types.Shoot{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "garden-my-project", Labels: labels},
+			Spec: gardener_types.ShootSpec{
+				Cloud:      gardener_types.Cloud{Region: "europe-west3", GCP: &gardener_types.GCPCloud{}},
+				Kubernetes: gardener_types.Kubernetes{Version: "1.15.4"},
+			},
+			Status: gardener_types.ShootStatus{
+				LastOperation: &gardener_core.LastOperation{State: gardener_core.LastOperationStateSucceeded},
+			},
+		}
+		for _, w := range workers {
+			shoot.Spec.Cloud.GCP.Workers = append(shoot.Spec.Cloud.GCP.Workers, gardener_types.GCPWorker{
+				Worker:     gardener_types.Worker{Name: w, MachineType: "n1-standard-4", AutoScalerMin: 1, AutoScalerMax: 1},
+				VolumeSize: "30Gi",
+				VolumeType: "pd-standard",
+			})
+		}
+		shoot.Spec.Cloud.GCP.Zones = []string{"europe-west3-a"}
+		return shoot
+	}
+	other := newShoot("other-cluster", nil, "cpu-worker-1")
+	other.Namespace = "garden-other-project"
+
+	g := gardenerProvisioner{
+		newClients: func(context.Context, string) (*clients, error) {
+			return &clients{
+				gardener: gardener_fake.NewSimpleClientset(
+					newShoot("hydro-cluster", map[string]string{"team": "hydro"}, "cpu-worker-1", "cpu-worker-2"),
+					newShoot("ci-cluster", map[string]string{"team": "ci"}, "cpu-worker-1"),
+					other,
+				).GardenV1beta1(),
+			}, nil
+		},
+	}
+	provider := &types.Provider{
+		Type:                types.Gardener,
+		ProjectName:         "my-project",
+		CredentialsFilePath: "/path/to/credentials",
+	}
+
+	clusters, err := g.List(context.Background(), provider, nil)
+	require.NoError(t, err, "List should succeed")
+	require.Len(t, clusters, 2, "Only the Shoots of the project should be listed")
+
+	hydroCluster := newShoot("hydro-cluster", map[string]string{"team": "hydro"}, "cpu-worker-1", "gpu")
+	gpu := &hydroCluster.Spec.Cloud.GCP.Workers[1]
+	gpu.MachineType = "n1-highmem-8"
+	gpu.AutoScalerMax = 4
+	maxSurge := intstr.FromInt(2)
+	gpu.MaxSurge = &maxSurge
+	gpu.Labels = map[string]string{"pool": "gpu"}
+	gpu.Taints = []v1.Taint{{Key: "gpu", Effect: v1.TaintEffectNoSchedule}}
+	g.newClients = func(context.Context, string) (*clients, error) {
+		return &clients{gardener: gardener_fake.NewSimpleClientset(hydroCluster).GardenV1beta1()}, nil
+	}
+
+	clusters, err = g.List(context.Background(), provider, map[string]string{"team": "hydro"})
+	require.NoError(t, err)
+	require.Equal(t, []*types.Cluster{{
+		Name:              "hydro-cluster",
+		Location:          "europe-west3",
+		KubernetesVersion: "1.15.4",
+		NodeCount:         2,
+		MachineType:       "n1-standard-4",
+		DiskSizeGB:        30,
+		NodePools: []types.NodePool{
+			{Name: "cpu-worker-1", MachineType: "n1-standard-4", NodeCount: 1, DiskSizeGB: 30, DiskType: "pd-standard", Zones: []string{"europe-west3-a"}},
+			{
+				Name:        "gpu",
+				MachineType: "n1-highmem-8",
+				NodeCount:   1,
+				Autoscaling: &types.Autoscaling{Min: 1, Max: 4, MaxSurge: 2},
+				DiskSizeGB:  30,
+				DiskType:    "pd-standard",
+				Labels:      map[string]string{"pool": "gpu"},
+				Taints:      []types.Taint{{Key: "gpu", Effect: types.NoSchedule}},
+				Zones:       []string{"europe-west3-a"},
+			},
+		},
+		Labels: map[string]string{"team": "hydro"},
+		ClusterInfo: &types.ClusterInfo{
+			Status: &types.ClusterStatus{Phase: types.Provisioned, Labels: map[string]string{"team": "hydro"}},
+		},
+	}}, clusters)
+
+	_, err = g.List(context.Background(), &types.Provider{Type: types.Gardener}, nil)
+	var validationErr *types.ValidationError
+	require.True(t, stderrors.As(err, &validationErr), "List should fail without project and credentials")
+}
@@ -2,16 +2,27 @@ package gcp
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
+	"net/http"
 	"regexp"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/kyma-incubator/hydroform/catalog"
 	"github.com/kyma-incubator/hydroform/internal/errs"
 
-	"cloud.google.com/go/container"
 	"github.com/kyma-incubator/hydroform/internal/operator"
+	"github.com/kyma-incubator/hydroform/internal/versions"
 	"github.com/kyma-incubator/hydroform/types"
 	"github.com/pkg/errors"
+	compute "google.golang.org/api/compute/v1"
+	container "google.golang.org/api/container/v1"
+	"google.golang.org/api/googleapi"
 	"google.golang.org/api/option"
+	metav1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/tools/clientcmd/api"
 )
@@ -19,49 +30,65 @@ import (
 // gcpProvisioner implements Provisioner
 type gcpProvisioner struct {
 	provisionOperator operator.Operator
+	// apiOptions configure the clients of the GCP APIs. If empty, the clients authenticate with the credentials file of the provider.
+	apiOptions []option.ClientOption
+	// catalog lists the machine types offered in a location. If nil, they are listed with the compute API and cached.
+	catalog catalog.Catalog
+}
+
+// SetMachineTypeCatalog makes the provisioner list the machine types from the catalog instead of the compute API.
+func (g *gcpProvisioner) SetMachineTypeCatalog(machineTypes catalog.Catalog) {
+	g.catalog = machineTypes
 }
 
 // Provision requests provisioning of a new Kubernetes cluster on GCP with the given configurations.
-func (g *gcpProvisioner) Provision(cluster *types.Cluster, provider *types.Provider) (*types.Cluster, error) {
+func (g *gcpProvisioner) Provision(ctx context.Context, cluster *types.Cluster, provider *types.Provider) (*types.Cluster, error) {
 	if err := g.validateInputs(cluster, provider); err != nil {
 		return nil, err
 	}
+	if err := g.resolveKubernetesVersion(ctx, cluster, provider); err != nil {
+		return nil, err
+	}
+	if err := g.validateMachineTypes(ctx, cluster, provider); err != nil {
+		return nil, err
+	}
 
 	config := g.loadConfigurations(cluster, provider)
 
-	clusterInfo, err := g.provisionOperator.Create(provider.Type, config)
+	clusterInfo, err := g.provisionOperator.Create(ctx, provider.Type, config)
+	// keep the state reached so far, so that a failed provisioning can be cleaned up
+	if clusterInfo != nil {
+		cluster.ClusterInfo = clusterInfo
+		versions.Store(cluster)
+	}
 	if err != nil {
 		return cluster, errors.Wrap(err, "unable to provision gcp cluster")
 	}
-
-	cluster.ClusterInfo = clusterInfo
+	if err := g.readLiveOutputs(ctx, cluster, provider); err != nil {
+		return cluster, errors.Wrap(err, "unable to read the outputs of gcp cluster")
+	}
 	return cluster, nil
 }
 
 // Status returns the ClusterStatus for the requested cluster.
-func (g *gcpProvisioner) Status(cluster *types.Cluster, provider *types.Provider) (*types.ClusterStatus, error) {
+func (g *gcpProvisioner) Status(ctx context.Context, cluster *types.Cluster, provider *types.Provider) (*types.ClusterStatus, error) {
 	if err := g.validateInputs(cluster, provider); err != nil {
 		return nil, err
 	}
 
-	containerClient, err := container.NewClient(context.Background(),
-		provider.ProjectName,
-		option.WithCredentialsFile(provider.CredentialsFilePath))
-	if err != nil {
-		return nil, errors.Wrap(err, "unable to create GCP client")
-	}
-	cl, err := containerClient.Cluster(context.Background(), cluster.Location, cluster.Name)
+	cl, err := g.getCluster(ctx, cluster, provider)
 	if err != nil {
-		return nil, errors.Wrap(err, "unable to get cluster info")
+		return nil, err
 	}
 
 	return &types.ClusterStatus{
-		Phase: g.convertGCPStatus(cl.Status),
+		Phase:  g.convertGCPStatus(cl.Status),
+		Labels: cl.ResourceLabels,
 	}, nil
 }
 
 // Credentials returns the Kubeconfig file as a byte array for the requested cluster.
-func (g *gcpProvisioner) Credentials(cluster *types.Cluster, provider *types.Provider) ([]byte, error) {
+func (g *gcpProvisioner) Credentials(ctx context.Context, cluster *types.Cluster, provider *types.Provider) ([]byte, error) {
 	if err := g.validateInputs(cluster, provider); err != nil {
 		return nil, err
 	}
@@ -94,7 +121,7 @@ func (g *gcpProvisioner) Credentials(cluster *types.Cluster, provider *types.Pro
 }
 
 // Deprovision requests deprovisioning of an existing cluster on GCP with the given configurations.
-func (g *gcpProvisioner) Deprovision(cluster *types.Cluster, provider *types.Provider) error {
+func (g *gcpProvisioner) Deprovision(ctx context.Context, cluster *types.Cluster, provider *types.Provider) error {
 	if err := g.validateInputs(cluster, provider); err != nil {
 		return err
 	}
@@ -104,7 +131,7 @@ func (g *gcpProvisioner) Deprovision(cluster *types.Cluster, provider *types.Pro
 
 	config := g.loadConfigurations(cluster, provider)
 
-	err := g.provisionOperator.Delete(cluster.ClusterInfo.InternalState, provider.Type, config)
+	err := g.provisionOperator.Delete(ctx, cluster.ClusterInfo.InternalState, provider.Type, config)
 	if err != nil {
 		return errors.Wrap(err, "unable to deprovision gcp cluster")
 	}
@@ -112,6 +139,184 @@ func (g *gcpProvisioner) Deprovision(cluster *types.Cluster, provider *types.Pro
 	return nil
 }
 
+// Update applies the current cluster configuration to an existing cluster on GCP. Changes that would recreate the cluster are refused unless allowReplacement is true.
+func (g *gcpProvisioner) Update(ctx context.Context, cluster *types.Cluster, provider *types.Provider, allowReplacement bool) (*types.Cluster, error) {
+	if err := g.validateInputs(cluster, provider); err != nil {
+		return nil, err
+	}
+	if cluster.ClusterInfo == nil || cluster.ClusterInfo.InternalState == nil {
+		return nil, errors.New(errs.EmptyClusterInfo)
+	}
+	if err := g.resolveKubernetesVersion(ctx, cluster, provider); err != nil {
+		return nil, err
+	}
+	if err := g.validateMachineTypes(ctx, cluster, provider); err != nil {
+		return nil, err
+	}
+
+	config := g.loadConfigurations(cluster, provider)
+
+	clusterInfo, err := g.provisionOperator.Update(ctx, cluster.ClusterInfo.InternalState, provider.Type, config, allowReplacement)
+	if clusterInfo != nil {
+		cluster.ClusterInfo = clusterInfo
+		versions.Store(cluster)
+	}
+	if err != nil {
+		return cluster, errors.Wrap(err, "unable to update gcp cluster")
+	}
+	if err := g.readLiveOutputs(ctx, cluster, provider); err != nil {
+		return cluster, errors.Wrap(err, "unable to read the outputs of gcp cluster")
+	}
+	return cluster, nil
+}
+
+// Plan returns the changes provisioning the cluster on GCP would make. If the cluster holds the state of a previous provisioning, the changes are computed against it.
+func (g *gcpProvisioner) Plan(ctx context.Context, cluster *types.Cluster, provider *types.Provider) (*types.Plan, error) {
+	if err := g.validateInputs(cluster, provider); err != nil {
+		return nil, err
+	}
+	if err := g.resolveKubernetesVersion(ctx, cluster, provider); err != nil {
+		return nil, err
+	}
+	if err := g.validateMachineTypes(ctx, cluster, provider); err != nil {
+		return nil, err
+	}
+
+	var state *types.InternalState
+	if cluster.ClusterInfo != nil {
+		state = cluster.ClusterInfo.InternalState
+	}
+	config := g.loadConfigurations(cluster, provider)
+
+	plan, err := g.provisionOperator.Plan(ctx, state, provider.Type, config, false)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to plan gcp cluster provisioning")
+	}
+	return plan, nil
+}
+
+// PlanDeprovision returns the changes deprovisioning the cluster on GCP would make.
+func (g *gcpProvisioner) PlanDeprovision(ctx context.Context, cluster *types.Cluster, provider *types.Provider) (*types.Plan, error) {
+	if err := g.validateInputs(cluster, provider); err != nil {
+		return nil, err
+	}
+	if cluster.ClusterInfo == nil || cluster.ClusterInfo.InternalState == nil {
+		return nil, errors.New(errs.EmptyClusterInfo)
+	}
+
+	config := g.loadConfigurations(cluster, provider)
+
+	plan, err := g.provisionOperator.Plan(ctx, cluster.ClusterInfo.InternalState, provider.Type, config, true)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to plan gcp cluster deprovisioning")
+	}
+	return plan, nil
+}
+
+// Drift compares the cluster specification, its stored state and the live cluster on GCP, without changing anything.
+func (g *gcpProvisioner) Drift(ctx context.Context, cluster *types.Cluster, provider *types.Provider) (*types.DriftReport, error) {
+	if err := g.validateInputs(cluster, provider); err != nil {
+		return nil, err
+	}
+	if cluster.ClusterInfo == nil || cluster.ClusterInfo.InternalState == nil {
+		return nil, errors.New(errs.EmptyClusterInfo)
+	}
+	if err := g.resolveKubernetesVersion(ctx, cluster, provider); err != nil {
+		return nil, err
+	}
+	if err := g.validateMachineTypes(ctx, cluster, provider); err != nil {
+		return nil, err
+	}
+
+	config := g.loadConfigurations(cluster, provider)
+
+	report, err := g.provisionOperator.Drift(ctx, cluster.ClusterInfo.InternalState, provider.Type, config)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to detect gcp cluster drift")
+	}
+	return report, nil
+}
+
+// Import adopts an existing cluster on GCP that was not provisioned by Hydroform. The cluster is looked up by name, location and project, and its state is built so that it can be managed with the other operations afterwards.
+func (g *gcpProvisioner) Import(ctx context.Context, cluster *types.Cluster, provider *types.Provider) (*types.Cluster, error) {
+	if err := g.validateInputs(cluster, provider); err != nil {
+		return nil, err
+	}
+
+	cl, err := g.getCluster(ctx, cluster, provider)
+	if err != nil {
+		return nil, err
+	}
+	// the cluster keeps its version if the specification matches it, instead of being upgraded to the newest match
+	if versions.Matches(cluster.KubernetesVersion, cl.CurrentMasterVersion) {
+		cluster.KubernetesVersion = cl.CurrentMasterVersion
+	} else if err := g.resolveKubernetesVersion(ctx, cluster, provider); err != nil {
+		return nil, err
+	}
+	if err := g.validateMachineTypes(ctx, cluster, provider); err != nil {
+		return nil, err
+	}
+	if cl.MasterAuth == nil {
+		return nil, errors.New("the cluster has no master authentication data")
+	}
+	certificateData, err := base64.StdEncoding.DecodeString(cl.MasterAuth.ClusterCaCertificate)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to decode the cluster CA certificate")
+	}
+
+	config := g.loadConfigurations(cluster, provider)
+
+	clusterInfo, err := g.provisionOperator.Import(ctx, provider.Type, config)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to import gcp cluster")
+	}
+	clusterInfo.Endpoint = cl.Endpoint
+	clusterInfo.CertificateAuthorityData = certificateData
+	clusterInfo.Status = &types.ClusterStatus{
+		Phase: g.convertGCPStatus(cl.Status),
+	}
+	setLiveOutputs(clusterInfo, cl)
+
+	cluster.ClusterInfo = clusterInfo
+	versions.Store(cluster)
+	return cluster, nil
+}
+
+// List returns the GKE clusters in all locations of the project. If labels are given, only the clusters with all of these resource labels are returned.
+// If some zones are unavailable, the clusters found elsewhere are returned with an *types.IncompleteListError naming the zones.
+func (g *gcpProvisioner) List(ctx context.Context, provider *types.Provider, labels map[string]string) ([]*types.Cluster, error) {
+	if fieldErrs := validateProvider(provider); len(fieldErrs) > 0 {
+		return nil, &types.ValidationError{Errors: fieldErrs}
+	}
+
+	containerService, err := g.containerService(ctx, provider)
+	if err != nil {
+		return nil, err
+	}
+	// the location "-" matches all locations of the project
+	resp, err := containerService.Projects.Locations.Clusters.List(fmt.Sprintf("projects/%s/locations/-", provider.ProjectName)).Context(ctx).Do()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list gcp clusters")
+	}
+
+	var clusters []*types.Cluster
+	for _, cl := range resp.Clusters {
+		if matchLabels(cl.ResourceLabels, labels) {
+			clusters = append(clusters, g.convertCluster(cl))
+		}
+	}
+	// an incomplete list would hide clusters from cleanups, so the callers are told about it
+	if len(resp.MissingZones) > 0 {
+		return clusters, &types.IncompleteListError{Locations: resp.MissingZones}
+	}
+	return clusters, nil
+}
+
+// Validate checks the cluster and provider specification for GCP without calling the provider.
+func (g *gcpProvisioner) Validate(cluster *types.Cluster, provider *types.Provider) error {
+	return g.validateInputs(cluster, provider)
+}
+
 // New creates a new instance of gcpProvisioner.
 func New(operatorType operator.Type) *gcpProvisioner {
 	var op operator.Operator
@@ -128,41 +333,187 @@ func New(operatorType operator.Type) *gcpProvisioner {
 	}
 }
 
+// schema describes the custom configurations of GCP. Everything GKE needs is part of the cluster specification, so no custom configurations are accepted.
+var schema = &types.ProviderSchema{Type: types.GCP}
+
+// Describe returns the schema of the custom configurations of GCP.
+func (g *gcpProvisioner) Describe() *types.ProviderSchema {
+	return schema
+}
+
+const (
+	// defaultDiskSizeGB is the disk size of the nodes GKE uses if none is given.
+	defaultDiskSizeGB = 100
+	// defaultDiskType is the type of the node disks GKE uses if none is given.
+	defaultDiskType = "pd-standard"
+)
+
+// Default sets the Kubernetes version of the cluster to the default of GKE, the disk size of the cluster, and the disk size and type of its node pools, if they are not set.
+func (g *gcpProvisioner) Default(cluster *types.Cluster, provider *types.Provider) {
+	if cluster.KubernetesVersion == "" {
+		cluster.KubernetesVersion = versions.Default
+	}
+	if cluster.DiskSizeGB == 0 {
+		cluster.DiskSizeGB = defaultDiskSizeGB
+	}
+	for i := range cluster.NodePools {
+		pool := &cluster.NodePools[i]
+		if pool.DiskSizeGB == 0 {
+			pool.DiskSizeGB = cluster.DiskSizeGB
+		}
+		if pool.DiskType == "" {
+			pool.DiskType = defaultDiskType
+		}
+	}
+}
+
 func (g *gcpProvisioner) validateInputs(cluster *types.Cluster, provider *types.Provider) error {
-	var errMessage string
-	if cluster.NodeCount < 1 {
-		errMessage += fmt.Sprintf(errs.CannotBeLess, "Cluster.NodeCount", 1)
+	var fieldErrs []types.FieldError
+	if len(cluster.NodePools) == 0 && cluster.NodeCount < 1 {
+		fieldErrs = append(fieldErrs, types.FieldError{Field: "Cluster.NodeCount", Reason: fmt.Sprintf(errs.CannotBeLess, 1), Value: cluster.NodeCount})
 	}
 	// Matches the regex for a GCP cluster name.
 	if match, _ := regexp.MatchString(`^(?:[a-z](?:[-a-z0-9]{0,37}[a-z0-9])?)$`, cluster.Name); !match {
-		errMessage += fmt.Sprintf(errs.Custom, "Cluster.Name must start with a lowercase letter followed by up to 39 lowercase letters, "+
-			"numbers, or hyphens, and cannot end with a hyphen")
+		fieldErrs = append(fieldErrs, types.FieldError{Field: "Cluster.Name", Reason: "must start with a lowercase letter followed by up to 39 lowercase letters, " +
+			"numbers, or hyphens, and cannot end with a hyphen", Value: cluster.Name})
 	}
 	if cluster.Location == "" {
-		errMessage += fmt.Sprintf(errs.CannotBeEmpty, "Cluster.Location")
+		fieldErrs = append(fieldErrs, types.FieldError{Field: "Cluster.Location", Reason: errs.CannotBeEmpty})
 	}
-	if cluster.MachineType == "" {
-		errMessage += fmt.Sprintf(errs.CannotBeEmpty, "Cluster.MachineType")
+	if len(cluster.NodePools) == 0 && cluster.MachineType == "" {
+		fieldErrs = append(fieldErrs, types.FieldError{Field: "Cluster.MachineType", Reason: errs.CannotBeEmpty})
 	}
 	if cluster.KubernetesVersion == "" {
-		errMessage += fmt.Sprintf(errs.CannotBeEmpty, "Cluster.KubernetesVersion")
+		fieldErrs = append(fieldErrs, types.FieldError{Field: "Cluster.KubernetesVersion", Reason: errs.CannotBeEmpty})
 	}
 	if cluster.DiskSizeGB < 0 {
-		errMessage += fmt.Sprintf(errs.CannotBeLess, "Cluster.DiskSizeGB", 0)
+		fieldErrs = append(fieldErrs, types.FieldError{Field: "Cluster.DiskSizeGB", Reason: fmt.Sprintf(errs.CannotBeLess, 0), Value: cluster.DiskSizeGB})
 	}
 
-	if provider.CredentialsFilePath == "" {
-		errMessage += fmt.Sprintf(errs.CannotBeEmpty, "Provider.CredentialsFilePath")
+	fieldErrs = append(fieldErrs, validateNodePools(cluster.NodePools)...)
+	fieldErrs = append(fieldErrs, types.ValidateAutoscaling(cluster)...)
+	fieldErrs = append(fieldErrs, validateNetworking(cluster.Networking)...)
+	// only the labels are checked, the annotations are ignored as GKE clusters have none
+	fieldErrs = append(fieldErrs, validateLabels(cluster.Labels)...)
+
+	fieldErrs = append(fieldErrs, validateProvider(provider)...)
+	fieldErrs = append(fieldErrs, schema.Validate(provider.CustomConfigurations)...)
+
+	if len(fieldErrs) > 0 {
+		return &types.ValidationError{Errors: fieldErrs}
 	}
-	if provider.ProjectName == "" {
-		errMessage += fmt.Sprintf(errs.CannotBeEmpty, "Provider.ProjectName")
+
+	return nil
+}
+
+func validateNetworking(networking *types.Networking) []types.FieldError {
+	if networking == nil {
+		return nil
+	}
+	fieldErrs := types.ValidateNetworking(networking)
+	if networking.AWS != nil {
+		fieldErrs = append(fieldErrs, types.FieldError{Field: "Cluster.Networking.AWS", Reason: "is only supported by Gardener"})
+	}
+	if networking.Azure != nil {
+		fieldErrs = append(fieldErrs, types.FieldError{Field: "Cluster.Networking.Azure", Reason: "is only supported by Gardener"})
+	}
+	return fieldErrs
+}
+
+// maxLabels is the number of labels a GKE cluster can have at most.
+const maxLabels = 64
+
+var (
+	// labelKeyPattern matches GCP label keys: up to 63 lowercase letters, digits, underscores and dashes, starting with a lowercase letter.
+	labelKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{0,62}$`)
+	// labelValuePattern matches GCP label values, which can also be empty or start with any of the allowed characters.
+	labelValuePattern = regexp.MustCompile(`^[a-z0-9_-]{0,63}$`)
+)
+
+func validateLabels(labels map[string]string) []types.FieldError {
+	var fieldErrs []types.FieldError
+	if len(labels) > maxLabels {
+		fieldErrs = append(fieldErrs, types.FieldError{Field: "Cluster.Labels", Reason: fmt.Sprintf("cannot have more than %d labels", maxLabels), Value: len(labels)})
 	}
 
-	if errMessage != "" {
-		return errors.New("input validation failed with the following information: " + errMessage)
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
 	}
+	sort.Strings(keys)
 
-	return nil
+	for _, k := range keys {
+		field := fmt.Sprintf("Cluster.Labels['%s']", k)
+		if !labelKeyPattern.MatchString(k) {
+			fieldErrs = append(fieldErrs, types.FieldError{Field: field, Reason: "key must start with a lowercase letter followed by up to 62 lowercase letters, " +
+				"numbers, underscores, or hyphens", Value: k})
+		}
+		if !labelValuePattern.MatchString(labels[k]) {
+			fieldErrs = append(fieldErrs, types.FieldError{Field: field, Reason: "value must consist of up to 63 lowercase letters, numbers, underscores, or hyphens", Value: labels[k]})
+		}
+	}
+	return fieldErrs
+}
+
+// nodePoolNamePattern matches the names GKE accepts for node pools.
+var nodePoolNamePattern = regexp.MustCompile(`^[a-z](?:[-a-z0-9]{0,38}[a-z0-9])?$`)
+
+func validateNodePools(pools []types.NodePool) []types.FieldError {
+	var fieldErrs []types.FieldError
+	names := map[string]bool{}
+	for i, pool := range pools {
+		path := field.NewPath("Cluster", "NodePools").Index(i)
+		if !nodePoolNamePattern.MatchString(pool.Name) {
+			fieldErrs = append(fieldErrs, types.FieldError{Field: path.Child("Name").String(), Reason: "must start with a lowercase letter followed by up to 39 lowercase letters, " +
+				"numbers, or hyphens, and cannot end with a hyphen", Value: pool.Name})
+		}
+		if names[pool.Name] {
+			fieldErrs = append(fieldErrs, types.FieldError{Field: path.Child("Name").String(), Reason: "must be unique", Value: pool.Name})
+		}
+		names[pool.Name] = true
+		if pool.MachineType == "" {
+			fieldErrs = append(fieldErrs, types.FieldError{Field: path.Child("MachineType").String(), Reason: errs.CannotBeEmpty})
+		}
+		if pool.NodeCount < 0 {
+			fieldErrs = append(fieldErrs, types.FieldError{Field: path.Child("NodeCount").String(), Reason: fmt.Sprintf(errs.CannotBeLess, 0), Value: pool.NodeCount})
+		}
+		if pool.DiskSizeGB < 0 {
+			fieldErrs = append(fieldErrs, types.FieldError{Field: path.Child("DiskSizeGB").String(), Reason: fmt.Sprintf(errs.CannotBeLess, 0), Value: pool.DiskSizeGB})
+		}
+		fieldErrs = append(fieldErrs, fieldErrors(metav1validation.ValidateLabels(pool.Labels, path.Child("Labels")))...)
+		// taints and zones of node pools are only available in the beta API of GKE
+		if len(pool.Taints) > 0 {
+			fieldErrs = append(fieldErrs, types.FieldError{Field: path.Child("Taints").String(), Reason: "are not supported by GCP"})
+		}
+		if len(pool.Zones) > 0 {
+			fieldErrs = append(fieldErrs, types.FieldError{Field: path.Child("Zones").String(), Reason: "are not supported by GCP"})
+		}
+	}
+	return fieldErrs
+}
+
+// fieldErrors converts the errors of the Kubernetes validation.
+func fieldErrors(errList field.ErrorList) []types.FieldError {
+	fieldErrs := make([]types.FieldError, 0, len(errList))
+	for _, err := range errList {
+		fieldErrs = append(fieldErrs, types.FieldError{Field: err.Field, Reason: err.Detail, Value: err.BadValue})
+	}
+	// the validation walks maps in random order
+	sort.SliceStable(fieldErrs, func(i, j int) bool {
+		return fmt.Sprint(fieldErrs[i].Value) < fmt.Sprint(fieldErrs[j].Value)
+	})
+	return fieldErrs
+}
+
+func validateProvider(provider *types.Provider) []types.FieldError {
+	var fieldErrs []types.FieldError
+	if provider.CredentialsFilePath == "" {
+		fieldErrs = append(fieldErrs, types.FieldError{Field: "Provider.CredentialsFilePath", Reason: errs.CannotBeEmpty})
+	}
+	if provider.ProjectName == "" {
+		fieldErrs = append(fieldErrs, types.FieldError{Field: "Provider.ProjectName", Reason: errs.CannotBeEmpty})
+	}
+	return fieldErrs
 }
 
 func (g *gcpProvisioner) loadConfigurations(cluster *types.Cluster, provider *types.Provider) map[string]interface{} {
@@ -175,12 +526,286 @@ func (g *gcpProvisioner) loadConfigurations(cluster *types.Cluster, provider *ty
 	config["location"] = cluster.Location
 	config["project"] = provider.ProjectName
 	config["credentials_file_path"] = provider.CredentialsFilePath
+	labels := map[string]interface{}{}
+	for k, v := range cluster.Labels {
+		labels[k] = v
+	}
+	config["labels"] = labels
+	config["node_pools"] = nodePools(cluster)
+	config["networking"] = cluster.Networking
 	for k, v := range provider.CustomConfigurations {
 		config[k] = v
 	}
 	return config
 }
 
+// nodePools returns the node pools of the cluster, with the autoscaling settings of the cluster applied to the pools without their own. As only node pools can be autoscaled, an autoscaled cluster without node pools gets a pool in place of the default pool of GKE.
+func nodePools(cluster *types.Cluster) []types.NodePool {
+	if len(cluster.NodePools) == 0 {
+		if cluster.Autoscaling == nil {
+			return nil
+		}
+		return []types.NodePool{{
+			Name:        defaultNodePool,
+			MachineType: cluster.MachineType,
+			NodeCount:   cluster.NodeCount,
+			Autoscaling: cluster.Autoscaling,
+		}}
+	}
+
+	pools := make([]types.NodePool, 0, len(cluster.NodePools))
+	for _, pool := range cluster.NodePools {
+		if pool.Autoscaling == nil {
+			pool.Autoscaling = cluster.Autoscaling
+		}
+		pools = append(pools, pool)
+	}
+	return pools
+}
+
+// clientOptions returns the options of the clients of the GCP APIs.
+func (g *gcpProvisioner) clientOptions(provider *types.Provider) []option.ClientOption {
+	if len(g.apiOptions) > 0 {
+		return g.apiOptions
+	}
+	return []option.ClientOption{option.WithCredentialsFile(provider.CredentialsFilePath)}
+}
+
+// containerService creates a client of the GCP container API.
+func (g *gcpProvisioner) containerService(ctx context.Context, provider *types.Provider) (*container.Service, error) {
+	containerService, err := container.NewService(ctx, g.clientOptions(provider)...)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create GCP client")
+	}
+	return containerService, nil
+}
+
+// kubernetesVersions returns the versions GKE offers for new clusters in the location. As the nodes run the version of the control plane, only the versions offered for both are supported.
+func (g *gcpProvisioner) kubernetesVersions(ctx context.Context, location string, provider *types.Provider) (*versions.Supported, error) {
+	containerService, err := g.containerService(ctx, provider)
+	if err != nil {
+		return nil, err
+	}
+	config, err := containerService.Projects.Locations.GetServerConfig(fmt.Sprintf("projects/%s/locations/%s", provider.ProjectName, location)).Context(ctx).Do()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to get the kubernetes versions of gcp")
+	}
+
+	nodeVersions := map[string]bool{}
+	for _, v := range config.ValidNodeVersions {
+		nodeVersions[v] = true
+	}
+	supported := &versions.Supported{Default: config.DefaultClusterVersion}
+	for _, v := range config.ValidMasterVersions {
+		if nodeVersions[v] {
+			supported.Versions = append(supported.Versions, v)
+		}
+	}
+	return supported, nil
+}
+
+// resolveKubernetesVersion replaces the Kubernetes version of the cluster, which can be an alias such as `1.15` or `latest`, with a version GKE offers in the location of the cluster. An unsupported version is reported with a ValidationError.
+// The version stored for an existing cluster is kept as long as the specification matches it, see versions.Stored.
+func (g *gcpProvisioner) resolveKubernetesVersion(ctx context.Context, cluster *types.Cluster, provider *types.Provider) error {
+	if version, ok := versions.Stored(cluster); ok {
+		cluster.KubernetesVersion = version
+		return nil
+	}
+	supported, err := g.kubernetesVersions(ctx, cluster.Location, provider)
+	if err != nil {
+		return err
+	}
+	version, ok := supported.Resolve(cluster.KubernetesVersion)
+	if !ok {
+		return &types.ValidationError{Errors: []types.FieldError{supported.FieldError(cluster.KubernetesVersion)}}
+	}
+	cluster.KubernetesVersion = version
+	return nil
+}
+
+// machineTypeCache keeps the machine types listed with the compute API for all provisioners.
+var machineTypeCache = catalog.NewCache(time.Hour)
+
+// MachineTypes returns the machine types offered in the location, which is a zone or a region. For a region, the machine types offered in any of its zones are returned.
+// Unless the provisioner has its own catalog, the machine types are listed with the compute API, and cached for an hour.
+func (g *gcpProvisioner) MachineTypes(ctx context.Context, provider *types.Provider, location string) ([]types.MachineType, error) {
+	if fieldErrs := validateProvider(provider); len(fieldErrs) > 0 {
+		return nil, &types.ValidationError{Errors: fieldErrs}
+	}
+	if g.catalog != nil {
+		return g.catalog.MachineTypes(ctx, provider, location)
+	}
+
+	key := fmt.Sprintf("%s/%s/%s", provider.CredentialsFilePath, provider.ProjectName, location)
+	return machineTypeCache.Get(ctx, key, func(ctx context.Context) ([]types.MachineType, error) {
+		return g.listMachineTypes(ctx, provider, location)
+	})
+}
+
+// listMachineTypes lists the machine types offered in the zones of the location with the compute API.
+func (g *gcpProvisioner) listMachineTypes(ctx context.Context, provider *types.Provider, location string) ([]types.MachineType, error) {
+	computeService, err := compute.NewService(ctx, g.clientOptions(provider)...)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create GCP client")
+	}
+
+	offered := map[string]types.MachineType{}
+	err = computeService.MachineTypes.AggregatedList(provider.ProjectName).Pages(ctx, func(page *compute.MachineTypeAggregatedList) error {
+		for scope, list := range page.Items {
+			// the scopes are the zones, for example `zones/europe-west3-a` for the region `europe-west3`
+			zone := strings.TrimPrefix(scope, "zones/")
+			if zone != location && !strings.HasPrefix(zone, location+"-") {
+				continue
+			}
+			for _, machineType := range list.MachineTypes {
+				offered[machineType.Name] = types.MachineType{Name: machineType.Name, CPUs: int(machineType.GuestCpus), MemoryMB: int(machineType.MemoryMb)}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list the machine types of gcp")
+	}
+
+	machineTypes := make([]types.MachineType, 0, len(offered))
+	for _, machineType := range offered {
+		machineTypes = append(machineTypes, machineType)
+	}
+	sort.Slice(machineTypes, func(i, j int) bool { return machineTypes[i].Name < machineTypes[j].Name })
+	return machineTypes, nil
+}
+
+// validateMachineTypes checks that the machine types of the cluster are offered in its location. Unknown machine types are reported with a ValidationError.
+func (g *gcpProvisioner) validateMachineTypes(ctx context.Context, cluster *types.Cluster, provider *types.Provider) error {
+	machineTypes, err := g.MachineTypes(ctx, provider, cluster.Location)
+	if err != nil {
+		return err
+	}
+	if fieldErrs := catalog.Validate(cluster, machineTypes); len(fieldErrs) > 0 {
+		return &types.ValidationError{Errors: fieldErrs}
+	}
+	return nil
+}
+
+// getCluster fetches the cluster from the GCP container API.
+func (g *gcpProvisioner) getCluster(ctx context.Context, cluster *types.Cluster, provider *types.Provider) (*container.Cluster, error) {
+	containerService, err := g.containerService(ctx, provider)
+	if err != nil {
+		return nil, err
+	}
+	cl, err := containerService.Projects.Locations.Clusters.Get(clusterPath(provider.ProjectName, cluster.Location, cluster.Name)).Context(ctx).Do()
+	if apiErr, ok := err.(*googleapi.Error); ok && apiErr.Code == http.StatusNotFound {
+		return nil, &types.NotFoundError{Name: cluster.Name}
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to get cluster info")
+	}
+	return cl, nil
+}
+
+// readLiveOutputs adds the outputs of the live cluster the Terraform provider does not expose to the cluster info.
+func (g *gcpProvisioner) readLiveOutputs(ctx context.Context, cluster *types.Cluster, provider *types.Provider) error {
+	cl, err := g.getCluster(ctx, cluster, provider)
+	if err != nil {
+		return err
+	}
+	setLiveOutputs(cluster.ClusterInfo, cl)
+	return nil
+}
+
+// setLiveOutputs adds the self link of the live cluster, which the Terraform provider does not expose, to the outputs of the cluster info.
+func setLiveOutputs(info *types.ClusterInfo, cl *container.Cluster) {
+	if info.Outputs == nil {
+		info.Outputs = map[string]interface{}{}
+	}
+	info.Outputs["self_link"] = cl.SelfLink
+}
+
+// defaultNodePool is the name GKE gives the node pool it creates with the cluster.
+const defaultNodePool = "default-pool"
+
+// convertCluster maps a GKE cluster to a Hydroform cluster. The machine type and disk size are those of the first node pool. The node pools are only listed if the cluster has other pools than the default one, which the other fields describe.
+func (g *gcpProvisioner) convertCluster(cl *container.Cluster) *types.Cluster {
+	cluster := &types.Cluster{
+		Name:              cl.Name,
+		Location:          cl.Location,
+		KubernetesVersion: cl.CurrentMasterVersion,
+		NodeCount:         int(cl.CurrentNodeCount),
+		Labels:            cl.ResourceLabels,
+		ClusterInfo: &types.ClusterInfo{
+			Endpoint: cl.Endpoint,
+			Status: &types.ClusterStatus{
+				Phase:  g.convertGCPStatus(cl.Status),
+				Labels: cl.ResourceLabels,
+			},
+		},
+	}
+	if len(cl.NodePools) > 0 && cl.NodePools[0].Config != nil {
+		cluster.MachineType = cl.NodePools[0].Config.MachineType
+		cluster.DiskSizeGB = int(cl.NodePools[0].Config.DiskSizeGb)
+	}
+	if len(cl.NodePools) > 1 || (len(cl.NodePools) == 1 && cl.NodePools[0].Name != defaultNodePool) {
+		for _, pool := range cl.NodePools {
+			cluster.NodePools = append(cluster.NodePools, convertNodePool(pool))
+		}
+	} else if len(cl.NodePools) == 1 {
+		cluster.Autoscaling = convertNodePool(cl.NodePools[0]).Autoscaling
+	}
+	if cl.MasterAuth != nil {
+		if certificateData, err := base64.StdEncoding.DecodeString(cl.MasterAuth.ClusterCaCertificate); err == nil {
+			cluster.ClusterInfo.CertificateAuthorityData = certificateData
+		}
+	}
+	return cluster
+}
+
+// taintEffects maps the taint effects of the GKE API to the Kubernetes ones.
+var taintEffects = map[string]types.TaintEffect{
+	"NO_SCHEDULE":        types.NoSchedule,
+	"PREFER_NO_SCHEDULE": types.PreferNoSchedule,
+	"NO_EXECUTE":         types.NoExecute,
+}
+
+// convertNodePool maps a GKE node pool to a Hydroform one. GKE only reports the initial size of the pools, which autoscaling changes, so the NodeCount of autoscaled pools is left unset.
+func convertNodePool(pool *container.NodePool) types.NodePool {
+	converted := types.NodePool{
+		Name: pool.Name,
+	}
+	if pool.Autoscaling == nil || !pool.Autoscaling.Enabled {
+		converted.NodeCount = int(pool.InitialNodeCount)
+	} else {
+		converted.Autoscaling = &types.Autoscaling{
+			Min: int(pool.Autoscaling.MinNodeCount),
+			Max: int(pool.Autoscaling.MaxNodeCount),
+		}
+	}
+	if pool.Config != nil {
+		converted.MachineType = pool.Config.MachineType
+		converted.DiskSizeGB = int(pool.Config.DiskSizeGb)
+		converted.DiskType = pool.Config.DiskType
+		converted.Labels = pool.Config.Labels
+		for _, taint := range pool.Config.Taints {
+			converted.Taints = append(converted.Taints, types.Taint{Key: taint.Key, Value: taint.Value, Effect: taintEffects[taint.Effect]})
+		}
+	}
+	return converted
+}
+
+// matchLabels reports whether the resource labels contain all of the wanted labels.
+func matchLabels(resourceLabels, labels map[string]string) bool {
+	for k, v := range labels {
+		if value, ok := resourceLabels[k]; !ok || value != v {
+			return false
+		}
+	}
+	return true
+}
+
+// clusterPath returns the fully qualified name of a GKE cluster as expected by the container API.
+func clusterPath(project, location, name string) string {
+	return fmt.Sprintf("projects/%s/locations/%s/clusters/%s", project, location, name)
+}
+
 // Possible values for the GCP Cluster Status:
 //   "STATUS_UNSPECIFIED" - not set.
 //   "PROVISIONING" - indicates the cluster is being created.
@@ -191,7 +816,7 @@ func (g *gcpProvisioner) loadConfigurations(cluster *types.Cluster, provider *ty
 //   "ERROR" - indicates the cluster may be unusable.
 //   "DEGRADED" - indicates the cluster requires user action to restore full functionality.
 // More details can be found in the `statusMessage` field.
-func (g *gcpProvisioner) convertGCPStatus(status container.Status) types.Phase {
+func (g *gcpProvisioner) convertGCPStatus(status string) types.Phase {
 	switch status {
 	default:
 		return types.Unknown
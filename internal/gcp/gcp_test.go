@@ -1,20 +1,76 @@
 package gcp
 
 import (
+	"context"
+	stderrors "errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/kyma-incubator/hydroform/internal/terraform"
 
+	"github.com/kyma-incubator/hydroform/catalog"
+	"github.com/kyma-incubator/hydroform/internal/errs"
 	"github.com/kyma-incubator/hydroform/internal/operator/mocks"
+	"github.com/kyma-incubator/hydroform/internal/versions"
 	"github.com/pkg/errors"
 
 	"github.com/kyma-incubator/hydroform/types"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/api/option"
 )
 
 const convertError = "Status [%s] should be converted to [%s]"
 
+// serverConfig is the answer of the fake container API to requests for the Kubernetes versions GKE offers.
+const serverConfig = `{
+	"defaultClusterVersion": "1.13.11-gke.14",
+	"validMasterVersions": ["1.14.10-gke.17", "1.14.8-gke.12", "1.13.11-gke.14"],
+	"validNodeVersions": ["1.14.10-gke.17", "1.14.8-gke.12", "1.13.11-gke.14", "1.12.10-gke.17"]
+}`
+
+// fakeGCPAPI starts a fake of the GCP APIs, which returns the serverConfig for every location, and passes the other requests to handler. Without a handler, the other requests are not found.
+func fakeGCPAPI(handler http.HandlerFunc) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/serverConfig") {
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, serverConfig)
+			return
+		}
+		if handler == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		handler(w, r)
+	}))
+}
+
+// liveCluster answers requests of the fake container API for the hydro-cluster in europe-west3 of my-project, and reports other clusters as not found.
+func liveCluster(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/v1/projects/my-project/locations/europe-west3/clusters/hydro-cluster" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprint(w, `{"name": "hydro-cluster", "endpoint": "35.1.2.3", "status": "RUNNING", "currentMasterVersion": "1.14.8-gke.12", "masterAuth": {"clusterCaCertificate": "TXkgY2VydA=="},
+		"selfLink": "https://container.googleapis.com/v1/projects/my-project/locations/europe-west3/clusters/hydro-cluster"}`)
+}
+
+// fakeAPIOptions make the clients of the provisioner call the fake API.
+func fakeAPIOptions(server *httptest.Server) []option.ClientOption {
+	return []option.ClientOption{option.WithEndpoint(server.URL), option.WithoutAuthentication()}
+}
+
+// offlineCatalog returns the machine types of the static catalog file, so that the tests do not need the compute API.
+func offlineCatalog(t *testing.T) catalog.Static {
+	static, err := catalog.LoadFile("testdata/machine_types.json")
+	require.NoError(t, err)
+	return static
+}
+
 func TestConvertgcpState(t *testing.T) {
 	g := &gcpProvisioner{}
 
@@ -43,12 +99,6 @@ func TestValidateInputs(t *testing.T) {
 		Type:                types.GCP,
 		ProjectName:         "my-project",
 		CredentialsFilePath: "/path/to/credentials",
-		CustomConfigurations: map[string]interface{}{
-			"target_provider": "gcp",
-			"target_secret":   "secret-name",
-			"disk_type":       "pd-standard",
-			"zone":            "europe-west3-b",
-		},
 	}
 
 	require.NoError(t, g.validateInputs(cluster, provider), "Validation should pass")
@@ -91,18 +141,272 @@ func TestValidateInputs(t *testing.T) {
 	require.Error(t, g.validateInputs(cluster, provider), "Validation should fail when project name is empty")
 	provider.CredentialsFilePath = "/my-project"
 
-	delete(provider.CustomConfigurations, "target_provider")
-	require.Error(t, g.validateInputs(cluster, provider), "Validation should fail when target provider is empty")
-	provider.CustomConfigurations["target_provider"] = "nimbus"
-	require.Error(t, g.validateInputs(cluster, provider), "Validation should fail when target provider is not supported")
-	provider.CustomConfigurations["target_provider"] = "gcp"
+	provider.ProjectName = "my-project"
+	cluster.MachineType = "type1"
+
+	provider.CustomConfigurations = map[string]interface{}{"zone": "europe-west3-b"}
+	err := g.validateInputs(cluster, provider)
+	var validationErr *types.ValidationError
+	require.True(t, stderrors.As(err, &validationErr), "Validation should fail for unknown custom configurations")
+	require.Equal(t, []types.FieldError{
+		{Field: "Provider.CustomConfigurations['zone']", Reason: "is not a custom configuration of gcp", Value: "europe-west3-b"},
+	}, validationErr.Errors)
+}
 
-	delete(provider.CustomConfigurations, "target_secret")
-	require.Error(t, g.validateInputs(cluster, provider), "Validation should fail when target secret is empty")
-	provider.CustomConfigurations["target_secret"] = "secret_name"
+func TestValidateLabels(t *testing.T) {
+	g := &gcpProvisioner{}
+
+	cluster := &types.Cluster{
+		KubernetesVersion: "1.12",
+		Name:              "hydro-cluster",
+		DiskSizeGB:        30,
+		NodeCount:         2,
+		Location:          "europe-west3",
+		MachineType:       "type1",
+		Labels:            map[string]string{"team": "hydro", "cost-center": "", "env_1": "ci-2"},
+	}
+	provider := &types.Provider{
+		Type:                types.GCP,
+		ProjectName:         "my-project",
+		CredentialsFilePath: "/path/to/credentials",
+	}
+	require.NoError(t, g.validateInputs(cluster, provider), "Validation should pass")
 
-	delete(provider.CustomConfigurations, "disk_type")
-	require.Error(t, g.validateInputs(cluster, provider), "Validation should fail when disk type is empty")
+	cluster.Labels = map[string]string{"Team": "hydro", "owner": "Hydro Team", "1st": "a"}
+	err := g.validateInputs(cluster, provider)
+	var validationErr *types.ValidationError
+	require.True(t, stderrors.As(err, &validationErr), "Validation should fail for invalid labels")
+	require.Equal(t, []string{"Cluster.Labels['1st']", "Cluster.Labels['Team']", "Cluster.Labels['owner']"}, validationErr.Fields())
+
+	cluster.Labels = map[string]string{}
+	for i := 0; i <= maxLabels; i++ {
+		cluster.Labels[fmt.Sprintf("label-%d", i)] = "value"
+	}
+	require.Error(t, g.validateInputs(cluster, provider), "Validation should fail with too many labels")
+
+	cluster.Labels = nil
+	cluster.Annotations = map[string]string{"owner": "hydro"}
+	require.NoError(t, g.validateInputs(cluster, provider), "Annotations should be ignored")
+}
+
+func TestValidateNodePools(t *testing.T) {
+	g := &gcpProvisioner{}
+
+	cluster := &types.Cluster{
+		KubernetesVersion: "1.12",
+		Name:              "hydro-cluster",
+		DiskSizeGB:        30,
+		Location:          "europe-west3",
+		NodePools: []types.NodePool{
+			{Name: "cpu", MachineType: "n1-standard-4", NodeCount: 2},
+			{Name: "gpu", MachineType: "n1-highmem-8", NodeCount: 1, Autoscaling: &types.Autoscaling{Min: 1, Max: 4}, Labels: map[string]string{"hydroform.kyma-project.io/pool": "gpu"}},
+		},
+	}
+	provider := &types.Provider{
+		Type:                types.GCP,
+		ProjectName:         "my-project",
+		CredentialsFilePath: "/path/to/credentials",
+	}
+	require.NoError(t, g.validateInputs(cluster, provider), "Validation should pass without the node count and machine type of the cluster")
+
+	cluster.NodePools = []types.NodePool{
+		{Name: "cpu", MachineType: "n1-standard-4", NodeCount: 2, Taints: []types.Taint{{Key: "gpu", Effect: types.NoSchedule}}},
+		{Name: "cpu", NodeCount: -1, Autoscaling: &types.Autoscaling{Min: 3, Max: 2}, Labels: map[string]string{"-pool": "gpu"}, Zones: []string{"europe-west3-a"}},
+		{Name: "Pool_1", MachineType: "n1-standard-4"},
+	}
+	err := g.validateInputs(cluster, provider)
+	var validationErr *types.ValidationError
+	require.True(t, stderrors.As(err, &validationErr), "Validation should fail for invalid node pools")
+	require.Equal(t, []string{
+		"Cluster.NodePools[0].Taints",
+		"Cluster.NodePools[1].Name",
+		"Cluster.NodePools[1].MachineType",
+		"Cluster.NodePools[1].NodeCount",
+		"Cluster.NodePools[1].Labels",
+		"Cluster.NodePools[1].Zones",
+		"Cluster.NodePools[2].Name",
+		"Cluster.NodePools[1].Autoscaling.Max",
+		"Cluster.NodePools[1].NodeCount",
+	}, validationErr.Fields())
+}
+
+func TestValidateNetworking(t *testing.T) {
+	g := &gcpProvisioner{}
+
+	cluster := &types.Cluster{
+		KubernetesVersion: "1.12",
+		Name:              "hydro-cluster",
+		DiskSizeGB:        30,
+		NodeCount:         2,
+		Location:          "europe-west3",
+		MachineType:       "n1-standard-4",
+		Networking: &types.Networking{
+			Network:     "hydro-network",
+			Subnetwork:  "hydro-nodes",
+			NodeCIDR:    "10.250.0.0/19",
+			PodCIDR:     "100.96.0.0/11",
+			ServiceCIDR: "100.64.0.0/13",
+		},
+	}
+	provider := &types.Provider{
+		Type:                types.GCP,
+		ProjectName:         "my-project",
+		CredentialsFilePath: "/path/to/credentials",
+	}
+	require.NoError(t, g.validateInputs(cluster, provider))
+
+	cluster.Networking.PodCIDR = "10.250.0.0/16"
+	cluster.Networking.AWS = &types.AWSSubnets{VPCCIDR: "10.250.0.0/16"}
+	err := g.validateInputs(cluster, provider)
+	var validationErr *types.ValidationError
+	require.True(t, stderrors.As(err, &validationErr), "Validation should fail for overlapping ranges and Gardener subnets")
+	require.Equal(t, []string{
+		"Cluster.Networking.PodCIDR",
+		"Cluster.Networking.PodCIDR",
+		"Cluster.Networking.AWS",
+	}, validationErr.Fields())
+}
+
+func TestDefault(t *testing.T) {
+	g := &gcpProvisioner{}
+
+	cluster := &types.Cluster{
+		Name:        "hydro-cluster",
+		DiskSizeGB:  30,
+		MachineType: "n1-standard-4",
+		NodePools: []types.NodePool{
+			{Name: "cpu", MachineType: "n1-standard-4", NodeCount: 2},
+			{Name: "ssd", MachineType: "n1-standard-4", NodeCount: 2, DiskSizeGB: 200, DiskType: "pd-ssd"},
+		},
+	}
+	g.Default(cluster, &types.Provider{Type: types.GCP})
+	require.Equal(t, versions.Default, cluster.KubernetesVersion)
+	require.Equal(t, 30, cluster.DiskSizeGB, "The disk size should be kept if it is set")
+	require.Equal(t, []types.NodePool{
+		{Name: "cpu", MachineType: "n1-standard-4", NodeCount: 2, DiskSizeGB: 30, DiskType: "pd-standard"},
+		{Name: "ssd", MachineType: "n1-standard-4", NodeCount: 2, DiskSizeGB: 200, DiskType: "pd-ssd"},
+	}, cluster.NodePools, "Node pools should get the disk size of the cluster and the default disk type")
+
+	cluster = &types.Cluster{Name: "hydro-cluster", KubernetesVersion: "1.13"}
+	g.Default(cluster, &types.Provider{Type: types.GCP})
+	require.Equal(t, "1.13", cluster.KubernetesVersion, "The Kubernetes version should be kept if it is set")
+	require.Equal(t, defaultDiskSizeGB, cluster.DiskSizeGB)
+}
+
+func TestResolveKubernetesVersion(t *testing.T) {
+	server := fakeGCPAPI(nil)
+	defer server.Close()
+	g := &gcpProvisioner{apiOptions: fakeAPIOptions(server)}
+	provider := &types.Provider{
+		Type:                types.GCP,
+		ProjectName:         "my-project",
+		CredentialsFilePath: "/path/to/credentials",
+	}
+
+	for version, expected := range map[string]string{
+		"1.14":           "1.14.10-gke.17",
+		"1.14.8":         "1.14.8-gke.12",
+		"latest":         "1.14.10-gke.17",
+		"default":        "1.13.11-gke.14",
+		"1.13.11-gke.14": "1.13.11-gke.14",
+	} {
+		cluster := &types.Cluster{KubernetesVersion: version, Location: "europe-west3"}
+		require.NoError(t, g.resolveKubernetesVersion(context.Background(), cluster, provider))
+		require.Equal(t, expected, cluster.KubernetesVersion, "Version %s should be resolved", version)
+	}
+
+	cluster := &types.Cluster{KubernetesVersion: "1.12", Location: "europe-west3"}
+	err := g.resolveKubernetesVersion(context.Background(), cluster, provider)
+	var validationErr *types.ValidationError
+	require.True(t, stderrors.As(err, &validationErr), "Versions only offered for nodes should not be supported")
+	require.Equal(t, []string{"Cluster.KubernetesVersion"}, validationErr.Fields())
+	require.Equal(t, "1.12", cluster.KubernetesVersion, "An unsupported version should be kept")
+
+	cluster = &types.Cluster{
+		KubernetesVersion: "latest",
+		Location:          "europe-west3",
+		ClusterInfo:       &types.ClusterInfo{InternalState: &types.InternalState{KubernetesVersion: "1.14.8-gke.12"}},
+	}
+	require.NoError(t, g.resolveKubernetesVersion(context.Background(), cluster, provider))
+	require.Equal(t, "1.14.8-gke.12", cluster.KubernetesVersion, "The stored version should be kept while the specification matches it")
+
+	cluster.KubernetesVersion = "1.13"
+	require.NoError(t, g.resolveKubernetesVersion(context.Background(), cluster, provider))
+	require.Equal(t, "1.13.11-gke.14", cluster.KubernetesVersion, "A version which does not match the stored one should be resolved")
+}
+
+func TestMachineTypes(t *testing.T) {
+	calls := 0
+	server := fakeGCPAPI(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/machine-project/aggregated/machineTypes" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"items": {
+			"zones/europe-west3-a": {"machineTypes": [{"name": "n1-standard-4", "guestCpus": 4, "memoryMb": 15360}]},
+			"zones/europe-west3-b": {"machineTypes": [{"name": "n1-standard-4", "guestCpus": 4, "memoryMb": 15360}, {"name": "n1-highmem-8", "guestCpus": 8, "memoryMb": 53248}]},
+			"zones/europe-west4-a": {"machineTypes": [{"name": "n1-ultramem-40", "guestCpus": 40, "memoryMb": 981504}]}
+		}}`)
+	})
+	defer server.Close()
+
+	g := &gcpProvisioner{apiOptions: fakeAPIOptions(server)}
+	provider := &types.Provider{
+		Type:                types.GCP,
+		ProjectName:         "machine-project",
+		CredentialsFilePath: "/path/to/credentials",
+	}
+
+	machineTypes, err := g.MachineTypes(context.Background(), provider, "europe-west3")
+	require.NoError(t, err)
+	require.Equal(t, []types.MachineType{
+		{Name: "n1-highmem-8", CPUs: 8, MemoryMB: 53248},
+		{Name: "n1-standard-4", CPUs: 4, MemoryMB: 15360},
+	}, machineTypes, "The machine types of all zones of the region should be listed")
+
+	machineTypes, err = g.MachineTypes(context.Background(), provider, "europe-west3-a")
+	require.NoError(t, err)
+	require.Equal(t, []types.MachineType{{Name: "n1-standard-4", CPUs: 4, MemoryMB: 15360}}, machineTypes)
+	require.Equal(t, 2, calls)
+
+	_, err = g.MachineTypes(context.Background(), provider, "europe-west3")
+	require.NoError(t, err)
+	require.Equal(t, 2, calls, "The machine types should be cached")
+
+	cluster := &types.Cluster{
+		Location:  "europe-west3",
+		NodePools: []types.NodePool{{Name: "cpu", MachineType: "n1-standard-4"}, {Name: "memory", MachineType: "n1-ultramem-40"}},
+	}
+	err = g.validateMachineTypes(context.Background(), cluster, provider)
+	var validationErr *types.ValidationError
+	require.True(t, stderrors.As(err, &validationErr), "Machine types of other regions should be rejected")
+	require.Equal(t, []string{"Cluster.NodePools[1].MachineType"}, validationErr.Fields())
+
+	g.catalog = offlineCatalog(t)
+	cluster.NodePools[1].MachineType = "n1-highmem-8"
+	require.NoError(t, g.validateMachineTypes(context.Background(), cluster, provider), "The machine types of the catalog of the provisioner should be used")
+}
+
+func TestNodePools(t *testing.T) {
+	cluster := &types.Cluster{NodeCount: 2, MachineType: "n1-standard-4"}
+	require.Empty(t, nodePools(cluster), "Clusters without node pools and autoscaling should keep the default pool of GKE")
+
+	cluster.Autoscaling = &types.Autoscaling{Min: 1, Max: 3}
+	require.Equal(t, []types.NodePool{
+		{Name: "default-pool", MachineType: "n1-standard-4", NodeCount: 2, Autoscaling: cluster.Autoscaling},
+	}, nodePools(cluster), "Autoscaled clusters should get a node pool in place of the default one")
+
+	own := &types.Autoscaling{Min: 2, Max: 5}
+	cluster.NodePools = []types.NodePool{
+		{Name: "cpu", MachineType: "n1-standard-4", NodeCount: 2},
+		{Name: "gpu", MachineType: "n1-highmem-8", NodeCount: 2, Autoscaling: own},
+	}
+	pools := nodePools(cluster)
+	require.Equal(t, cluster.Autoscaling, pools[0].Autoscaling, "Node pools should inherit the autoscaling of the cluster")
+	require.Equal(t, own, pools[1].Autoscaling, "Node pools should keep their own autoscaling")
+	require.Nil(t, cluster.NodePools[0].Autoscaling, "The cluster should not be changed")
 }
 
 func TestLoadConfigurations(t *testing.T) {
@@ -116,6 +420,7 @@ func TestLoadConfigurations(t *testing.T) {
 		NodeCount:         2,
 		Location:          "europe-west3",
 		MachineType:       "type1",
+		Labels:            map[string]string{"team": "hydro"},
 	}
 	provider := &types.Provider{
 		Type:                types.GCP,
@@ -139,6 +444,9 @@ func TestLoadConfigurations(t *testing.T) {
 	require.Equal(t, cluster.KubernetesVersion, config["kubernetes_version"])
 	require.Equal(t, cluster.Location, config["location"])
 	require.Equal(t, provider.ProjectName, config["project"])
+	require.Equal(t, map[string]interface{}{"team": "hydro"}, config["labels"])
+	require.Empty(t, config["node_pools"])
+	require.Empty(t, config["networking"])
 
 	for k, v := range provider.CustomConfigurations {
 		require.Equal(t, v, config[k], fmt.Sprintf("Custom config %s is incorrect", k))
@@ -146,30 +454,29 @@ func TestLoadConfigurations(t *testing.T) {
 }
 
 func TestProvision(t *testing.T) {
+	server := fakeGCPAPI(liveCluster)
+	defer server.Close()
+
 	mockOp := &mocks.Operator{}
 	g := gcpProvisioner{
 		provisionOperator: mockOp,
+		apiOptions:        fakeAPIOptions(server),
+		catalog:           offlineCatalog(t),
 	}
 
 	cluster := &types.Cluster{
 		CPU:               1,
-		KubernetesVersion: "1.12",
+		KubernetesVersion: "1.14.8-gke.12",
 		Name:              "hydro-cluster",
 		DiskSizeGB:        30,
 		NodeCount:         2,
 		Location:          "europe-west3",
-		MachineType:       "type1",
+		MachineType:       "n1-standard-4",
 	}
 	provider := &types.Provider{
 		Type:                types.GCP,
 		ProjectName:         "my-project",
 		CredentialsFilePath: "/path/to/credentials",
-		CustomConfigurations: map[string]interface{}{
-			"target_provider": "gcp",
-			"target_secret":   "secret-name",
-			"disk_type":       "pd-standard",
-			"zone":            "europe-west3-b",
-		},
 	}
 
 	result := &types.ClusterInfo{
@@ -182,18 +489,20 @@ func TestProvision(t *testing.T) {
 			TerraformState: nil,
 		},
 	}
-	mockOp.On("Create", types.GCP, g.loadConfigurations(cluster, provider)).Return(result, nil)
+	mockOp.On("Create", mock.Anything, types.GCP, g.loadConfigurations(cluster, provider)).Return(result, nil)
 
-	cluster, err := g.Provision(cluster, provider)
+	cluster, err := g.Provision(context.Background(), cluster, provider)
 	require.NoError(t, err, "Provision should succeed")
 	require.Equal(t, result, cluster.ClusterInfo, "The cluster info returned from the operator should be in the cluster returned by Provision")
+	require.Equal(t, "https://container.googleapis.com/v1/projects/my-project/locations/europe-west3/clusters/hydro-cluster", cluster.ClusterInfo.Outputs["self_link"],
+		"The self link should be read from the live cluster")
 
 	badCluster := &types.Cluster{
 		CPU: 1,
 	}
-	mockOp.On("Create", types.GCP, g.loadConfigurations(badCluster, provider)).Return(badCluster, errors.New("Unable to provision cluster"))
+	mockOp.On("Create", mock.Anything, types.GCP, g.loadConfigurations(badCluster, provider)).Return(badCluster, errors.New("Unable to provision cluster"))
 
-	_, err = g.Provision(badCluster, provider)
+	_, err = g.Provision(context.Background(), badCluster, provider)
 	require.Error(t, err, "Provision should fail")
 }
 
@@ -217,28 +526,338 @@ func TestDeprovision(t *testing.T) {
 		Type:                types.GCP,
 		ProjectName:         "my-project",
 		CredentialsFilePath: "/path/to/credentials",
-		CustomConfigurations: map[string]interface{}{
-			"target_provider": "gcp",
-			"target_secret":   "secret-name",
-			"disk_type":       "pd-standard",
-			"zone":            "europe-west3-b",
-		},
 	}
 	goodState := &types.InternalState{
 		TerraformState: terraform.NewState(),
 	}
 	cluster.ClusterInfo.InternalState = goodState
-	mockOp.On("Delete", goodState, types.GCP, g.loadConfigurations(cluster, provider)).Return(nil)
+	mockOp.On("Delete", mock.Anything, goodState, types.GCP, g.loadConfigurations(cluster, provider)).Return(nil)
 
-	err := g.Deprovision(cluster, provider)
+	err := g.Deprovision(context.Background(), cluster, provider)
 	require.NoError(t, err, "Deprovision should succeed")
 
 	badState := &types.InternalState{
 		TerraformState: nil,
 	}
 	cluster.ClusterInfo.InternalState = badState
-	mockOp.On("Delete", badState, types.GCP, g.loadConfigurations(cluster, provider)).Return(errors.New("Unable to deprovision cluster"))
+	mockOp.On("Delete", mock.Anything, badState, types.GCP, g.loadConfigurations(cluster, provider)).Return(errors.New("Unable to deprovision cluster"))
 
-	err = g.Deprovision(cluster, provider)
+	err = g.Deprovision(context.Background(), cluster, provider)
 	require.Error(t, err, "Deprovision should fail")
 }
+
+func TestPlan(t *testing.T) {
+	server := fakeGCPAPI(nil)
+	defer server.Close()
+
+	mockOp := &mocks.Operator{}
+	g := gcpProvisioner{
+		provisionOperator: mockOp,
+		apiOptions:        fakeAPIOptions(server),
+		catalog:           offlineCatalog(t),
+	}
+
+	cluster := &types.Cluster{
+		CPU:               1,
+		KubernetesVersion: "1.14.8-gke.12",
+		Name:              "hydro-cluster",
+		DiskSizeGB:        30,
+		NodeCount:         2,
+		Location:          "europe-west3",
+		MachineType:       "n1-standard-4",
+	}
+	provider := &types.Provider{
+		Type:                types.GCP,
+		ProjectName:         "my-project",
+		CredentialsFilePath: "/path/to/credentials",
+	}
+
+	createPlan := &types.Plan{Resources: []types.ResourceChange{{Address: "google_container_cluster.gke_cluster", Change: types.ChangeCreate}}}
+	mockOp.On("Plan", mock.Anything, (*types.InternalState)(nil), types.GCP, g.loadConfigurations(cluster, provider), false).Return(createPlan, nil)
+
+	plan, err := g.Plan(context.Background(), cluster, provider)
+	require.NoError(t, err, "Plan should succeed")
+	require.Equal(t, createPlan, plan)
+
+	_, err = g.PlanDeprovision(context.Background(), cluster, provider)
+	require.Error(t, err, "PlanDeprovision should fail without the cluster state")
+
+	state := &types.InternalState{
+		TerraformState: terraform.NewState(),
+	}
+	cluster.ClusterInfo = &types.ClusterInfo{InternalState: state}
+	destroyPlan := &types.Plan{Resources: []types.ResourceChange{{Address: "google_container_cluster.gke_cluster", Change: types.ChangeDestroy}}}
+	mockOp.On("Plan", mock.Anything, state, types.GCP, g.loadConfigurations(cluster, provider), true).Return(destroyPlan, nil)
+
+	plan, err = g.PlanDeprovision(context.Background(), cluster, provider)
+	require.NoError(t, err, "PlanDeprovision should succeed")
+	require.Equal(t, destroyPlan, plan)
+}
+
+func TestDrift(t *testing.T) {
+	server := fakeGCPAPI(nil)
+	defer server.Close()
+
+	mockOp := &mocks.Operator{}
+	g := gcpProvisioner{
+		provisionOperator: mockOp,
+		apiOptions:        fakeAPIOptions(server),
+		catalog:           offlineCatalog(t),
+	}
+
+	cluster := &types.Cluster{
+		CPU:               1,
+		KubernetesVersion: "1.14.8-gke.12",
+		Name:              "hydro-cluster",
+		DiskSizeGB:        30,
+		NodeCount:         2,
+		Location:          "europe-west3",
+		MachineType:       "n1-standard-4",
+	}
+	provider := &types.Provider{
+		Type:                types.GCP,
+		ProjectName:         "my-project",
+		CredentialsFilePath: "/path/to/credentials",
+	}
+
+	_, err := g.Drift(context.Background(), cluster, provider)
+	require.Error(t, err, "Drift should fail without the cluster state")
+
+	state := &types.InternalState{
+		TerraformState: terraform.NewState(),
+	}
+	cluster.ClusterInfo = &types.ClusterInfo{InternalState: state}
+	driftReport := &types.DriftReport{Resources: []types.ResourceDrift{{
+		Address:    "google_container_cluster.gke_cluster",
+		Change:     types.ChangeUpdate,
+		Attributes: []types.AttributeDrift{{Name: "initial_node_count", Declared: "2", Stored: "2", Live: "5", ChangedOutside: true, DiffersFromDeclared: true}},
+	}}}
+	mockOp.On("Drift", mock.Anything, state, types.GCP, g.loadConfigurations(cluster, provider)).Return(driftReport, nil)
+
+	report, err := g.Drift(context.Background(), cluster, provider)
+	require.NoError(t, err, "Drift should succeed")
+	require.Equal(t, driftReport, report)
+	mockOp.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUpdate(t *testing.T) {
+	server := fakeGCPAPI(liveCluster)
+	defer server.Close()
+
+	mockOp := &mocks.Operator{}
+	g := gcpProvisioner{
+		provisionOperator: mockOp,
+		apiOptions:        fakeAPIOptions(server),
+		catalog:           offlineCatalog(t),
+	}
+
+	cluster := &types.Cluster{
+		CPU:               1,
+		KubernetesVersion: "1.14.8-gke.12",
+		Name:              "hydro-cluster",
+		DiskSizeGB:        30,
+		NodeCount:         3,
+		Location:          "europe-west3",
+		MachineType:       "n1-standard-4",
+	}
+	provider := &types.Provider{
+		Type:                types.GCP,
+		ProjectName:         "my-project",
+		CredentialsFilePath: "/path/to/credentials",
+	}
+
+	_, err := g.Update(context.Background(), cluster, provider, false)
+	require.Error(t, err, "Update should fail without the cluster state")
+
+	state := &types.InternalState{
+		TerraformState: terraform.NewState(),
+	}
+	cluster.ClusterInfo = &types.ClusterInfo{InternalState: state}
+	result := &types.ClusterInfo{
+		Endpoint: "https://cluster-url.fake",
+		Status: &types.ClusterStatus{
+			Phase: types.Provisioned,
+		},
+		InternalState: &types.InternalState{
+			TerraformState: terraform.NewState(),
+		},
+	}
+	mockOp.On("Update", mock.Anything, state, types.GCP, g.loadConfigurations(cluster, provider), false).Return(result, nil).Once()
+
+	cluster, err = g.Update(context.Background(), cluster, provider, false)
+	require.NoError(t, err, "Update should succeed")
+	require.Equal(t, result, cluster.ClusterInfo, "The cluster info returned from the operator should be in the updated cluster")
+	require.NotEmpty(t, cluster.ClusterInfo.Outputs["self_link"], "The self link should be read from the live cluster")
+	require.Equal(t, "1.14.8-gke.12", cluster.ClusterInfo.InternalState.KubernetesVersion, "The resolved version should be stored")
+
+	cluster.Location = "europe-west1"
+	mockOp.On("Update", mock.Anything, result.InternalState, types.GCP, g.loadConfigurations(cluster, provider), false).Return(nil, &types.ReplacementError{})
+
+	cluster, err = g.Update(context.Background(), cluster, provider, false)
+	require.Error(t, err, "Update should fail when the cluster would be replaced")
+	var replacement *types.ReplacementError
+	require.True(t, stderrors.As(err, &replacement), "The error should be a ReplacementError")
+	require.Equal(t, result, cluster.ClusterInfo, "A refused update should keep the cluster info")
+}
+
+func TestStateCheckedFirst(t *testing.T) {
+	// without API options, the provisioner would fail to read the credentials file if it called the GCP APIs
+	g := gcpProvisioner{}
+	cluster := &types.Cluster{
+		CPU:               1,
+		KubernetesVersion: "latest",
+		Name:              "hydro-cluster",
+		DiskSizeGB:        30,
+		NodeCount:         2,
+		Location:          "europe-west3",
+		MachineType:       "n1-standard-4",
+	}
+	provider := &types.Provider{
+		Type:                types.GCP,
+		ProjectName:         "my-project",
+		CredentialsFilePath: "/path/to/credentials",
+	}
+
+	_, err := g.Update(context.Background(), cluster, provider, false)
+	require.EqualError(t, err, errs.EmptyClusterInfo, "Update should check the cluster state before calling the GCP APIs")
+	_, err = g.Drift(context.Background(), cluster, provider)
+	require.EqualError(t, err, errs.EmptyClusterInfo, "Drift should check the cluster state before calling the GCP APIs")
+}
+
+func TestImport(t *testing.T) {
+	server := fakeGCPAPI(liveCluster)
+	defer server.Close()
+
+	mockOp := &mocks.Operator{}
+	g := gcpProvisioner{
+		provisionOperator: mockOp,
+		apiOptions:        fakeAPIOptions(server),
+		catalog:           offlineCatalog(t),
+	}
+
+	cluster := &types.Cluster{
+		CPU:               1,
+		KubernetesVersion: "1.14.8-gke.12",
+		Name:              "hydro-cluster",
+		DiskSizeGB:        30,
+		NodeCount:         2,
+		Location:          "europe-west3",
+		MachineType:       "n1-standard-4",
+	}
+	provider := &types.Provider{
+		Type:                types.GCP,
+		ProjectName:         "my-project",
+		CredentialsFilePath: "/path/to/credentials",
+	}
+
+	state := &types.InternalState{
+		TerraformState: terraform.NewState(),
+	}
+	mockOp.On("Import", mock.Anything, types.GCP, g.loadConfigurations(cluster, provider)).Return(&types.ClusterInfo{InternalState: state}, nil)
+
+	// the live cluster runs 1.14.8-gke.12, which is not the newest 1.14 version
+	cluster.KubernetesVersion = "1.14"
+	cluster, err := g.Import(context.Background(), cluster, provider)
+	require.NoError(t, err, "Import should succeed")
+	require.Equal(t, state, cluster.ClusterInfo.InternalState, "The state built by the operator should be in the imported cluster")
+	require.Equal(t, "1.14.8-gke.12", cluster.KubernetesVersion, "The live version should be kept if the specification matches it")
+	require.Equal(t, "1.14.8-gke.12", state.KubernetesVersion, "The live version should be stored")
+	require.Equal(t, "35.1.2.3", cluster.ClusterInfo.Endpoint)
+	require.Equal(t, []byte("My cert"), cluster.ClusterInfo.CertificateAuthorityData)
+	require.Equal(t, types.Provisioned, cluster.ClusterInfo.Status.Phase)
+	require.NotEmpty(t, cluster.ClusterInfo.Outputs["self_link"], "The self link should be read from the live cluster")
+
+	cluster.Name = "missing-cluster"
+	_, err = g.Import(context.Background(), cluster, provider)
+	require.Error(t, err, "Import should fail when the cluster does not exist")
+	var notFound *types.NotFoundError
+	require.True(t, stderrors.As(err, &notFound), "A missing cluster should be reported with a NotFoundError")
+	mockOp.AssertNumberOfCalls(t, "Import", 1)
+}
+
+func TestList(t *testing.T) {
+	var missingZones bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/projects/my-project/locations/-/clusters" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"clusters": [
+			{"name": "hydro-cluster", "location": "europe-west3", "currentMasterVersion": "1.14.7-gke.10", "currentNodeCount": 3, "status": "RUNNING",
+			 "endpoint": "35.1.2.3", "masterAuth": {"clusterCaCertificate": "TXkgY2VydA=="}, "resourceLabels": {"team": "hydro", "env": "ci"},
+			 "nodePools": [{"name": "default-pool", "autoscaling": {"enabled": true, "minNodeCount": 1, "maxNodeCount": 5}, "config": {"machineType": "n1-standard-4", "diskSizeGb": 30}}]},
+			{"name": "other-cluster", "location": "us-central1-a", "currentMasterVersion": "1.13.11-gke.9", "currentNodeCount": 1, "status": "PROVISIONING",
+			 "resourceLabels": {"team": "other"},
+			 "nodePools": [{"name": "gpu", "initialNodeCount": 1, "autoscaling": {"enabled": true, "minNodeCount": 1, "maxNodeCount": 4},
+			                "config": {"machineType": "n1-highmem-8", "diskType": "pd-ssd", "labels": {"pool": "gpu"}, "taints": [{"key": "gpu", "effect": "NO_SCHEDULE"}]}},
+			               {"name": "cpu", "initialNodeCount": 2, "config": {"machineType": "n1-standard-4"}}]}
+		]`)
+		if missingZones {
+			fmt.Fprint(w, `, "missingZones": ["us-east1-b"]`)
+		}
+		fmt.Fprint(w, `}`)
+	}))
+	defer server.Close()
+
+	g := gcpProvisioner{
+		apiOptions: fakeAPIOptions(server),
+	}
+	provider := &types.Provider{
+		Type:                types.GCP,
+		ProjectName:         "my-project",
+		CredentialsFilePath: "/path/to/credentials",
+	}
+
+	clusters, err := g.List(context.Background(), provider, nil)
+	require.NoError(t, err, "List should succeed")
+	require.Len(t, clusters, 2)
+	require.Equal(t, &types.Cluster{
+		Name:              "hydro-cluster",
+		Location:          "europe-west3",
+		KubernetesVersion: "1.14.7-gke.10",
+		NodeCount:         3,
+		MachineType:       "n1-standard-4",
+		DiskSizeGB:        30,
+		Autoscaling:       &types.Autoscaling{Min: 1, Max: 5},
+		Labels:            map[string]string{"team": "hydro", "env": "ci"},
+		ClusterInfo: &types.ClusterInfo{
+			Endpoint:                 "35.1.2.3",
+			CertificateAuthorityData: []byte("My cert"),
+			Status:                   &types.ClusterStatus{Phase: types.Provisioned, Labels: map[string]string{"team": "hydro", "env": "ci"}},
+		},
+	}, clusters[0])
+	require.Equal(t, types.Provisioning, clusters[1].ClusterInfo.Status.Phase)
+	require.Equal(t, []types.NodePool{{
+		Name:        "gpu",
+		MachineType: "n1-highmem-8",
+		Autoscaling: &types.Autoscaling{Min: 1, Max: 4},
+		DiskType:    "pd-ssd",
+		Labels:      map[string]string{"pool": "gpu"},
+		Taints:      []types.Taint{{Key: "gpu", Effect: types.NoSchedule}},
+	}, {
+		Name:        "cpu",
+		MachineType: "n1-standard-4",
+		NodeCount:   2,
+	}}, clusters[1].NodePools, "Node pools other than the default one should be listed, without the stale initial size of autoscaled pools")
+
+	clusters, err = g.List(context.Background(), provider, map[string]string{"team": "hydro"})
+	require.NoError(t, err)
+	require.Len(t, clusters, 1, "Only the clusters with the labels should be listed")
+	require.Equal(t, "hydro-cluster", clusters[0].Name)
+
+	clusters, err = g.List(context.Background(), provider, map[string]string{"team": "hydro", "env": "prod"})
+	require.NoError(t, err)
+	require.Empty(t, clusters, "A cluster should only be listed if it has all the labels")
+
+	missingZones = true
+	clusters, err = g.List(context.Background(), provider, nil)
+	var incompleteErr *types.IncompleteListError
+	require.True(t, stderrors.As(err, &incompleteErr), "List should report unavailable zones")
+	require.Equal(t, []string{"us-east1-b"}, incompleteErr.Locations)
+	require.Len(t, clusters, 2, "The clusters of the available zones should be listed")
+
+	_, err = g.List(context.Background(), &types.Provider{Type: types.GCP}, nil)
+	var validationErr *types.ValidationError
+	require.True(t, stderrors.As(err, &validationErr), "List should fail without project and credentials")
+}
@@ -0,0 +1,221 @@
+package operator
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/terraform"
+	"github.com/kyma-incubator/hydroform/types"
+	"github.com/pkg/errors"
+)
+
+// resourceDrift collects the drift of a resource, with its attributes indexed by name.
+type resourceDrift struct {
+	types.ResourceDrift
+	attributes map[string]*types.AttributeDrift
+}
+
+func (r *resourceDrift) attribute(name string) *types.AttributeDrift {
+	a, ok := r.attributes[name]
+	if !ok {
+		a = &types.AttributeDrift{Name: name}
+		r.attributes[name] = a
+	}
+	return a
+}
+
+// driftReport compares the resources of the stored state with those of the live state, and adds the differences between the live state and the configuration found in the diff.
+func driftReport(stored, live *terraform.State, diff *terraform.Diff, sensitive func(resourceType, attribute string) bool) (*types.DriftReport, error) {
+	resources := map[string]*resourceDrift{}
+	// resource returns the drift of the resource with the given key in the module with the given address prefix
+	resource := func(prefix, key string) (*resourceDrift, error) {
+		if r, ok := resources[prefix+key]; ok {
+			return r, nil
+		}
+		resourceKey, err := terraform.ParseResourceStateKey(key)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to read the state")
+		}
+		r := &resourceDrift{
+			ResourceDrift: types.ResourceDrift{Address: prefix + key, Type: resourceKey.Type, Name: resourceKey.Name},
+			attributes:    map[string]*types.AttributeDrift{},
+		}
+		resources[prefix+key] = r
+		return r, nil
+	}
+
+	storedResources := stateResources(stored)
+	liveResources := stateResources(live)
+
+	// changes made outside of Hydroform
+	for address, s := range storedResources {
+		l, ok := liveResources[address]
+		if !ok {
+			r, err := resource(s.prefix, s.key)
+			if err != nil {
+				return nil, err
+			}
+			r.Missing = true
+			continue
+		}
+		for name := range unionKeys(s.attributes, l.attributes) {
+			if s.attributes[name] == l.attributes[name] {
+				continue
+			}
+			r, err := resource(s.prefix, s.key)
+			if err != nil {
+				return nil, err
+			}
+			a := r.attribute(name)
+			a.Stored, a.Live, a.ChangedOutside = s.attributes[name], l.attributes[name], true
+		}
+	}
+
+	// differences from the configuration
+	if diff != nil {
+		for _, module := range diff.Modules {
+			prefix := modulePrefix(module.Path)
+			for key, instance := range module.Resources {
+				change, ok := changeType(instance)
+				if !ok {
+					continue
+				}
+
+				r, err := resource(prefix, key)
+				if err != nil {
+					return nil, err
+				}
+				r.Change = change
+				if change == types.ChangeCreate {
+					r.Missing = true
+					continue
+				}
+
+				for name, attr := range instance.CopyAttributes() {
+					// values only known after applying do not tell anything about the live cluster
+					if attr.NewComputed || (attr.Old == attr.New && !attr.NewRemoved) {
+						continue
+					}
+					a := r.attribute(name)
+					a.Declared, a.Live, a.DiffersFromDeclared = attr.New, attr.Old, true
+					a.Stored = storedResources[prefix+key].attributes[name]
+					a.RequiresReplacement = attr.RequiresNew
+					a.Sensitive = attr.Sensitive
+				}
+			}
+		}
+	}
+
+	report := &types.DriftReport{}
+	for _, r := range resources {
+		for _, a := range r.attributes {
+			if a.Sensitive || sensitive(r.Type, a.Name) {
+				a.Sensitive = true
+				a.Stored, a.Live = sensitiveValue, sensitiveValue
+				if a.DiffersFromDeclared {
+					a.Declared = sensitiveValue
+				}
+			}
+			r.Attributes = append(r.Attributes, *a)
+		}
+		sort.Slice(r.Attributes, func(i, j int) bool { return r.Attributes[i].Name < r.Attributes[j].Name })
+		report.Resources = append(report.Resources, r.ResourceDrift)
+	}
+	sort.Slice(report.Resources, func(i, j int) bool { return report.Resources[i].Address < report.Resources[j].Address })
+
+	return report, nil
+}
+
+// stateResource is an existing resource of a state.
+type stateResource struct {
+	// prefix is the address prefix of the module of the resource.
+	prefix     string
+	key        string
+	attributes map[string]string
+}
+
+// stateResources returns the existing resources of the state, keyed by address.
+func stateResources(state *terraform.State) map[string]stateResource {
+	resources := map[string]stateResource{}
+	if state == nil {
+		return resources
+	}
+	for _, module := range state.Modules {
+		prefix := modulePrefix(module.Path)
+		for key, r := range module.Resources {
+			if r.Primary == nil || r.Primary.ID == "" {
+				continue
+			}
+			resources[prefix+key] = stateResource{prefix: prefix, key: key, attributes: r.Primary.Attributes}
+		}
+	}
+	return resources
+}
+
+// modulePrefix returns the prefix of the addresses of the resources in a module.
+func modulePrefix(path []string) string {
+	prefix := ""
+	// the first element of the path is always the root module
+	for _, name := range path[1:] {
+		prefix += fmt.Sprintf("module.%s.", name)
+	}
+	return prefix
+}
+
+func unionKeys(a, b map[string]string) map[string]bool {
+	keys := map[string]bool{}
+	for k := range a {
+		keys[k] = true
+	}
+	for k := range b {
+		keys[k] = true
+	}
+	return keys
+}
+
+// sensitiveAttributes returns a function telling whether an attribute of a resource is marked as sensitive by the schema of its provider.
+func sensitiveAttributes(providers map[string]terraform.ResourceProvider) func(resourceType, attribute string) bool {
+	return func(resourceType, attribute string) bool {
+		for _, p := range providers {
+			provider, ok := p.(*schema.Provider)
+			if !ok {
+				continue
+			}
+			if r, ok := provider.ResourcesMap[resourceType]; ok {
+				return sensitiveAttribute(r.Schema, strings.Split(attribute, "."))
+			}
+		}
+		return false
+	}
+}
+
+// sensitiveAttribute walks the flattened attribute path down the nested schemas. The path alternates attribute names with list indexes, set hashes, or the `#` and `%` counts.
+func sensitiveAttribute(s map[string]*schema.Schema, path []string) bool {
+	for len(path) > 0 {
+		attr, ok := s[path[0]]
+		if !ok {
+			return false
+		}
+		if attr.Sensitive {
+			return true
+		}
+
+		path = path[1:]
+		if len(path) == 0 {
+			return false
+		}
+		if _, err := strconv.Atoi(path[0]); err == nil || path[0] == "#" || path[0] == "%" {
+			path = path[1:]
+		}
+
+		elem, ok := attr.Elem.(*schema.Resource)
+		if !ok {
+			return false
+		}
+		s = elem.Schema
+	}
+	return false
+}
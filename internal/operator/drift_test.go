@@ -0,0 +1,101 @@
+package operator
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform/terraform"
+	"github.com/kyma-incubator/hydroform/types"
+	"github.com/stretchr/testify/require"
+	"github.com/terraform-providers/terraform-provider-google/google"
+)
+
+func newClusterState(attributes map[string]string) *terraform.State {
+	state := terraform.NewState()
+	state.RootModule().Resources["google_container_cluster.gke_cluster"] = &terraform.ResourceState{
+		Type:     "google_container_cluster",
+		Provider: "provider.google",
+		Primary: &terraform.InstanceState{
+			ID:         "hydro-cluster",
+			Attributes: attributes,
+		},
+	}
+	return state
+}
+
+func TestDriftReport(t *testing.T) {
+	stored := newClusterState(map[string]string{
+		"id":                         "hydro-cluster",
+		"initial_node_count":         "2",
+		"node_config.0.machine_type": "n1-standard-4",
+		"master_auth.0.password":     "secret",
+	})
+	stored.RootModule().Resources["google_container_node_pool.pool"] = &terraform.ResourceState{
+		Type:    "google_container_node_pool",
+		Primary: &terraform.InstanceState{ID: "pool"},
+	}
+	live := newClusterState(map[string]string{
+		"id":                         "hydro-cluster",
+		"initial_node_count":         "5",
+		"node_config.0.machine_type": "n1-standard-4",
+		"master_auth.0.password":     "changed",
+		"master_version":             "1.14.7-gke.10",
+	})
+	diff := &terraform.Diff{
+		Modules: []*terraform.ModuleDiff{
+			{
+				Path: []string{"root"},
+				Resources: map[string]*terraform.InstanceDiff{
+					"google_container_cluster.gke_cluster": {
+						Attributes: map[string]*terraform.ResourceAttrDiff{
+							"initial_node_count": {Old: "5", New: "2"},
+							"endpoint":           {Old: "35.1.2.3", NewComputed: true},
+						},
+					},
+					"google_container_node_pool.pool": {
+						Attributes: map[string]*terraform.ResourceAttrDiff{
+							"name": {Old: "", New: "pool", RequiresNew: true},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	report, err := driftReport(stored, live, diff, sensitiveAttributes(map[string]terraform.ResourceProvider{"google": google.Provider()}))
+	require.NoError(t, err)
+	require.True(t, report.Drifted())
+	require.Equal(t, []types.ResourceDrift{
+		{
+			Address: "google_container_cluster.gke_cluster",
+			Type:    "google_container_cluster",
+			Name:    "gke_cluster",
+			Change:  types.ChangeUpdate,
+			Attributes: []types.AttributeDrift{
+				{Name: "initial_node_count", Declared: "2", Stored: "2", Live: "5", ChangedOutside: true, DiffersFromDeclared: true},
+				{Name: "master_auth.0.password", Stored: sensitiveValue, Live: sensitiveValue, ChangedOutside: true, Sensitive: true},
+				{Name: "master_version", Stored: "", Live: "1.14.7-gke.10", ChangedOutside: true},
+			},
+		},
+		{
+			Address: "google_container_node_pool.pool",
+			Type:    "google_container_node_pool",
+			Name:    "pool",
+			Missing: true,
+			Change:  types.ChangeCreate,
+		},
+	}, report.Resources)
+
+	report, err = driftReport(stored, stored.DeepCopy(), nil, func(string, string) bool { return false })
+	require.NoError(t, err)
+	require.False(t, report.Drifted(), "A cluster matching its state and configuration should not drift")
+}
+
+func TestSensitiveAttributes(t *testing.T) {
+	sensitive := sensitiveAttributes(map[string]terraform.ResourceProvider{"google": google.Provider()})
+
+	require.True(t, sensitive("google_container_cluster", "master_auth.0.password"))
+	require.True(t, sensitive("google_container_cluster", "master_auth.0.client_key"))
+	require.False(t, sensitive("google_container_cluster", "master_auth.0.cluster_ca_certificate"))
+	require.False(t, sensitive("google_container_cluster", "initial_node_count"))
+	require.False(t, sensitive("unknown_resource", "password"))
+}
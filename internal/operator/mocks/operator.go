@@ -3,6 +3,8 @@
 package mocks
 
 import (
+	context "context"
+
 	mock "github.com/stretchr/testify/mock"
 
 	types "github.com/kyma-incubator/hydroform/types"
@@ -13,13 +15,13 @@ type Operator struct {
 	mock.Mock
 }
 
-// Create provides a mock function with given fields: providerType, configuration
-func (_m *Operator) Create(providerType types.ProviderType, configuration map[string]interface{}) (*types.ClusterInfo, error) {
-	ret := _m.Called(providerType, configuration)
+// Create provides a mock function with given fields: ctx, providerType, configuration
+func (_m *Operator) Create(ctx context.Context, providerType types.ProviderType, configuration map[string]interface{}) (*types.ClusterInfo, error) {
+	ret := _m.Called(ctx, providerType, configuration)
 
 	var r0 *types.ClusterInfo
-	if rf, ok := ret.Get(0).(func(types.ProviderType, map[string]interface{}) *types.ClusterInfo); ok {
-		r0 = rf(providerType, configuration)
+	if rf, ok := ret.Get(0).(func(context.Context, types.ProviderType, map[string]interface{}) *types.ClusterInfo); ok {
+		r0 = rf(ctx, providerType, configuration)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*types.ClusterInfo)
@@ -27,8 +29,8 @@ func (_m *Operator) Create(providerType types.ProviderType, configuration map[st
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(types.ProviderType, map[string]interface{}) error); ok {
-		r1 = rf(providerType, configuration)
+	if rf, ok := ret.Get(1).(func(context.Context, types.ProviderType, map[string]interface{}) error); ok {
+		r1 = rf(ctx, providerType, configuration)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -36,16 +38,108 @@ func (_m *Operator) Create(providerType types.ProviderType, configuration map[st
 	return r0, r1
 }
 
-// Delete provides a mock function with given fields: state, providerType, configuration
-func (_m *Operator) Delete(state *types.InternalState, providerType types.ProviderType, configuration map[string]interface{}) error {
-	ret := _m.Called(state, providerType, configuration)
+// Delete provides a mock function with given fields: ctx, state, providerType, configuration
+func (_m *Operator) Delete(ctx context.Context, state *types.InternalState, providerType types.ProviderType, configuration map[string]interface{}) error {
+	ret := _m.Called(ctx, state, providerType, configuration)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(*types.InternalState, types.ProviderType, map[string]interface{}) error); ok {
-		r0 = rf(state, providerType, configuration)
+	if rf, ok := ret.Get(0).(func(context.Context, *types.InternalState, types.ProviderType, map[string]interface{}) error); ok {
+		r0 = rf(ctx, state, providerType, configuration)
 	} else {
 		r0 = ret.Error(0)
 	}
 
 	return r0
 }
+
+// Drift provides a mock function with given fields: ctx, state, providerType, configuration
+func (_m *Operator) Drift(ctx context.Context, state *types.InternalState, providerType types.ProviderType, configuration map[string]interface{}) (*types.DriftReport, error) {
+	ret := _m.Called(ctx, state, providerType, configuration)
+
+	var r0 *types.DriftReport
+	if rf, ok := ret.Get(0).(func(context.Context, *types.InternalState, types.ProviderType, map[string]interface{}) *types.DriftReport); ok {
+		r0 = rf(ctx, state, providerType, configuration)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*types.DriftReport)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *types.InternalState, types.ProviderType, map[string]interface{}) error); ok {
+		r1 = rf(ctx, state, providerType, configuration)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Import provides a mock function with given fields: ctx, providerType, configuration
+func (_m *Operator) Import(ctx context.Context, providerType types.ProviderType, configuration map[string]interface{}) (*types.ClusterInfo, error) {
+	ret := _m.Called(ctx, providerType, configuration)
+
+	var r0 *types.ClusterInfo
+	if rf, ok := ret.Get(0).(func(context.Context, types.ProviderType, map[string]interface{}) *types.ClusterInfo); ok {
+		r0 = rf(ctx, providerType, configuration)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*types.ClusterInfo)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, types.ProviderType, map[string]interface{}) error); ok {
+		r1 = rf(ctx, providerType, configuration)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Plan provides a mock function with given fields: ctx, state, providerType, configuration, destroy
+func (_m *Operator) Plan(ctx context.Context, state *types.InternalState, providerType types.ProviderType, configuration map[string]interface{}, destroy bool) (*types.Plan, error) {
+	ret := _m.Called(ctx, state, providerType, configuration, destroy)
+
+	var r0 *types.Plan
+	if rf, ok := ret.Get(0).(func(context.Context, *types.InternalState, types.ProviderType, map[string]interface{}, bool) *types.Plan); ok {
+		r0 = rf(ctx, state, providerType, configuration, destroy)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*types.Plan)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *types.InternalState, types.ProviderType, map[string]interface{}, bool) error); ok {
+		r1 = rf(ctx, state, providerType, configuration, destroy)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Update provides a mock function with given fields: ctx, state, providerType, configuration, allowReplacement
+func (_m *Operator) Update(ctx context.Context, state *types.InternalState, providerType types.ProviderType, configuration map[string]interface{}, allowReplacement bool) (*types.ClusterInfo, error) {
+	ret := _m.Called(ctx, state, providerType, configuration, allowReplacement)
+
+	var r0 *types.ClusterInfo
+	if rf, ok := ret.Get(0).(func(context.Context, *types.InternalState, types.ProviderType, map[string]interface{}, bool) *types.ClusterInfo); ok {
+		r0 = rf(ctx, state, providerType, configuration, allowReplacement)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*types.ClusterInfo)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *types.InternalState, types.ProviderType, map[string]interface{}, bool) error); ok {
+		r1 = rf(ctx, state, providerType, configuration, allowReplacement)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
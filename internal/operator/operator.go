@@ -1,13 +1,21 @@
 package operator
 
-import "github.com/kyma-incubator/hydroform/types"
+import (
+	"context"
+
+	"github.com/kyma-incubator/hydroform/types"
+)
 
 //go:generate mockery -name=Operator -case=snake
 
 // Operator allows switching easily between different types of provisioning operators.
 type Operator interface {
-	Create(providerType types.ProviderType, configuration map[string]interface{}) (*types.ClusterInfo, error)
-	Delete(state *types.InternalState, providerType types.ProviderType, configuration map[string]interface{}) error
+	Create(ctx context.Context, providerType types.ProviderType, configuration map[string]interface{}) (*types.ClusterInfo, error)
+	Delete(ctx context.Context, state *types.InternalState, providerType types.ProviderType, configuration map[string]interface{}) error
+	Update(ctx context.Context, state *types.InternalState, providerType types.ProviderType, configuration map[string]interface{}, allowReplacement bool) (*types.ClusterInfo, error)
+	Import(ctx context.Context, providerType types.ProviderType, configuration map[string]interface{}) (*types.ClusterInfo, error)
+	Plan(ctx context.Context, state *types.InternalState, providerType types.ProviderType, configuration map[string]interface{}, destroy bool) (*types.Plan, error)
+	Drift(ctx context.Context, state *types.InternalState, providerType types.ProviderType, configuration map[string]interface{}) (*types.DriftReport, error)
 }
 
 // Type points out the type of the operator.
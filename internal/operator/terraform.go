@@ -1,8 +1,11 @@
 package operator
 
 import (
+	"context"
 	"encoding/base64"
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 	"text/template"
 
@@ -29,6 +32,9 @@ const (
   variable "machine_type"  		{}
   variable "kubernetes_version"   	{}
   variable "disk_size" 			{}
+  variable "labels" {
+	type = "map"
+  }
 
   provider "google" {
     	credentials   = "${file("${var.credentials_file_path}")}"
@@ -38,14 +44,47 @@ const (
   resource "google_container_cluster" "gke_cluster" {
     	name               = "${var.cluster_name}"
     	location 	   = "${var.location}"
-    	initial_node_count = "${var.node_count}"
     	min_master_version = "${var.kubernetes_version}"
     	node_version       = "${var.kubernetes_version}"
-    
+    	resource_labels    = "${var.labels}"
+    {{ with index . "networking" }}
+      {{ with .Network }}
+    	network            = {{ quote . }}
+      {{ end }}
+      {{ if and .Subnetwork (not .NodeCIDR) }}
+    	subnetwork         = {{ quote .Subnetwork }}
+      {{ end }}
+      {{ if or .NodeCIDR .PodCIDR .ServiceCIDR }}
+    # the ranges are allocated as aliases, GKE creates the subnetwork if the node range is set
+    ip_allocation_policy {
+      {{ if .NodeCIDR }}
+      	create_subnetwork        = true
+      	node_ipv4_cidr_block     = {{ quote .NodeCIDR }}
+        {{ with .Subnetwork }}
+      	subnetwork_name          = {{ quote . }}
+        {{ end }}
+      {{ end }}
+      {{ with .PodCIDR }}
+      	cluster_ipv4_cidr_block  = {{ quote . }}
+      {{ end }}
+      {{ with .ServiceCIDR }}
+      	services_ipv4_cidr_block = {{ quote . }}
+      {{ end }}
+    }
+      {{ end }}
+    {{ end }}
+    {{ if index . "node_pools" }}
+    	# the node pools are separate resources, so that they can change without replacing the cluster
+    	remove_default_node_pool = true
+    	initial_node_count       = 1
+    {{ else }}
+    	initial_node_count = "${var.node_count}"
+
     node_config {
       	machine_type = "${var.machine_type}"
 		disk_size_gb = "${var.disk_size}"
     }
+    {{ end }}
 
     maintenance_policy {
       	daily_maintenance_window {
@@ -54,6 +93,32 @@ const (
     	}
   }
 
+{{ with index . "node_pools" }}{{ range . }}
+  resource "google_container_node_pool" "{{ .Name }}" {
+    	name     = {{ quote .Name }}
+    	cluster  = "${google_container_cluster.gke_cluster.name}"
+    	location = "${var.location}"
+    	version  = "${var.kubernetes_version}"
+    {{ if .Autoscaling }}
+    	initial_node_count = {{ .NodeCount }}
+
+    autoscaling {
+      	min_node_count = {{ .Autoscaling.Min }}
+      	max_node_count = {{ .Autoscaling.Max }}
+    }
+    {{ else }}
+    	node_count = {{ .NodeCount }}
+    {{ end }}
+    node_config {
+      	machine_type = {{ quote .MachineType }}
+      	disk_size_gb = {{ if .DiskSizeGB }}{{ .DiskSizeGB }}{{ else }}"${var.disk_size}"{{ end }}
+      {{ if .DiskType }}
+      	disk_type    = {{ quote .DiskType }}
+      {{ end }}
+      	labels       = {{ hclMap .Labels }}
+    }
+  }
+{{ end }}{{ end }}
   output "endpoint" {
     value = "${google_container_cluster.gke_cluster.endpoint}"
   }
@@ -61,6 +126,18 @@ const (
   output "cluster_ca_certificate" {
     value = "${google_container_cluster.gke_cluster.master_auth.0.cluster_ca_certificate}"
   }
+
+  output "master_version" {
+    value = "${google_container_cluster.gke_cluster.master_version}"
+  }
+
+  output "cluster_ipv4_cidr" {
+    value = "${google_container_cluster.gke_cluster.cluster_ipv4_cidr}"
+  }
+
+  output "instance_group_urls" {
+    value = "${google_container_cluster.gke_cluster.instance_group_urls}"
+  }
 `
 
 	gardenerClusterTemplate = `
@@ -83,10 +160,17 @@ variable "machine_type"  			{}
 variable "kubernetes_version"   	{}
 variable "disk_size" 				{}
 variable "disk_type" 				{}
-variable "autoscaler_min" 			{}
-variable "autoscaler_max" 			{}
-variable "max_surge" 				{}
-variable "max_unavailable" 			{}
+# the autoscaler bounds are only needed for the default worker group, the surge settings default to those of Gardener
+variable "autoscaler_min" 			{ default = "" }
+variable "autoscaler_max" 			{ default = "" }
+variable "max_surge" 				{ default = 1 }
+variable "max_unavailable" 			{ default = 0 }
+variable "labels" {
+	type = "map"
+}
+variable "annotations" {
+	type = "map"
+}
 
 provider "gardener" {
 	kube_file          = "${file("${var.credentials_file_path}")}"
@@ -94,9 +178,10 @@ provider "gardener" {
 
 resource "gardener_shoot" "test_cluster" {
 	metadata {
-	  name      = "${var.cluster_name}"
-	  namespace = "${var.namespace}"
-  
+	  name        = "${var.cluster_name}"
+	  namespace   = "${var.namespace}"
+	  labels      = "${var.labels}"
+	  annotations = "${var.annotations}"
 	}
   
 	spec {
@@ -112,6 +197,7 @@ resource "gardener_shoot" "test_cluster" {
 		gcp {  
           networks {
 			workers = ["${var.workercidr}"]
+			{{ template "k8s_networks" index . "networking" }}
 		  }
 		{{ end }}
 
@@ -120,6 +206,7 @@ resource "gardener_shoot" "test_cluster" {
           networks {
 			vnet    = [{cidr = "${var.vnetcidr}"}]
 			workers = "${var.workercidr}"
+			{{ template "k8s_networks" index . "networking" }}
 		  }
 		{{ end }}
 
@@ -130,12 +217,40 @@ resource "gardener_shoot" "test_cluster" {
 			public		  = ["${var.publicscidr}"]
 			internal	  = ["${var.internalscidr}"]
 			vpc			  = [{cidr = "${var.vpccidr}"}]
+			{{ template "k8s_networks" index . "networking" }}
 		  }
 		{{ end }}
 
-		  {{range (seq (index . "node_count"))}}
+		  {{ with index . "node_pools" }}{{ range . }}
+		  worker {
+			  name            = {{ quote .Name }}
+			  machine_type    = {{ quote .MachineType }}
+			  {{ if .Autoscaling }}
+			  auto_scaler_min = {{ .Autoscaling.Min }}
+			  auto_scaler_max = {{ .Autoscaling.Max }}
+			  max_surge       = {{ if or .Autoscaling.MaxSurge .Autoscaling.MaxUnavailable }}{{ .Autoscaling.MaxSurge }}{{ else }}1{{ end }}
+			  max_unavailable = {{ .Autoscaling.MaxUnavailable }}
+			  {{ else }}
+			  auto_scaler_min = {{ .NodeCount }}
+			  auto_scaler_max = {{ .NodeCount }}
+			  max_surge       = "${var.max_surge}"
+			  max_unavailable = "${var.max_unavailable}"
+			  {{ end }}
+			  volume_size     = {{ if .DiskSizeGB }}"{{ .DiskSizeGB }}Gi"{{ else }}"${var.disk_size}Gi"{{ end }}
+			  volume_type     = {{ if .DiskType }}{{ quote .DiskType }}{{ else }}"${var.disk_type}"{{ end }}
+			  labels          = {{ hclMap .Labels }}
+			  {{ range .Taints }}
+			  taints {
+				  key      = {{ quote .Key }}
+				  operator = {{ if .Value }}"Equal"{{ else }}"Exists"{{ end }}
+				  value    = {{ quote .Value }}
+				  effect   = {{ quote .Effect }}
+			  }
+			  {{ end }}
+		  }
+		  {{ end }}{{ else }}
 		  worker {
-			  name            = "cpu-worker-{{.}}"
+			  name            = "cpu-worker-0"
 			  machine_type    = "${var.machine_type}"
 			  auto_scaler_min = "${var.autoscaler_min}"
 			  auto_scaler_max = "${var.autoscaler_max}"
@@ -144,9 +259,9 @@ resource "gardener_shoot" "test_cluster" {
 			  volume_size     = "${var.disk_size}Gi"
 			  volume_type     = "${var.disk_type}"
 		  }
-		  {{end}}
+		  {{ end }}
           {{ if not (eq (index . "target_provider") "azure") }}
-		  zones = ["${var.zone}"]
+		  zones = {{ with zones (index . "node_pools") }}{{ hclList . }}{{ else }}["${var.zone}"]{{ end }}
           {{ end }}
 		}
 	  }
@@ -155,7 +270,31 @@ resource "gardener_shoot" "test_cluster" {
 		version = "${var.kubernetes_version}"
 	  }
 	}
+
+	# the domain is assigned by Gardener and the deletion confirmation is added by the provider when reading the shoot
+	lifecycle {
+	  ignore_changes = ["spec.0.dns", "metadata.0.annotations.confirmation.garden.sapcloud.io/deletion"]
+	}
   }
+
+output "uid" {
+	value = "${gardener_shoot.test_cluster.metadata.0.uid}"
+}
+
+output "self_link" {
+	value = "${gardener_shoot.test_cluster.metadata.0.self_link}"
+}
+
+# Gardener assigns a domain to every shoot which does not set one
+output "domain" {
+	value = "${gardener_shoot.test_cluster.spec.0.dns.0.domain}"
+}
+
+{{ define "k8s_networks" }}{{ with . }}
+			{{ with .NodeCIDR }}nodes    = {{ quote . }}{{ end }}
+			{{ with .PodCIDR }}pods     = {{ quote . }}{{ end }}
+			{{ with .ServiceCIDR }}services = {{ quote . }}{{ end }}
+{{ end }}{{ end }}
 `
 )
 
@@ -164,54 +303,284 @@ type Terraform struct {
 }
 
 // Create creates a new cluster for a specific provider based on configuration details. It returns a ClusterInfo object with provider-related information, or an error if cluster provisioning failed.
-func (t *Terraform) Create(providerType types.ProviderType, configuration map[string]interface{}) (*types.ClusterInfo, error) {
+// Cancelling the context stops the running Terraform operation.
+func (t *Terraform) Create(ctx context.Context, providerType types.ProviderType, configuration map[string]interface{}) (*types.ClusterInfo, error) {
 	platform, err := t.newPlatform(providerType, configuration)
 	if err != nil {
 		return nil, err
 	}
 
-	state, err := platform.Apply(terraformClient.NewState(), false)
+	state, err := platform.Apply(ctx, terraformClient.NewState(), false)
 	if err != nil {
 		return &types.ClusterInfo{
-			InternalState: &types.InternalState{TerraformState: state},
+			InternalState: internalState(providerType, state),
 			Status:        &types.ClusterStatus{Phase: types.Errored},
 		}, errors.Wrap(err, "unable to provision cluster")
 	}
 
+	return clusterInfo(providerType, state)
+}
+
+// Delete removes an existing cluster or returns an error if removing the cluster is not possible.
+// Cancelling the context stops the running Terraform operation.
+func (t *Terraform) Delete(ctx context.Context, state *types.InternalState, providerType types.ProviderType, configuration map[string]interface{}) error {
+	platform, err := t.newPlatform(providerType, configuration)
+	if err != nil {
+		return err
+	}
+
+	_, err = platform.Apply(ctx, state.TerraformState, true)
+	return errors.Wrap(err, "unable to deprovision cluster")
+}
+
+// Update applies the configuration to an existing cluster and returns the updated ClusterInfo. Unless allowReplacement is true, changes that would destroy or replace the resources holding the cluster itself are refused with a ReplacementError before anything is applied.
+// Cancelling the context stops the running Terraform operation.
+func (t *Terraform) Update(ctx context.Context, state *types.InternalState, providerType types.ProviderType, configuration map[string]interface{}, allowReplacement bool) (*types.ClusterInfo, error) {
+	if state == nil || state.TerraformState == nil {
+		return nil, errors.New("unable to update cluster: the cluster state is empty")
+	}
+
+	platform, err := t.newPlatform(providerType, configuration)
+	if err != nil {
+		return nil, err
+	}
+
+	var refused error
+	newState, err := platform.ApplyApproved(ctx, state.TerraformState, func(plan *terraform.Plan) error {
+		if allowReplacement {
+			return nil
+		}
+		p, err := convertDiff(plan.Diff)
+		if err != nil {
+			return err
+		}
+		refused = checkReplacement(providerType, p)
+		return refused
+	})
+	if refused != nil {
+		return nil, refused
+	}
+	if err != nil {
+		return &types.ClusterInfo{
+			InternalState: internalState(providerType, newState),
+			Status:        &types.ClusterStatus{Phase: types.Errored},
+		}, errors.Wrap(err, "unable to update cluster")
+	}
+
+	return clusterInfo(providerType, newState)
+}
+
+// Import builds the state of an existing cluster, and of its node pools on GCP, which was not provisioned by Hydroform, as if it had been created from the configuration. The state is refreshed from the live cluster, so the returned ClusterInfo can be used to manage the cluster like a provisioned one.
+func (t *Terraform) Import(ctx context.Context, providerType types.ProviderType, configuration map[string]interface{}) (*types.ClusterInfo, error) {
+	address, resource, err := clusterResource(providerType, configuration)
+	if err != nil {
+		return nil, err
+	}
+
+	platform, err := t.newPlatform(providerType, configuration)
+	if err != nil {
+		return nil, err
+	}
+
+	state := terraformClient.NewState()
+	state.RootModule().Resources[address] = resource
+	if providerType == types.GCP {
+		for poolAddress, pool := range nodePoolResources(configuration) {
+			state.RootModule().Resources[poolAddress] = pool
+		}
+	}
+
+	state, err = platform.Refresh(ctx, state)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to import cluster")
+	}
+	// resources which cannot be found are dropped from the state during the refresh
+	if r, ok := state.RootModule().Resources[address]; !ok || r.Primary == nil || r.Primary.ID == "" {
+		return nil, errors.Errorf("unable to import cluster: %s %s not found", providerType, resource.Primary.ID)
+	}
+
+	return clusterInfo(providerType, state)
+}
+
+// Plan returns the changes that applying the configuration would make, starting from the given state. If destroy is true, the changes needed to remove the cluster are returned instead.
+// A nil state stands for a cluster which does not exist yet. The given state is left untouched.
+func (t *Terraform) Plan(ctx context.Context, state *types.InternalState, providerType types.ProviderType, configuration map[string]interface{}, destroy bool) (*types.Plan, error) {
+	platform, err := t.newPlatform(providerType, configuration)
+	if err != nil {
+		return nil, err
+	}
+
+	tfState := terraformClient.NewState()
+	if state != nil && state.TerraformState != nil {
+		// the plan is made against the refreshed state, which it keeps
+		tfState = state.TerraformState.DeepCopy()
+	}
+
+	plan, err := platform.Plan(ctx, tfState, destroy)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to plan cluster changes")
+	}
+
+	return convertDiff(plan.Diff)
+}
+
+// Drift compares the stored state with the live cluster and with the configuration. The state is refreshed and planned like for Plan, but nothing is applied, and the given state is left untouched.
+func (t *Terraform) Drift(ctx context.Context, state *types.InternalState, providerType types.ProviderType, configuration map[string]interface{}) (*types.DriftReport, error) {
+	if state == nil || state.TerraformState == nil {
+		return nil, errors.New("unable to detect cluster drift: the cluster state is empty")
+	}
+
+	platform, err := t.newPlatform(providerType, configuration)
+	if err != nil {
+		return nil, err
+	}
+
+	// the plan is made against the refreshed state, which it keeps
+	plan, err := platform.Plan(ctx, state.TerraformState.DeepCopy(), false)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to detect cluster drift")
+	}
+
+	return driftReport(state.TerraformState, plan.State, plan.Diff, sensitiveAttributes(platform.Providers))
+}
+
+// clusterInfo reads the cluster details from the outputs of the Terraform state.
+func clusterInfo(providerType types.ProviderType, state *terraform.State) (*types.ClusterInfo, error) {
 	var certificateData []byte
 	var endpoint string
+	var err error
+	outputs := map[string]interface{}{}
 	if len(state.Modules) > 0 {
-		if val, ok := state.Modules[0].Outputs["cluster_ca_certificate"]; ok {
-			certificateData, err = base64.StdEncoding.DecodeString(fmt.Sprintf("%v", val.Value))
+		for name, output := range state.Modules[0].Outputs {
+			outputs[name] = output.Value
+		}
+		if val, ok := outputs["cluster_ca_certificate"]; ok {
+			certificateData, err = base64.StdEncoding.DecodeString(fmt.Sprintf("%v", val))
 			if err != nil {
 				return &types.ClusterInfo{
-					InternalState: &types.InternalState{TerraformState: state},
+					InternalState: internalState(providerType, state),
 					Status:        &types.ClusterStatus{Phase: types.Errored},
+					Outputs:       outputs,
 				}, errors.Wrap(err, "Unable to decode certificate data")
 			}
 		}
-		if val, ok := state.Modules[0].Outputs["endpoint"]; ok {
-			endpoint = fmt.Sprintf("%v", val.Value)
+		if val, ok := outputs["endpoint"]; ok {
+			endpoint = fmt.Sprintf("%v", val)
 		}
 	}
 
 	return &types.ClusterInfo{
 		Endpoint:                 endpoint,
 		CertificateAuthorityData: certificateData,
-		InternalState:            &types.InternalState{TerraformState: state},
+		InternalState:            internalState(providerType, state),
 		Status:                   &types.ClusterStatus{Phase: types.Provisioned},
+		Outputs:                  outputs,
 	}, nil
 }
 
-// Delete removes an existing cluster or returns an error if removing the cluster is not possible.
-func (t *Terraform) Delete(state *types.InternalState, providerType types.ProviderType, configuration map[string]interface{}) error {
-	platform, err := t.newPlatform(providerType, configuration)
-	if err != nil {
-		return err
+// internalState wraps a Terraform state of a cluster on the given provider.
+func internalState(providerType types.ProviderType, state *terraform.State) *types.InternalState {
+	return &types.InternalState{
+		Operator:       string(TerraformOperator),
+		Provider:       providerType,
+		TerraformState: state,
 	}
+}
 
-	_, err = platform.Apply(state.TerraformState, true)
-	return errors.Wrap(err, "unable to deprovision cluster")
+// clusterResources lists, per provider, the types of the resources holding the cluster itself. Replacing them recreates the whole cluster.
+var clusterResources = map[types.ProviderType][]string{
+	types.GCP:      {"google_container_cluster"},
+	types.Gardener: {"gardener_shoot"},
+}
+
+// clusterResource returns the address and the minimal state of the template resource holding the cluster, enough for Terraform to read the rest of its attributes from the live cluster.
+func clusterResource(providerType types.ProviderType, configuration map[string]interface{}) (string, *terraform.ResourceState, error) {
+	name := fmt.Sprintf("%v", configuration["cluster_name"])
+
+	switch providerType {
+	case types.GCP:
+		return "google_container_cluster.gke_cluster", &terraform.ResourceState{
+			Type:     "google_container_cluster",
+			Provider: "provider.google",
+			Primary: &terraform.InstanceState{
+				ID: name,
+				Attributes: map[string]string{
+					"id":       name,
+					"name":     name,
+					"project":  fmt.Sprintf("%v", configuration["project"]),
+					"location": fmt.Sprintf("%v", configuration["location"]),
+				},
+			},
+		}, nil
+	case types.Gardener:
+		namespace := fmt.Sprintf("%v", configuration["namespace"])
+		// the ID format is defined by the Gardener Terraform provider
+		id := fmt.Sprintf("%s/%s", namespace, name)
+		return "gardener_shoot.test_cluster", &terraform.ResourceState{
+			Type:     "gardener_shoot",
+			Provider: "provider.gardener",
+			Primary: &terraform.InstanceState{
+				ID: id,
+				Attributes: map[string]string{
+					"id":                   id,
+					"metadata.#":           "1",
+					"metadata.0.name":      name,
+					"metadata.0.namespace": namespace,
+				},
+			},
+		}, nil
+	default:
+		return "", nil, errors.Errorf("import is not supported for provider %s", providerType)
+	}
+}
+
+// nodePoolResources returns the addresses and the minimal states of the GKE node pools of the template, keyed by address.
+// Pools which do not exist are dropped during the refresh, so that the next apply creates them.
+func nodePoolResources(configuration map[string]interface{}) map[string]*terraform.ResourceState {
+	pools, _ := configuration["node_pools"].([]types.NodePool)
+	cluster := fmt.Sprintf("%v", configuration["cluster_name"])
+	location := fmt.Sprintf("%v", configuration["location"])
+
+	resources := make(map[string]*terraform.ResourceState, len(pools))
+	for _, pool := range pools {
+		// the ID format is defined by the Google Terraform provider
+		id := fmt.Sprintf("%s/%s/%s", location, cluster, pool.Name)
+		resources["google_container_node_pool."+pool.Name] = &terraform.ResourceState{
+			Type:     "google_container_node_pool",
+			Provider: "provider.google",
+			Primary: &terraform.InstanceState{
+				ID: id,
+				Attributes: map[string]string{
+					"id":       id,
+					"name":     pool.Name,
+					"cluster":  cluster,
+					"location": location,
+					"project":  fmt.Sprintf("%v", configuration["project"]),
+				},
+			},
+		}
+	}
+	return resources
+}
+
+// checkReplacement returns a ReplacementError if the plan destroys or replaces any of the resources holding the cluster.
+func checkReplacement(providerType types.ProviderType, plan *types.Plan) error {
+	var replaced []types.ResourceChange
+	for _, r := range plan.Resources {
+		if r.Change != types.ChangeReplace && r.Change != types.ChangeDestroy {
+			continue
+		}
+		for _, resourceType := range clusterResources[providerType] {
+			if r.Type == resourceType {
+				replaced = append(replaced, r)
+			}
+		}
+	}
+
+	if len(replaced) > 0 {
+		return &types.ReplacementError{Resources: replaced}
+	}
+	return nil
 }
 
 func (t *Terraform) newPlatform(providerType types.ProviderType, configuration map[string]interface{}) (*terraformClient.Platform, error) {
@@ -223,8 +592,13 @@ func (t *Terraform) newPlatform(providerType types.ProviderType, configuration m
 	switch providerType {
 	case types.GCP:
 		resourceProvider = google.Provider()
-		clusterTemplate = gcpClusterTemplate
 		providerName = "google"
+
+		expTemplate, err := expandClusterTemplate("gcpCluster", gcpClusterTemplate, configuration)
+		if err != nil {
+			return nil, err
+		}
+		clusterTemplate = expTemplate
 	case types.AWS:
 		//resourceProvider = aws.Provider()
 		//clusterTemplate = awsClusterTemplate
@@ -239,7 +613,7 @@ func (t *Terraform) newPlatform(providerType types.ProviderType, configuration m
 		resourceProvider = gardener.Provider()
 		providerName = "gardener"
 
-		expTemplate, err := expandGardenerClusterTemplate(configuration)
+		expTemplate, err := expandClusterTemplate("gardenerCluster", gardenerClusterTemplate, configuration)
 		if err != nil {
 			return nil, err
 		}
@@ -257,23 +631,133 @@ func (t *Terraform) newPlatform(providerType types.ProviderType, configuration m
 	return platform, nil
 }
 
-func expandGardenerClusterTemplate(config map[string]interface{}) (string, error) {
+// sensitiveValue replaces the values of sensitive attributes in plans.
+const sensitiveValue = "<sensitive>"
+
+// convertDiff turns a Terraform diff into a Hydroform plan. Resources without changes are left out.
+func convertDiff(diff *terraform.Diff) (*types.Plan, error) {
+	plan := &types.Plan{}
+	if diff == nil {
+		return plan, nil
+	}
 
-	funcs := template.FuncMap{
-		"seq": func(n int) []int {
-			r := make([]int, n)
+	for _, module := range diff.Modules {
+		prefix := modulePrefix(module.Path)
 
-			for i := 0; i < n; i++ {
-				r[i] = i
+		for key, instance := range module.Resources {
+			change, ok := changeType(instance)
+			if !ok {
+				continue
 			}
-			return r
-		},
+
+			resourceKey, err := terraform.ParseResourceStateKey(key)
+			if err != nil {
+				return nil, errors.Wrap(err, "unable to read the plan")
+			}
+
+			resource := types.ResourceChange{
+				Address: prefix + key,
+				Type:    resourceKey.Type,
+				Name:    resourceKey.Name,
+				Change:  change,
+			}
+			for name, attr := range instance.CopyAttributes() {
+				a := types.AttributeChange{
+					Name:                name,
+					Old:                 attr.Old,
+					New:                 attr.New,
+					Computed:            attr.NewComputed,
+					Sensitive:           attr.Sensitive,
+					RequiresReplacement: attr.RequiresNew,
+				}
+				if a.Sensitive {
+					a.Old, a.New = sensitiveValue, sensitiveValue
+				}
+				resource.Attributes = append(resource.Attributes, a)
+			}
+			sort.Slice(resource.Attributes, func(i, j int) bool { return resource.Attributes[i].Name < resource.Attributes[j].Name })
+
+			plan.Resources = append(plan.Resources, resource)
+		}
 	}
+	sort.Slice(plan.Resources, func(i, j int) bool { return plan.Resources[i].Address < plan.Resources[j].Address })
+
+	return plan, nil
+}
 
-	t := template.Must(template.New("gardenerCluster").Funcs(funcs).Parse(gardenerClusterTemplate))
+// changeType converts the change of a resource diff. It returns false if the resource does not change.
+func changeType(instance *terraform.InstanceDiff) (types.ChangeType, bool) {
+	switch instance.ChangeType() {
+	case terraform.DiffCreate:
+		return types.ChangeCreate, true
+	case terraform.DiffUpdate:
+		return types.ChangeUpdate, true
+	case terraform.DiffDestroy:
+		return types.ChangeDestroy, true
+	case terraform.DiffDestroyCreate:
+		return types.ChangeReplace, true
+	default:
+		return "", false
+	}
+}
+
+// templateFuncs are the functions the cluster templates can call.
+var templateFuncs = template.FuncMap{
+	"quote":  quote,
+	"hclMap": hclMap,
+	"hclList": func(values []string) string {
+		quoted := make([]string, 0, len(values))
+		for _, v := range values {
+			quoted = append(quoted, quote(v))
+		}
+		return "[" + strings.Join(quoted, ", ") + "]"
+	},
+	// zones returns the zones of all node pools, sorted and without duplicates
+	"zones": func(pools []types.NodePool) []string {
+		var zones []string
+		seen := map[string]bool{}
+		for _, pool := range pools {
+			for _, zone := range pool.Zones {
+				if !seen[zone] {
+					seen[zone] = true
+					zones = append(zones, zone)
+				}
+			}
+		}
+		sort.Strings(zones)
+		return zones
+	},
+}
+
+// quote turns a value into an HCL string literal, which Terraform does not interpolate.
+func quote(value interface{}) string {
+	return strings.Replace(strconv.Quote(fmt.Sprint(value)), "${", "$${", -1)
+}
+
+// hclMap turns a map into an HCL map literal with sorted keys.
+func hclMap(m map[string]string) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	entries := make([]string, 0, len(m))
+	for _, k := range keys {
+		entries = append(entries, fmt.Sprintf("%s = %s", quote(k), quote(m[k])))
+	}
+	return "{" + strings.Join(entries, ", ") + "}"
+}
+
+// expandClusterTemplate executes the Go template directives of a cluster template with the configuration, which yields the Terraform configuration of the cluster.
+func expandClusterTemplate(name, clusterTemplate string, config map[string]interface{}) (string, error) {
+	t, err := template.New(name).Funcs(templateFuncs).Parse(clusterTemplate)
+	if err != nil {
+		return "", err
+	}
 	s := &strings.Builder{}
 	if err := t.Execute(s, config); err != nil {
-		return "", err
+		return "", errors.Wrapf(err, "unable to expand the %s template", name)
 	}
 	return s.String(), nil
 }
@@ -0,0 +1,384 @@
+package operator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	gardener_core "github.com/gardener/gardener/pkg/apis/core/v1alpha1"
+	gardener_types "github.com/gardener/gardener/pkg/apis/garden/v1beta1"
+	"github.com/hashicorp/terraform/config"
+	"github.com/hashicorp/terraform/terraform"
+	"github.com/kyma-incubator/hydroform/types"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestConvertDiff(t *testing.T) {
+	diff := &terraform.Diff{
+		Modules: []*terraform.ModuleDiff{
+			{
+				Path: []string{"root"},
+				Resources: map[string]*terraform.InstanceDiff{
+					"google_container_cluster.gke_cluster": {
+						// Terraform marks existing resources that require a new one for destruction
+						Destroy: true,
+						Attributes: map[string]*terraform.ResourceAttrDiff{
+							"node_config.0.machine_type": {Old: "n1-standard-2", New: "n1-standard-4", RequiresNew: true},
+							"initial_node_count":         {Old: "2", New: "3"},
+						},
+					},
+					"google_container_node_pool.pool": {
+						Attributes: map[string]*terraform.ResourceAttrDiff{
+							"node_count": {Old: "2", New: "3"},
+							"password":   {Old: "secret", New: "other-secret", Sensitive: true},
+						},
+					},
+					"null_resource.gone": {
+						Destroy: true,
+					},
+					"null_resource.unchanged": {},
+				},
+			},
+			{
+				Path: []string{"root", "network"},
+				Resources: map[string]*terraform.InstanceDiff{
+					"google_compute_network.vpc": {
+						Attributes: map[string]*terraform.ResourceAttrDiff{
+							"name":      {Old: "", New: "vpc", RequiresNew: true},
+							"self_link": {NewComputed: true},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	plan, err := convertDiff(diff)
+	require.NoError(t, err)
+	require.Len(t, plan.Resources, 4, "Resources without changes should be left out")
+
+	cluster := plan.Resources[0]
+	require.Equal(t, "google_container_cluster.gke_cluster", cluster.Address)
+	require.Equal(t, "google_container_cluster", cluster.Type)
+	require.Equal(t, "gke_cluster", cluster.Name)
+	require.Equal(t, types.ChangeReplace, cluster.Change)
+	require.Equal(t, []types.AttributeChange{
+		{Name: "initial_node_count", Old: "2", New: "3"},
+		{Name: "node_config.0.machine_type", Old: "n1-standard-2", New: "n1-standard-4", RequiresReplacement: true},
+	}, cluster.Attributes)
+
+	pool := plan.Resources[1]
+	require.Equal(t, types.ChangeUpdate, pool.Change)
+	require.Equal(t, types.AttributeChange{Name: "password", Old: sensitiveValue, New: sensitiveValue, Sensitive: true}, pool.Attributes[1], "Sensitive values should be hidden")
+
+	network := plan.Resources[2]
+	require.Equal(t, "module.network.google_compute_network.vpc", network.Address)
+	require.Equal(t, types.ChangeCreate, network.Change)
+	require.True(t, network.Attributes[1].Computed)
+
+	require.Equal(t, types.ChangeDestroy, plan.Resources[3].Change)
+	require.Len(t, plan.ResourcesWith(types.ChangeDestroy), 1)
+
+	plan, err = convertDiff(nil)
+	require.NoError(t, err)
+	require.True(t, plan.Empty())
+}
+
+func TestCheckReplacement(t *testing.T) {
+	plan := &types.Plan{
+		Resources: []types.ResourceChange{
+			{Address: "google_container_cluster.gke_cluster", Type: "google_container_cluster", Change: types.ChangeUpdate},
+			{Address: "null_resource.other", Type: "null_resource", Change: types.ChangeReplace},
+		},
+	}
+	require.NoError(t, checkReplacement(types.GCP, plan), "Updating the cluster in place should be allowed")
+
+	plan.Resources[0].Change = types.ChangeReplace
+	plan.Resources[0].Attributes = []types.AttributeChange{
+		{Name: "location", Old: "europe-west3-a", New: "europe-west3-b", RequiresReplacement: true},
+	}
+	err := checkReplacement(types.GCP, plan)
+	require.Error(t, err, "Replacing the cluster should be refused")
+	replacement, ok := err.(*types.ReplacementError)
+	require.True(t, ok, "The error should be a ReplacementError")
+	require.Len(t, replacement.Resources, 1)
+	require.Contains(t, err.Error(), "location")
+
+	require.NoError(t, checkReplacement(types.Gardener, plan), "Only the resources of the given provider should be checked")
+}
+
+func TestClusterResource(t *testing.T) {
+	address, resource, err := clusterResource(types.GCP, map[string]interface{}{
+		"cluster_name": "hydro-cluster",
+		"project":      "my-project",
+		"location":     "europe-west3",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "google_container_cluster.gke_cluster", address)
+	require.Equal(t, "hydro-cluster", resource.Primary.ID, "GKE clusters should be identified by name")
+	require.Equal(t, "europe-west3", resource.Primary.Attributes["location"])
+	require.Equal(t, "my-project", resource.Primary.Attributes["project"])
+
+	address, resource, err = clusterResource(types.Gardener, map[string]interface{}{
+		"cluster_name": "hydro-cluster",
+		"namespace":    "garden-my-project",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "gardener_shoot.test_cluster", address)
+	require.Equal(t, "garden-my-project/hydro-cluster", resource.Primary.ID, "Shoots should be identified by namespace and name")
+
+	_, _, err = clusterResource(types.AWS, map[string]interface{}{})
+	require.Error(t, err, "Import should not be supported for providers without templates")
+}
+
+func TestNodePoolResources(t *testing.T) {
+	resources := nodePoolResources(map[string]interface{}{
+		"cluster_name": "hydro-cluster",
+		"project":      "my-project",
+		"location":     "europe-west3",
+		"node_pools":   []types.NodePool{{Name: "cpu"}, {Name: "gpu"}},
+	})
+	require.Len(t, resources, 2)
+	pool := resources["google_container_node_pool.gpu"]
+	require.NotNil(t, pool, "Every node pool of the template should be seeded")
+	require.Equal(t, "europe-west3/hydro-cluster/gpu", pool.Primary.ID, "Node pools should be identified by location, cluster and name")
+	require.Equal(t, "hydro-cluster", pool.Primary.Attributes["cluster"])
+	require.Equal(t, "my-project", pool.Primary.Attributes["project"])
+
+	require.Empty(t, nodePoolResources(map[string]interface{}{"cluster_name": "hydro-cluster"}), "Clusters without node pools should only seed the cluster")
+}
+
+func TestImportPlansNoChanges(t *testing.T) {
+	var mu sync.Mutex
+	var shoot *gardener_types.Shoot
+	// the fake Gardener API sets what Gardener adds to a created shoot
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/apis/garden.sapcloud.io/v1beta1/namespaces/garden-my-project/shoots":
+			shoot = &gardener_types.Shoot{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(shoot))
+			shoot.UID = "7f9e3a52-5b5e-4a8d-9c43-0d1b2f6e8a10"
+			shoot.SelfLink = r.URL.Path + "/" + shoot.Name
+			shoot.Generation = 1
+			shoot.Annotations = map[string]string{"garden.sapcloud.io/createdBy": "hydroform"}
+			domain := "hydro-cluster.my-project.shoot.example.com"
+			shoot.Spec.DNS.Domain = &domain
+			for i, worker := range shoot.Spec.Cloud.GCP.Workers {
+				if worker.MaxSurge == nil {
+					surge := intstr.FromInt(1)
+					shoot.Spec.Cloud.GCP.Workers[i].MaxSurge = &surge
+				}
+			}
+			shoot.Status = gardener_types.ShootStatus{
+				ObservedGeneration: 1,
+				TechnicalID:        "shoot--my-project--hydro-cluster",
+				LastOperation:      &gardener_core.LastOperation{State: gardener_core.LastOperationStateSucceeded},
+			}
+			w.WriteHeader(http.StatusCreated)
+			require.NoError(t, json.NewEncoder(w).Encode(shoot))
+		case r.Method == http.MethodGet && r.URL.Path == "/apis/garden.sapcloud.io/v1beta1/namespaces/garden-my-project/shoots/hydro-cluster" && shoot != nil:
+			require.NoError(t, json.NewEncoder(w).Encode(shoot))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"kind": "Status", "apiVersion": "v1", "status": "Failure", "reason": "NotFound", "code": 404}`)
+		}
+	}))
+	defer server.Close()
+
+	kubeconfig, err := ioutil.TempFile("", "kubeconfig")
+	require.NoError(t, err)
+	defer os.Remove(kubeconfig.Name())
+	_, err = fmt.Fprintf(kubeconfig, `apiVersion: v1
+kind: Config
+clusters:
+- name: garden
+  cluster:
+    server: %s
+contexts:
+- name: garden
+  context:
+    cluster: garden
+current-context: garden
+`, server.URL)
+	require.NoError(t, err)
+	require.NoError(t, kubeconfig.Close())
+
+	configuration := map[string]interface{}{
+		"cluster_name":          "hydro-cluster",
+		"credentials_file_path": kubeconfig.Name(),
+		"node_count":            2,
+		"machine_type":          "n1-standard-4",
+		"disk_size":             30,
+		"disk_type":             "pd-standard",
+		"kubernetes_version":    "1.15.4",
+		"location":              "europe-west3",
+		"namespace":             "garden-my-project",
+		"labels":                map[string]string{},
+		"annotations":           map[string]string{},
+		"target_provider":       "gcp",
+		"target_profile":        "gcp",
+		"target_seed":           "gcp-eu1",
+		"target_secret":         "secret-name",
+		"zone":                  "europe-west3-b",
+		"workercidr":            "10.250.0.0/19",
+		"autoscaler_min":        2,
+		"autoscaler_max":        2,
+	}
+
+	tf := &Terraform{}
+	created, err := tf.Create(context.Background(), types.Gardener, configuration)
+	require.NoError(t, err)
+	plan, err := tf.Plan(context.Background(), created.InternalState, types.Gardener, configuration, false)
+	require.NoError(t, err)
+	require.Empty(t, plan.Resources, "A created shoot should not have changes")
+
+	imported, err := tf.Import(context.Background(), types.Gardener, configuration)
+	require.NoError(t, err)
+	require.Equal(t, created.Outputs, imported.Outputs, "The outputs of an imported shoot should be those of a created one")
+	require.Equal(t, "hydro-cluster.my-project.shoot.example.com", imported.Outputs["domain"])
+
+	plan, err = tf.Plan(context.Background(), imported.InternalState, types.Gardener, configuration, false)
+	require.NoError(t, err)
+	require.Empty(t, plan.Resources, "An imported shoot should not have changes")
+}
+
+func TestTemplates(t *testing.T) {
+	pools := []types.NodePool{
+		{Name: "cpu", MachineType: "n1-standard-4", NodeCount: 2, Zones: []string{"europe-west3-b", "europe-west3-a"}},
+		{
+			Name:        "gpu",
+			MachineType: "n1-highmem-8",
+			NodeCount:   1,
+			Autoscaling: &types.Autoscaling{Min: 1, Max: 4},
+			DiskSizeGB:  100,
+			DiskType:    "pd-ssd",
+			Labels:      map[string]string{"hydroform.kyma-project.io/pool": "gpu", "team": "${hydro}"},
+			Taints:      []types.Taint{{Key: "gpu", Effect: types.NoSchedule}, {Key: "team", Value: "hydro", Effect: types.NoExecute}},
+			Zones:       []string{"europe-west3-a"},
+		},
+	}
+
+	networking := &types.Networking{
+		Network:     "hydro-network",
+		Subnetwork:  "hydro-nodes",
+		NodeCIDR:    "10.250.0.0/19",
+		PodCIDR:     "100.96.0.0/11",
+		ServiceCIDR: "100.64.0.0/13",
+	}
+
+	for name, test := range map[string]struct {
+		template string
+		config   map[string]interface{}
+		contains []string
+	}{
+		"gcp": {
+			template: gcpClusterTemplate,
+			config:   map[string]interface{}{},
+			contains: []string{`initial_node_count = "${var.node_count}"`},
+		},
+		"gcp with node pools": {
+			template: gcpClusterTemplate,
+			config:   map[string]interface{}{"node_pools": pools},
+			contains: []string{
+				"remove_default_node_pool = true",
+				`resource "google_container_node_pool" "gpu"`,
+				"node_count = 2",
+				"min_node_count = 1",
+				"max_node_count = 4",
+				`disk_type    = "pd-ssd"`,
+				`labels       = {"hydroform.kyma-project.io/pool" = "gpu", "team" = "$${hydro}"}`,
+			},
+		},
+		"gcp with networking": {
+			template: gcpClusterTemplate,
+			config:   map[string]interface{}{"networking": networking},
+			contains: []string{
+				`network            = "hydro-network"`,
+				"create_subnetwork        = true",
+				`subnetwork_name          = "hydro-nodes"`,
+				`node_ipv4_cidr_block     = "10.250.0.0/19"`,
+				`cluster_ipv4_cidr_block  = "100.96.0.0/11"`,
+				`services_ipv4_cidr_block = "100.64.0.0/13"`,
+			},
+		},
+		"gcp with existing subnetwork": {
+			template: gcpClusterTemplate,
+			config:   map[string]interface{}{"networking": &types.Networking{Subnetwork: "hydro-nodes"}},
+			contains: []string{`subnetwork         = "hydro-nodes"`},
+		},
+		"gardener": {
+			template: gardenerClusterTemplate,
+			config:   map[string]interface{}{"target_provider": "gcp"},
+			contains: []string{`name            = "cpu-worker-0"`, `zones = ["${var.zone}"]`},
+		},
+		"gardener on azure": {
+			template: gardenerClusterTemplate,
+			config:   map[string]interface{}{"target_provider": "azure", "networking": networking},
+			contains: []string{`nodes    = "10.250.0.0/19"`, `pods     = "100.96.0.0/11"`, `services = "100.64.0.0/13"`},
+		},
+		"gardener on aws with node pools": {
+			template: gardenerClusterTemplate,
+			config:   map[string]interface{}{"target_provider": "aws", "node_pools": pools},
+			contains: []string{
+				`name            = "gpu"`,
+				"auto_scaler_max = 2",
+				"auto_scaler_max = 4",
+				"max_surge       = 1",
+				`volume_size     = "100Gi"`,
+				`volume_type     = "${var.disk_type}"`,
+				`operator = "Exists"`,
+				`operator = "Equal"`,
+				`zones = ["europe-west3-a", "europe-west3-b"]`,
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			template, err := expandClusterTemplate(name, test.template, test.config)
+			require.NoError(t, err)
+			for _, s := range test.contains {
+				require.Contains(t, template, s)
+			}
+
+			dir, err := ioutil.TempDir("", "hydroform")
+			require.NoError(t, err)
+			defer os.RemoveAll(dir)
+			require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "main.tf"), []byte(template), 0600))
+
+			cfg, err := config.LoadDir(dir)
+			require.NoError(t, err, "The template should be parsed")
+			require.NoError(t, cfg.Validate().Err(), "The template should be valid")
+		})
+	}
+}
+
+func TestClusterInfo(t *testing.T) {
+	state := terraform.NewState()
+	state.RootModule().Outputs = map[string]*terraform.OutputState{
+		"endpoint":               {Type: "string", Value: "35.1.2.3"},
+		"cluster_ca_certificate": {Type: "string", Value: "TXkgY2VydA=="},
+		"instance_group_urls":    {Type: "list", Value: []interface{}{"https://instance-group.fake"}},
+	}
+
+	info, err := clusterInfo(types.GCP, state)
+	require.NoError(t, err)
+	require.Equal(t, "35.1.2.3", info.Endpoint)
+	require.Equal(t, []byte("My cert"), info.CertificateAuthorityData)
+	require.Equal(t, map[string]interface{}{
+		"endpoint":               "35.1.2.3",
+		"cluster_ca_certificate": "TXkgY2VydA==",
+		"instance_group_urls":    []interface{}{"https://instance-group.fake"},
+	}, info.Outputs, "Every output should be exposed")
+	require.Equal(t, "terraform", info.InternalState.Operator)
+	require.Equal(t, types.GCP, info.InternalState.Provider)
+}
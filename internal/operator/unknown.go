@@ -1,6 +1,7 @@
 package operator
 
 import (
+	"context"
 	"errors"
 
 	"github.com/kyma-incubator/hydroform/types"
@@ -11,11 +12,31 @@ type Unknown struct {
 }
 
 // Create returns an error if the operator is unknown.
-func (u *Unknown) Create(providerType types.ProviderType, configuration map[string]interface{}) (*types.ClusterInfo, error) {
+func (u *Unknown) Create(ctx context.Context, providerType types.ProviderType, configuration map[string]interface{}) (*types.ClusterInfo, error) {
 	return nil, errors.New("unknown operator")
 }
 
 // Delete returns an error if the operator is unknown.
-func (u *Unknown) Delete(state *types.InternalState, providerType types.ProviderType, configuration map[string]interface{}) error {
+func (u *Unknown) Delete(ctx context.Context, state *types.InternalState, providerType types.ProviderType, configuration map[string]interface{}) error {
 	return errors.New("unknown operator")
 }
+
+// Plan returns an error if the operator is unknown.
+func (u *Unknown) Plan(ctx context.Context, state *types.InternalState, providerType types.ProviderType, configuration map[string]interface{}, destroy bool) (*types.Plan, error) {
+	return nil, errors.New("unknown operator")
+}
+
+// Update returns an error if the operator is unknown.
+func (u *Unknown) Update(ctx context.Context, state *types.InternalState, providerType types.ProviderType, configuration map[string]interface{}, allowReplacement bool) (*types.ClusterInfo, error) {
+	return nil, errors.New("unknown operator")
+}
+
+// Import returns an error if the operator is unknown.
+func (u *Unknown) Import(ctx context.Context, providerType types.ProviderType, configuration map[string]interface{}) (*types.ClusterInfo, error) {
+	return nil, errors.New("unknown operator")
+}
+
+// Drift returns an error if the operator is unknown.
+func (u *Unknown) Drift(ctx context.Context, state *types.InternalState, providerType types.ProviderType, configuration map[string]interface{}) (*types.DriftReport, error) {
+	return nil, errors.New("unknown operator")
+}
@@ -1,6 +1,7 @@
 package terraform
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -63,40 +64,81 @@ func (p *Platform) AddProvisioner(name string, provisioner terraform.ResourcePro
 }
 
 // Apply brings the platform to the desired state. It'll destroy the platform
-// when `destroy` is `true`.
-func (p *Platform) Apply(state *State, destroy bool) (*State, error) {
-	ctx, err := p.newContext(state, destroy)
+// when `destroy` is `true`. If the given context is done before Apply returns,
+// the running Terraform operation is stopped and the context error is returned
+// along with the state reached so far.
+func (p *Platform) Apply(ctx context.Context, state *State, destroy bool) (*State, error) {
+	return p.apply(ctx, state, destroy, nil)
+}
+
+// ApplyApproved brings the platform to the desired state like Apply, but
+// only if the approve function accepts the execution plan. The plan that is
+// approved is exactly the one that gets applied. If approve returns an error,
+// nothing is changed and the error is returned along with the given state.
+func (p *Platform) ApplyApproved(ctx context.Context, state *State, approve func(plan *terraform.Plan) error) (*State, error) {
+	return p.apply(ctx, state, false, approve)
+}
+
+func (p *Platform) apply(ctx context.Context, state *State, destroy bool, approve func(plan *terraform.Plan) error) (*State, error) {
+	tfCtx, err := p.newContext(state, destroy)
 	if err != nil {
 		return state, err
 	}
 
-	if _, err := ctx.Refresh(); err != nil {
+	stop := stopOnDone(ctx, tfCtx)
+	defer stop()
+
+	if _, err := tfCtx.Refresh(); err != nil {
+		return state, err
+	}
+	if err := ctx.Err(); err != nil {
 		return state, err
 	}
 
-	if _, err := ctx.Plan(); err != nil {
+	plan, err := tfCtx.Plan()
+	if err != nil {
+		return state, err
+	}
+	if err := ctx.Err(); err != nil {
 		return state, err
 	}
+	if approve != nil {
+		if err := approve(plan); err != nil {
+			return state, err
+		}
+	}
 
-	_, err = ctx.Apply()
-	state = ctx.State()
+	_, err = tfCtx.Apply()
+	state = tfCtx.State()
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return state, ctxErr
+	}
 
 	return state, err
 }
 
 // Plan returns execution plan for an existing configuration to apply to the
 // platform.
-func (p *Platform) Plan(state *State, destroy bool) (*terraform.Plan, error) {
-	ctx, err := p.newContext(state, destroy)
+func (p *Platform) Plan(ctx context.Context, state *State, destroy bool) (*terraform.Plan, error) {
+	tfCtx, err := p.newContext(state, destroy)
 	if err != nil {
 		return nil, err
 	}
 
-	if _, err := ctx.Refresh(); err != nil {
+	stop := stopOnDone(ctx, tfCtx)
+	defer stop()
+
+	if _, err := tfCtx.Refresh(); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
 		return nil, err
 	}
 
-	plan, err := ctx.Plan()
+	plan, err := tfCtx.Plan()
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, ctxErr
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -104,6 +146,48 @@ func (p *Platform) Plan(state *State, destroy bool) (*terraform.Plan, error) {
 	return plan, nil
 }
 
+// Refresh updates the state with the real-world attributes of the managed
+// resources, without changing any of them. Resources that do not exist anymore
+// are removed from the returned state.
+func (p *Platform) Refresh(ctx context.Context, state *State) (*State, error) {
+	tfCtx, err := p.newContext(state, false)
+	if err != nil {
+		return state, err
+	}
+
+	stop := stopOnDone(ctx, tfCtx)
+	defer stop()
+
+	refreshed, err := tfCtx.Refresh()
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return state, ctxErr
+	}
+	if err != nil {
+		return state, err
+	}
+
+	return refreshed, nil
+}
+
+// stopOnDone stops the running Terraform operation as soon as ctx is done.
+// The returned function releases the watcher and must be called once the
+// Terraform context is not used anymore.
+func stopOnDone(ctx context.Context, tfCtx *terraform.Context) func() {
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			// Stop blocks until the running operation returns
+			tfCtx.Stop()
+		case <-done:
+		}
+	}()
+
+	return func() {
+		close(done)
+	}
+}
+
 // newContext creates the Terraform context or configuration
 func (p *Platform) newContext(state *State, destroy bool) (*terraform.Context, error) {
 	module, err := p.module()
@@ -223,3 +307,13 @@ func (p *Platform) Var(name string, value interface{}) *Platform {
 
 	return p
 }
+
+// ReadState reads a Terraform state in the format of Terraform state files
+func ReadState(src io.Reader) (*State, error) {
+	return terraform.ReadState(src)
+}
+
+// WriteState writes a Terraform state in the format of Terraform state files
+func WriteState(state *State, dst io.Writer) error {
+	return terraform.WriteState(state, dst)
+}
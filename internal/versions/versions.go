@@ -0,0 +1,111 @@
+// Package versions resolves the Kubernetes version of a cluster specification, which can be an alias, to a version the provider supports.
+package versions
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kyma-incubator/hydroform/types"
+)
+
+const (
+	// Latest is resolved to the newest supported version.
+	Latest = "latest"
+	// Default is resolved to the version the provider uses if none is given, or to the newest supported version if the provider has no default.
+	Default = "default"
+)
+
+// Supported lists the Kubernetes versions a provider offers for new clusters.
+type Supported struct {
+	// Versions are the concrete versions, for example `1.15.4` or `1.14.8-gke.12`.
+	Versions []string
+	// Default is the version the provider uses if none is given. It can be empty.
+	Default string
+}
+
+// Resolve returns the supported version the given version stands for. Besides the concrete versions, it accepts the Latest and Default aliases, and version prefixes such as `1.15` or `1.14.8`, which are resolved to the newest matching version.
+// It returns false if no supported version matches.
+func (s *Supported) Resolve(version string) (string, bool) {
+	switch version {
+	case Default:
+		if s.Default != "" {
+			return s.Default, true
+		}
+		return s.newest(func(string) bool { return true })
+	case Latest:
+		return s.newest(func(string) bool { return true })
+	}
+	return s.newest(func(v string) bool {
+		return Matches(version, v)
+	})
+}
+
+// Matches reports whether the given version stands for the concrete one, without checking that it is the newest match. The Latest and Default aliases stand for any version.
+// It is used to keep the version a cluster was resolved to as long as its specification still allows it, instead of upgrading the cluster whenever a newer version is released.
+func Matches(version, concrete string) bool {
+	switch version {
+	case Default, Latest:
+		return true
+	}
+	return concrete == version || strings.HasPrefix(concrete, version+".") || strings.HasPrefix(concrete, version+"-")
+}
+
+// FieldError reports that the version of the cluster is not supported, and lists the supported ones.
+func (s *Supported) FieldError(version string) types.FieldError {
+	versions := append([]string(nil), s.Versions...)
+	sort.Slice(versions, func(i, j int) bool { return less(versions[j], versions[i]) })
+	return types.FieldError{
+		Field:  "Cluster.KubernetesVersion",
+		Reason: fmt.Sprintf("is not supported, use %s, %s, a minor version such as 1.15, or one of: %s", Latest, Default, strings.Join(versions, ", ")),
+		Value:  version,
+	}
+}
+
+func (s *Supported) newest(match func(v string) bool) (string, bool) {
+	var newest string
+	for _, v := range s.Versions {
+		if match(v) && (newest == "" || less(newest, v)) {
+			newest = v
+		}
+	}
+	return newest, newest != ""
+}
+
+// less compares the numbers of two versions in order, so that `1.9.7` is older than `1.14.8-gke.12`.
+func less(a, b string) bool {
+	as, bs := numbers(a), numbers(b)
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if as[i] != bs[i] {
+			return as[i] < bs[i]
+		}
+	}
+	return len(as) < len(bs)
+}
+
+// numbers returns the numbers a version is made of, for example 1, 14, 8, and 12 for `1.14.8-gke.12`.
+func numbers(version string) []int {
+	var ns []int
+	for _, field := range strings.FieldsFunc(version, func(r rune) bool { return r < '0' || r > '9' }) {
+		n, _ := strconv.Atoi(field)
+		ns = append(ns, n)
+	}
+	return ns
+}
+
+// Stored returns the version recorded in the state of the cluster, if the Kubernetes version of the cluster specification still matches it. See Store.
+func Stored(cluster *types.Cluster) (string, bool) {
+	if cluster.ClusterInfo == nil || cluster.ClusterInfo.InternalState == nil {
+		return "", false
+	}
+	stored := cluster.ClusterInfo.InternalState.KubernetesVersion
+	return stored, stored != "" && Matches(cluster.KubernetesVersion, stored)
+}
+
+// Store records the resolved Kubernetes version of the cluster in its state, so that later operations keep it instead of resolving the specification again.
+func Store(cluster *types.Cluster) {
+	if cluster.ClusterInfo != nil && cluster.ClusterInfo.InternalState != nil {
+		cluster.ClusterInfo.InternalState.KubernetesVersion = cluster.KubernetesVersion
+	}
+}
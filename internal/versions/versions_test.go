@@ -0,0 +1,75 @@
+package versions
+
+import (
+	"testing"
+
+	"github.com/kyma-incubator/hydroform/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolve(t *testing.T) {
+	supported := &Supported{
+		Versions: []string{"1.13.11-gke.14", "1.14.8-gke.12", "1.14.10-gke.17", "1.9.7-gke.11"},
+		Default:  "1.13.11-gke.14",
+	}
+	for version, expected := range map[string]string{
+		"1.14":           "1.14.10-gke.17",
+		"1.14.8":         "1.14.8-gke.12",
+		"1.14.8-gke.12":  "1.14.8-gke.12",
+		"1.9":            "1.9.7-gke.11",
+		Latest:           "1.14.10-gke.17",
+		Default:          "1.13.11-gke.14",
+		"1.13.11-gke.14": "1.13.11-gke.14",
+	} {
+		resolved, ok := supported.Resolve(version)
+		require.True(t, ok, "Version %s should be supported", version)
+		require.Equal(t, expected, resolved, "Version %s should be resolved to the newest matching version", version)
+	}
+
+	for _, version := range []string{"1.1", "1.15", "1.14.9", "1.14.8-gke.1", "stable"} {
+		_, ok := supported.Resolve(version)
+		require.False(t, ok, "Version %s should not be supported", version)
+	}
+
+	supported.Default = ""
+	resolved, ok := supported.Resolve(Default)
+	require.True(t, ok)
+	require.Equal(t, "1.14.10-gke.17", resolved, "The default should be the newest version if the provider has none")
+
+	fieldErr := supported.FieldError("1.15")
+	require.Equal(t, "Cluster.KubernetesVersion", fieldErr.Field)
+	require.Equal(t, "is not supported, use latest, default, a minor version such as 1.15, or one of: 1.14.10-gke.17, 1.14.8-gke.12, 1.13.11-gke.14, 1.9.7-gke.11", fieldErr.Reason)
+
+	_, ok = (&Supported{}).Resolve(Latest)
+	require.False(t, ok, "Nothing should be resolved without supported versions")
+}
+
+func TestMatches(t *testing.T) {
+	for _, version := range []string{Latest, Default, "1.14", "1.14.8", "1.14.8-gke.12"} {
+		require.True(t, Matches(version, "1.14.8-gke.12"), "Version %s should stand for 1.14.8-gke.12", version)
+	}
+	for _, version := range []string{"1.1", "1.15", "1.14.8-gke.1", "1.14.80"} {
+		require.False(t, Matches(version, "1.14.8-gke.12"), "Version %s should not stand for 1.14.8-gke.12", version)
+	}
+}
+
+func TestStored(t *testing.T) {
+	cluster := &types.Cluster{KubernetesVersion: Latest}
+	_, ok := Stored(cluster)
+	require.False(t, ok, "Clusters without state should have no stored version")
+
+	cluster.ClusterInfo = &types.ClusterInfo{InternalState: &types.InternalState{}}
+	_, ok = Stored(cluster)
+	require.False(t, ok, "States saved without a version should have no stored version")
+
+	cluster.KubernetesVersion = "1.14.8-gke.12"
+	Store(cluster)
+	cluster.KubernetesVersion = Latest
+	version, ok := Stored(cluster)
+	require.True(t, ok)
+	require.Equal(t, "1.14.8-gke.12", version, "An alias should keep the stored version")
+
+	cluster.KubernetesVersion = "1.15"
+	_, ok = Stored(cluster)
+	require.False(t, ok, "A version which does not match the stored one should be resolved again")
+}
@@ -0,0 +1,152 @@
+package lock
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// FileLocker is a Locker based on lock files on the local filesystem, at `<dir>/<provider>/<project>/<cluster name>.lock`. A lock file is created atomically and holds the description of its holder.
+// Keys whose components contain path separators or are `.` or `..` are rejected, so that the lock files stay in the directory.
+// It only guards against processes sharing the filesystem. A lock file left behind by a crashed process must be removed with ForceUnlock.
+type FileLocker struct {
+	dir string
+}
+
+// NewFileLocker creates a FileLocker keeping the lock files in the given directory.
+func NewFileLocker(dir string) *FileLocker {
+	return &FileLocker{dir: dir}
+}
+
+// Lock creates the lock file of the key. It returns an *ErrLocked if the file already exists.
+func (l *FileLocker) Lock(ctx context.Context, key Key, holder Holder) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := checkPath(key); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(holder)
+	if err != nil {
+		return errors.Wrapf(err, "unable to lock %s", key)
+	}
+
+	path := l.path(key)
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return errors.Wrapf(err, "unable to lock %s", key)
+	}
+
+	// the lock file is written aside and linked in place, so that it never exists without its holder
+	tmp, err := ioutil.TempFile(dir, "."+key.Name)
+	if err != nil {
+		return errors.Wrapf(err, "unable to lock %s", key)
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return errors.Wrapf(err, "unable to lock %s", key)
+	}
+
+	for {
+		err := os.Link(tmp.Name(), path)
+		if err == nil {
+			return nil
+		}
+		if !os.IsExist(err) {
+			return errors.Wrapf(err, "unable to lock %s", key)
+		}
+
+		current, err := l.holder(key)
+		// the lock was released in the meantime, try again
+		if os.IsNotExist(errors.Cause(err)) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		return &ErrLocked{Key: key, Holder: current}
+	}
+}
+
+// Unlock removes the lock file of the key if it was created by the holder.
+func (l *FileLocker) Unlock(ctx context.Context, key Key, holder Holder) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := checkPath(key); err != nil {
+		return err
+	}
+
+	current, err := l.holder(key)
+	if os.IsNotExist(errors.Cause(err)) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if current.ID != holder.ID {
+		return &ErrLocked{Key: key, Holder: current}
+	}
+
+	return l.remove(key)
+}
+
+// ForceUnlock removes the lock file of the key.
+func (l *FileLocker) ForceUnlock(ctx context.Context, key Key) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := checkPath(key); err != nil {
+		return err
+	}
+	return l.remove(key)
+}
+
+func (l *FileLocker) holder(key Key) (Holder, error) {
+	var holder Holder
+	data, err := ioutil.ReadFile(l.path(key))
+	if err != nil {
+		return holder, errors.Wrapf(err, "unable to read the lock of %s", key)
+	}
+	if err := json.Unmarshal(data, &holder); err != nil {
+		return holder, errors.Wrapf(err, "unable to read the lock of %s", key)
+	}
+	return holder, nil
+}
+
+func (l *FileLocker) remove(key Key) error {
+	err := os.Remove(l.path(key))
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "unable to unlock %s", key)
+	}
+	return nil
+}
+
+// checkPath returns an error if a component of the key cannot be used as a file name, as it would make the lock file leave the directory of the locker.
+func checkPath(key Key) error {
+	for _, c := range []struct{ field, value string }{
+		{"provider", string(key.Provider)},
+		{"project", key.Project},
+		{"name", key.Name},
+	} {
+		if c.value == "." || c.value == ".." || strings.ContainsAny(c.value, `/\`) {
+			return fmt.Errorf("unable to lock %s: the %s %q cannot be used in a file name", key, c.field, c.value)
+		}
+	}
+	return nil
+}
+
+func (l *FileLocker) path(key Key) string {
+	return filepath.Join(l.dir, string(key.Provider), key.Project, key.Name+".lock")
+}
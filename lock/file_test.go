@@ -0,0 +1,46 @@
+package lock
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kyma-incubator/hydroform/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileLocker(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hydroform-lock")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	testLocker(t, NewFileLocker(dir))
+}
+
+func TestFileLockerRejectsPaths(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hydroform-lock")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ctx := context.Background()
+	locker := NewFileLocker(filepath.Join(dir, "locks"))
+	holder, err := NewHolder("provision")
+	require.NoError(t, err)
+
+	for _, key := range []Key{
+		{Provider: types.GCP, Project: "..", Name: "hydro-cluster"},
+		{Provider: types.GCP, Project: "my-project", Name: "../../../hydro-cluster"},
+		{Provider: types.GCP, Project: `my\project`, Name: "hydro-cluster"},
+		{Provider: "..", Project: "my-project", Name: "hydro-cluster"},
+	} {
+		require.Error(t, locker.Lock(ctx, key, holder), "Locking %s should fail", key)
+		require.Error(t, locker.Unlock(ctx, key, holder), "Unlocking %s should fail", key)
+		require.Error(t, locker.ForceUnlock(ctx, key), "Force unlocking %s should fail", key)
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	require.Empty(t, files, "No lock file should be created")
+}
@@ -0,0 +1,279 @@
+package lock
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	coordination "k8s.io/client-go/kubernetes/typed/coordination/v1"
+)
+
+const holderAnnotation = "hydroform.kyma-project.io/lock-holder"
+
+// DefaultLeaseDurationSeconds is the LeaseDurationSeconds of the lockers created by NewLeaseLocker.
+const DefaultLeaseDurationSeconds = 60
+
+// LeaseLocker is a Locker based on Kubernetes Leases named `hydroform-<provider>-<project>-<cluster name>-<hash>`, where the hash identifies the key and the rest of the name is shortened and lower-cased as needed to make a valid name. The Lease exists for as long as the lock is held, and its holder identity is the ID of the holder.
+// While the lock is held, the Lease is renewed several times per lease duration, until Unlock is called or the context given to Lock is done. A Lease which was not renewed for longer than its duration is left behind by a crashed process, and is taken over by the next Lock.
+type LeaseLocker struct {
+	// LeaseDurationSeconds is the time a Lease is valid without being renewed. If it is zero, Leases do not expire and are not renewed, so that a Lease left behind by a crashed process must be removed with ForceUnlock.
+	LeaseDurationSeconds int32
+
+	client    kubernetes.Interface
+	namespace string
+
+	mu sync.Mutex
+	// renewals stop the renewal of the Leases held by this locker, keyed by Lease name.
+	renewals map[string]context.CancelFunc
+}
+
+// NewLeaseLocker creates a LeaseLocker keeping the Leases in the given namespace. The Leases last DefaultLeaseDurationSeconds.
+func NewLeaseLocker(client kubernetes.Interface, namespace string) *LeaseLocker {
+	return &LeaseLocker{
+		LeaseDurationSeconds: DefaultLeaseDurationSeconds,
+		client:               client,
+		namespace:            namespace,
+		renewals:             map[string]context.CancelFunc{},
+	}
+}
+
+// Lock creates the Lease of the key, or takes over an expired one. It returns an *ErrLocked if the Lease is held by someone else.
+func (l *LeaseLocker) Lock(ctx context.Context, key Key, holder Holder) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(holder)
+	if err != nil {
+		return errors.Wrapf(err, "unable to lock %s", key)
+	}
+
+	lease := &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      leaseName(key),
+			Namespace: l.namespace,
+		},
+	}
+	l.hold(lease, holder, string(data))
+	_, err = l.leases().Create(lease)
+	if apierrors.IsAlreadyExists(err) {
+		lease, err = l.leases().Get(leaseName(key), metav1.GetOptions{})
+		// the lock was released in the meantime, try again
+		if apierrors.IsNotFound(err) {
+			return l.Lock(ctx, key, holder)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "unable to read the lock of %s", key)
+		}
+		if !expired(lease, time.Now()) {
+			return &ErrLocked{Key: key, Holder: leaseHolder(lease)}
+		}
+
+		previous := leaseHolder(lease)
+		l.hold(lease, holder, string(data))
+		transitions := int32(1)
+		if lease.Spec.LeaseTransitions != nil {
+			transitions += *lease.Spec.LeaseTransitions
+		}
+		lease.Spec.LeaseTransitions = &transitions
+		// the resource version of the expired Lease makes sure only one process takes it over
+		_, err = l.leases().Update(lease)
+		if apierrors.IsConflict(err) || apierrors.IsNotFound(err) {
+			return l.Lock(ctx, key, holder)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "unable to take over the expired lock of %s held by %s", key, previous.Who)
+		}
+	}
+	if err != nil {
+		return errors.Wrapf(err, "unable to lock %s", key)
+	}
+
+	l.startRenewal(ctx, key, holder)
+	return nil
+}
+
+// Unlock deletes the Lease of the key if it is held by the holder.
+func (l *LeaseLocker) Unlock(ctx context.Context, key Key, holder Holder) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	lease, err := l.leases().Get(leaseName(key), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, "unable to read the lock of %s", key)
+	}
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity != holder.ID {
+		return &ErrLocked{Key: key, Holder: leaseHolder(lease)}
+	}
+	l.stopRenewal(lease.Name)
+
+	// the precondition makes sure a Lease recreated by someone else in the meantime is not deleted
+	err = l.leases().Delete(lease.Name, &metav1.DeleteOptions{Preconditions: &metav1.Preconditions{UID: &lease.UID}})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "unable to unlock %s", key)
+	}
+	return nil
+}
+
+// ForceUnlock deletes the Lease of the key.
+func (l *LeaseLocker) ForceUnlock(ctx context.Context, key Key) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	l.stopRenewal(leaseName(key))
+	err := l.leases().Delete(leaseName(key), &metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "unable to unlock %s", key)
+	}
+	return nil
+}
+
+// hold makes the Lease held by the holder from now on.
+func (l *LeaseLocker) hold(lease *coordinationv1.Lease, holder Holder, data string) {
+	now := metav1.NewMicroTime(time.Now())
+	lease.Annotations = map[string]string{holderAnnotation: data}
+	lease.Spec.HolderIdentity = &holder.ID
+	lease.Spec.AcquireTime = &now
+	lease.Spec.RenewTime = &now
+	lease.Spec.LeaseDurationSeconds = nil
+	if l.LeaseDurationSeconds > 0 {
+		duration := l.LeaseDurationSeconds
+		lease.Spec.LeaseDurationSeconds = &duration
+	}
+}
+
+// startRenewal renews the Lease of the key a few times per lease duration, until the renewal is stopped, the context is done, or the Lease is no longer held by the holder.
+func (l *LeaseLocker) startRenewal(ctx context.Context, key Key, holder Holder) {
+	if l.LeaseDurationSeconds <= 0 {
+		return
+	}
+	name := leaseName(key)
+	ctx, cancel := context.WithCancel(ctx)
+	l.mu.Lock()
+	if stop, ok := l.renewals[name]; ok {
+		stop()
+	}
+	l.renewals[name] = cancel
+	l.mu.Unlock()
+
+	interval := time.Duration(l.LeaseDurationSeconds) * time.Second / 3
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			if !l.renew(name, holder) {
+				return
+			}
+		}
+	}()
+}
+
+// renew updates the renew time of the Lease. It returns false once the Lease is no longer held by the holder. Failed updates are retried at the next renewal, before the Lease expires.
+func (l *LeaseLocker) renew(name string, holder Holder) bool {
+	lease, err := l.leases().Get(name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return false
+	}
+	if err != nil {
+		return true
+	}
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity != holder.ID {
+		return false
+	}
+	now := metav1.NewMicroTime(time.Now())
+	lease.Spec.RenewTime = &now
+	_, err = l.leases().Update(lease)
+	return !apierrors.IsNotFound(err)
+}
+
+// stopRenewal stops the renewal of the Lease, if this locker renews it.
+func (l *LeaseLocker) stopRenewal(name string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if stop, ok := l.renewals[name]; ok {
+		stop()
+		delete(l.renewals, name)
+	}
+}
+
+func (l *LeaseLocker) leases() coordination.LeaseInterface {
+	return l.client.CoordinationV1().Leases(l.namespace)
+}
+
+// leaseHolder reads the holder of a Lease. Leases which were not created by a LeaseLocker are described by their holder identity only.
+func leaseHolder(lease *coordinationv1.Lease) Holder {
+	var holder Holder
+	if err := json.Unmarshal([]byte(lease.Annotations[holderAnnotation]), &holder); err == nil {
+		return holder
+	}
+
+	if lease.Spec.HolderIdentity != nil {
+		holder.ID = *lease.Spec.HolderIdentity
+		holder.Who = *lease.Spec.HolderIdentity
+	}
+	if lease.Spec.AcquireTime != nil {
+		holder.Created = lease.Spec.AcquireTime.Time
+	}
+	return holder
+}
+
+// expired reports whether the Lease was not renewed within its duration. Leases without a duration do not expire.
+func expired(lease *coordinationv1.Lease, now time.Time) bool {
+	if lease.Spec.LeaseDurationSeconds == nil || *lease.Spec.LeaseDurationSeconds <= 0 {
+		return false
+	}
+	renewed := lease.Spec.RenewTime
+	if renewed == nil {
+		renewed = lease.Spec.AcquireTime
+	}
+	if renewed == nil {
+		return false
+	}
+	return renewed.Add(time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second).Before(now)
+}
+
+const (
+	// maxLeaseNameLength keeps the Lease names valid DNS-1123 labels.
+	maxLeaseNameLength = 63
+	leaseNamePrefix    = "hydroform-"
+	// leaseHashLength is the number of hex digits of the hash of the key in the Lease name.
+	leaseHashLength = 10
+)
+
+// invalidLeaseNameChars are the characters that cannot be used in a DNS-1123 label.
+var invalidLeaseNameChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// leaseName returns a valid Lease name for the key. The readable part of the name can be the same for different keys, so the name ends with a hash of the key.
+func leaseName(key Key) string {
+	data, _ := json.Marshal(key)
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])[:leaseHashLength]
+
+	readable := strings.ToLower(fmt.Sprintf("%s-%s-%s", key.Provider, key.Project, key.Name))
+	readable = invalidLeaseNameChars.ReplaceAllString(readable, "-")
+	if max := maxLeaseNameLength - len(leaseNamePrefix) - len(hash) - 1; len(readable) > max {
+		readable = readable[:max]
+	}
+	return leaseNamePrefix + readable + "-" + hash
+}
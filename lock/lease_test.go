@@ -0,0 +1,113 @@
+package lock
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kyma-incubator/hydroform/types"
+	"github.com/stretchr/testify/require"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestLeaseLocker(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	testLocker(t, NewLeaseLocker(client, "hydroform"))
+
+	holder, err := NewHolder("update")
+	require.NoError(t, err)
+	key := Key{Provider: types.Gardener, Project: "my-project", Name: "hydro-cluster"}
+	require.NoError(t, NewLeaseLocker(client, "hydroform").Lock(context.Background(), key, holder))
+
+	lease, err := client.CoordinationV1().Leases("hydroform").Get(leaseName(key), metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, holder.ID, *lease.Spec.HolderIdentity, "The Lease should be held by the ID of the holder")
+}
+
+// abandonedLease returns a Lease of the hydro-cluster on Gardener, last renewed at the given time by a process which crashed.
+func abandonedLease(renewed time.Time, durationSeconds *int32) *coordinationv1.Lease {
+	id := "crashed"
+	renewTime := metav1.NewMicroTime(renewed)
+	return &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{Name: leaseName(Key{Provider: types.Gardener, Project: "my-project", Name: "hydro-cluster"}), Namespace: "hydroform"},
+		Spec: coordinationv1.LeaseSpec{
+			HolderIdentity:       &id,
+			AcquireTime:          &renewTime,
+			RenewTime:            &renewTime,
+			LeaseDurationSeconds: durationSeconds,
+		},
+	}
+}
+
+func TestLeaseLockerTakeover(t *testing.T) {
+	key := Key{Provider: types.Gardener, Project: "my-project", Name: "hydro-cluster"}
+	holder, err := NewHolder("update")
+	require.NoError(t, err)
+	duration := int32(60)
+
+	client := fake.NewSimpleClientset(abandonedLease(time.Now().Add(-2*time.Minute), &duration))
+	require.NoError(t, NewLeaseLocker(client, "hydroform").Lock(context.Background(), key, holder), "An expired Lease should be taken over")
+	lease, err := client.CoordinationV1().Leases("hydroform").Get(leaseName(key), metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, holder.ID, *lease.Spec.HolderIdentity, "The Lease should be held by the new holder")
+	require.Equal(t, int32(1), *lease.Spec.LeaseTransitions)
+	require.True(t, lease.Spec.RenewTime.After(time.Now().Add(-time.Minute)), "The Lease should be renewed by the takeover")
+
+	client = fake.NewSimpleClientset(abandonedLease(time.Now().Add(-30*time.Second), &duration))
+	_, ok := NewLeaseLocker(client, "hydroform").Lock(context.Background(), key, holder).(*ErrLocked)
+	require.True(t, ok, "A Lease renewed within its duration should be held")
+
+	client = fake.NewSimpleClientset(abandonedLease(time.Now().Add(-time.Hour), nil))
+	_, ok = NewLeaseLocker(client, "hydroform").Lock(context.Background(), key, holder).(*ErrLocked)
+	require.True(t, ok, "A Lease without duration should not expire")
+}
+
+func TestLeaseLockerRenewal(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	locker := NewLeaseLocker(client, "hydroform")
+	locker.LeaseDurationSeconds = 1
+	key := Key{Provider: types.Gardener, Project: "my-project", Name: "hydro-cluster"}
+	holder, err := NewHolder("provision")
+	require.NoError(t, err)
+
+	require.NoError(t, locker.Lock(context.Background(), key, holder))
+	lease, err := client.CoordinationV1().Leases("hydroform").Get(leaseName(key), metav1.GetOptions{})
+	require.NoError(t, err)
+	acquired := lease.Spec.AcquireTime.Time
+
+	require.Eventually(t, func() bool {
+		lease, err := client.CoordinationV1().Leases("hydroform").Get(leaseName(key), metav1.GetOptions{})
+		return err == nil && lease.Spec.RenewTime.After(acquired)
+	}, 5*time.Second, 50*time.Millisecond, "The Lease should be renewed while the lock is held")
+
+	time.Sleep(1500 * time.Millisecond)
+	other, err := NewHolder("deprovision")
+	require.NoError(t, err)
+	_, ok := locker.Lock(context.Background(), key, other).(*ErrLocked)
+	require.True(t, ok, "A renewed Lease should not expire")
+
+	require.NoError(t, locker.Unlock(context.Background(), key, holder))
+}
+
+func TestLeaseName(t *testing.T) {
+	key := Key{Provider: types.Gardener, Project: "my-project", Name: "hydro-cluster"}
+	require.Regexp(t, "^hydroform-gardener-my-project-hydro-cluster-[0-9a-f]+$", leaseName(key), "The Lease name should tell the cluster")
+	require.Equal(t, leaseName(key), leaseName(key))
+
+	for _, key := range []Key{
+		{Provider: types.GCP, Project: "My_Project", Name: "Hydro.Cluster"},
+		{Provider: types.GCP, Project: strings.Repeat("my-project", 10), Name: strings.Repeat("hydro-cluster", 10)},
+		{Provider: types.GCP, Project: "my-project-", Name: "-"},
+	} {
+		require.Empty(t, validation.IsDNS1123Label(leaseName(key)), "The Lease name of %s should be valid", key)
+	}
+
+	require.NotEqual(t, leaseName(Key{Provider: types.GCP, Project: "my-project", Name: "hydro-cluster"}), leaseName(Key{Provider: types.GCP, Project: "my", Name: "project-hydro-cluster"}),
+		"Keys with the same readable name should have different Leases")
+	require.NotEqual(t, leaseName(Key{Provider: types.GCP, Project: "my-project", Name: strings.Repeat("a", 60) + "1"}), leaseName(Key{Provider: types.GCP, Project: "my-project", Name: strings.Repeat("a", 60) + "2"}),
+		"Keys with the same shortened name should have different Leases")
+}
@@ -0,0 +1,122 @@
+// Package lock provides lockers that prevent several processes from running operations on the same cluster at the same time.
+package lock
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/user"
+	"time"
+
+	"github.com/kyma-incubator/hydroform/types"
+)
+
+// Locker guards clusters against concurrent operations. Implementations must be safe for concurrent use.
+type Locker interface {
+	// Lock acquires the lock of the key for the holder. If someone else holds the lock, it returns an *ErrLocked without waiting.
+	Lock(ctx context.Context, key Key, holder Holder) error
+	// Unlock releases the lock of the key if it is held by the holder, as identified by its ID. Unlocking a lock which is not held is not an error.
+	Unlock(ctx context.Context, key Key, holder Holder) error
+	// ForceUnlock releases the lock of the key regardless of its holder. Use it to clean up after a process which crashed while holding the lock.
+	ForceUnlock(ctx context.Context, key Key) error
+}
+
+// Key identifies the lock of a cluster.
+type Key struct {
+	// Provider is the type of the provider the cluster runs on.
+	Provider types.ProviderType `json:"provider"`
+	// Project is the name of the provider project the cluster belongs to.
+	Project string `json:"project"`
+	// Name is the name of the cluster.
+	Name string `json:"name"`
+}
+
+// KeyFor returns the key of the given cluster and provider.
+func KeyFor(cluster *types.Cluster, provider *types.Provider) Key {
+	return Key{Provider: provider.Type, Project: provider.ProjectName, Name: cluster.Name}
+}
+
+func (k Key) String() string {
+	return fmt.Sprintf("%s/%s/%s", k.Provider, k.Project, k.Name)
+}
+
+// Holder describes who holds a lock.
+type Holder struct {
+	// ID identifies a single acquisition of the lock. Only the holder with the same ID can release the lock.
+	ID string `json:"id"`
+	// Who identifies the process holding the lock, for example `user@host (pid 42)`.
+	Who string `json:"who"`
+	// Operation is the Hydroform operation run under the lock, for example `provision`.
+	Operation string `json:"operation"`
+	// Created is the time the lock was acquired.
+	Created time.Time `json:"created"`
+}
+
+// NewHolder returns a Holder with a new random ID, describing the current process running the given operation.
+func NewHolder(operation string) (Holder, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return Holder{}, err
+	}
+
+	who := "unknown"
+	if u, err := user.Current(); err == nil {
+		who = u.Username
+	}
+	if host, err := os.Hostname(); err == nil {
+		who = fmt.Sprintf("%s@%s", who, host)
+	}
+
+	return Holder{
+		ID:        hex.EncodeToString(id),
+		Who:       fmt.Sprintf("%s (pid %d)", who, os.Getpid()),
+		Operation: operation,
+		Created:   time.Now().UTC(),
+	}, nil
+}
+
+// ErrLocked is returned when the lock of a cluster is held by someone else.
+type ErrLocked struct {
+	// Key is the key of the lock.
+	Key Key
+	// Holder is the current holder of the lock.
+	Holder Holder
+}
+
+func (e *ErrLocked) Error() string {
+	return fmt.Sprintf("cluster %s is locked by %s running %s since %s (lock ID %s)",
+		e.Key, e.Holder.Who, e.Holder.Operation, e.Holder.Created.Format(time.RFC3339), e.Holder.ID)
+}
+
+// pollInterval is the time between attempts to acquire a lock in LockWithTimeout.
+var pollInterval = time.Second
+
+// LockWithTimeout acquires the lock of the key, retrying while it is held by someone else for at most the given timeout. A zero timeout tries only once.
+// If the lock could not be acquired in time, the *ErrLocked of the last attempt is returned.
+func LockWithTimeout(ctx context.Context, locker Locker, key Key, holder Holder, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		err := locker.Lock(ctx, key, holder)
+		if _, locked := err.(*ErrLocked); !locked {
+			return err
+		}
+
+		wait := pollInterval
+		if remaining := time.Until(deadline); remaining < wait {
+			wait = remaining
+		}
+		if wait <= 0 {
+			return err
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
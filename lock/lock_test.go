@@ -0,0 +1,80 @@
+package lock
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kyma-incubator/hydroform/types"
+	"github.com/stretchr/testify/require"
+)
+
+// testLocker checks the behavior every Locker must have.
+func testLocker(t *testing.T, locker Locker) {
+	ctx := context.Background()
+	key := Key{Provider: types.GCP, Project: "my-project", Name: "hydro-cluster"}
+
+	first, err := NewHolder("provision")
+	require.NoError(t, err)
+	second, err := NewHolder("deprovision")
+	require.NoError(t, err)
+	require.NotEqual(t, first.ID, second.ID, "Every holder should get a unique ID")
+
+	require.NoError(t, locker.Lock(ctx, key, first))
+
+	err = locker.Lock(ctx, key, second)
+	locked, ok := err.(*ErrLocked)
+	require.True(t, ok, "Locking a held lock should return ErrLocked")
+	require.Equal(t, key, locked.Key)
+	require.Equal(t, first.ID, locked.Holder.ID, "ErrLocked should report the current holder")
+	require.Equal(t, "provision", locked.Holder.Operation)
+
+	require.NoError(t, locker.Lock(ctx, Key{Provider: types.GCP, Project: "my-project", Name: "other-cluster"}, second), "Other clusters should be locked separately")
+
+	_, ok = locker.Unlock(ctx, key, second).(*ErrLocked)
+	require.True(t, ok, "Only the holder should be able to unlock")
+
+	require.NoError(t, locker.Unlock(ctx, key, first))
+	require.NoError(t, locker.Unlock(ctx, key, first), "Unlocking a released lock should not fail")
+	require.NoError(t, locker.Lock(ctx, key, second), "A released lock should be acquired")
+
+	require.NoError(t, locker.ForceUnlock(ctx, key))
+	require.NoError(t, locker.Lock(ctx, key, first), "A force-unlocked lock should be acquired")
+}
+
+// releasingLocker reports the lock as held for the given number of attempts.
+type releasingLocker struct {
+	attempts int
+}
+
+func (l *releasingLocker) Lock(ctx context.Context, key Key, holder Holder) error {
+	if l.attempts > 0 {
+		l.attempts--
+		return &ErrLocked{Key: key}
+	}
+	return nil
+}
+
+func (l *releasingLocker) Unlock(ctx context.Context, key Key, holder Holder) error {
+	return nil
+}
+
+func (l *releasingLocker) ForceUnlock(ctx context.Context, key Key) error {
+	return nil
+}
+
+func TestLockWithTimeout(t *testing.T) {
+	defer func(interval time.Duration) { pollInterval = interval }(pollInterval)
+	pollInterval = time.Millisecond
+
+	require.NoError(t, LockWithTimeout(context.Background(), &releasingLocker{attempts: 3}, Key{}, Holder{}, time.Minute), "The lock should be acquired once released")
+
+	err := LockWithTimeout(context.Background(), &releasingLocker{attempts: 3}, Key{}, Holder{}, 0)
+	_, ok := err.(*ErrLocked)
+	require.True(t, ok, "Without timeout, the lock should be tried only once")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = LockWithTimeout(ctx, &releasingLocker{attempts: 3}, Key{}, Holder{}, time.Minute)
+	require.Equal(t, context.Canceled, err)
+}
@@ -0,0 +1,138 @@
+package hydroform
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/kyma-incubator/hydroform/internal/gardener"
+	"github.com/kyma-incubator/hydroform/internal/gcp"
+	"github.com/kyma-incubator/hydroform/internal/operator"
+	"github.com/kyma-incubator/hydroform/types"
+)
+
+// OperatorType points out the type of the operator a Provisioner uses to manage the cluster infrastructure.
+type OperatorType = operator.Type
+
+// TerraformOperator indicates the type of the operator is Terraform.
+const TerraformOperator OperatorType = operator.TerraformOperator
+
+// ProvisionerFactory creates a Provisioner which manages clusters with the given operator type.
+type ProvisionerFactory func(operatorType OperatorType) Provisioner
+
+// defaultRegistry holds the providers registered with RegisterProvider. It is used by the package-level functions and copied into every new Client.
+var defaultRegistry = newProviderRegistry()
+
+func init() {
+	RegisterProvider(types.GCP, newGCPProvisioner)
+	RegisterProvider(types.Gardener, newGardenerProvisioner)
+}
+
+// RegisterProvider makes a Provisioner available for the given provider type. Registering a provider type which is already registered replaces the previous factory, which allows overriding the built-in providers.
+// Clients created with New before the call are not affected. It panics if the factory is nil.
+func RegisterProvider(providerType types.ProviderType, factory ProvisionerFactory) {
+	defaultRegistry.register(providerType, factory)
+}
+
+// RegisteredProviders returns the sorted list of provider types that have a Provisioner registered.
+func RegisteredProviders() []types.ProviderType {
+	return defaultRegistry.providerTypes()
+}
+
+// UnsupportedProviderError is returned when there is no Provisioner registered for the requested provider type.
+type UnsupportedProviderError struct {
+	// Type is the requested provider type.
+	Type types.ProviderType
+	// Registered lists the provider types available at the time of the request.
+	Registered []types.ProviderType
+}
+
+func (e *UnsupportedProviderError) Error() string {
+	registered := make([]string, 0, len(e.Registered))
+	for _, t := range e.Registered {
+		registered = append(registered, string(t))
+	}
+	return fmt.Sprintf("provider %q is not supported, registered providers: [%s]", e.Type, strings.Join(registered, ", "))
+}
+
+// UnsupportedOperationError is returned when the Provisioner registered for a provider type does not implement an optional operation, such as Plan.
+type UnsupportedOperationError struct {
+	// Operation is the name of the requested operation.
+	Operation string
+	// Type is the provider type the operation was requested for.
+	Type types.ProviderType
+}
+
+func (e *UnsupportedOperationError) Error() string {
+	return fmt.Sprintf("operation %s is not supported by provider %q", e.Operation, e.Type)
+}
+
+// providerRegistry maps provider types to the factories of their provisioners. It is safe for concurrent use.
+type providerRegistry struct {
+	lock      sync.RWMutex
+	factories map[types.ProviderType]ProvisionerFactory
+}
+
+func newProviderRegistry() *providerRegistry {
+	return &providerRegistry{
+		factories: map[types.ProviderType]ProvisionerFactory{},
+	}
+}
+
+func (r *providerRegistry) register(providerType types.ProviderType, factory ProvisionerFactory) {
+	if factory == nil {
+		panic(fmt.Sprintf("hydroform: nil provisioner factory registered for provider %q", providerType))
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.factories[providerType] = factory
+}
+
+// provisioner creates the Provisioner registered for the given provider type.
+func (r *providerRegistry) provisioner(providerType types.ProviderType, operatorType OperatorType) (Provisioner, error) {
+	r.lock.RLock()
+	factory, ok := r.factories[providerType]
+	r.lock.RUnlock()
+
+	if !ok {
+		return nil, &UnsupportedProviderError{
+			Type:       providerType,
+			Registered: r.providerTypes(),
+		}
+	}
+	return factory(operatorType), nil
+}
+
+func (r *providerRegistry) providerTypes() []types.ProviderType {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	providerTypes := make([]types.ProviderType, 0, len(r.factories))
+	for t := range r.factories {
+		providerTypes = append(providerTypes, t)
+	}
+	sort.Slice(providerTypes, func(i, j int) bool { return providerTypes[i] < providerTypes[j] })
+	return providerTypes
+}
+
+// copy returns an independent registry with the same providers.
+func (r *providerRegistry) copy() *providerRegistry {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	c := newProviderRegistry()
+	for t, f := range r.factories {
+		c.factories[t] = f
+	}
+	return c
+}
+
+func newGCPProvisioner(operatorType OperatorType) Provisioner {
+	return gcp.New(operatorType)
+}
+
+func newGardenerProvisioner(operatorType OperatorType) Provisioner {
+	return gardener.New(operatorType)
+}
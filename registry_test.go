@@ -0,0 +1,85 @@
+package hydroform
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kyma-incubator/hydroform/types"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeProvisioner is a Provisioner that records the calls it receives.
+type fakeProvisioner struct {
+	operatorType OperatorType
+	calls        []string
+}
+
+func (f *fakeProvisioner) Provision(ctx context.Context, cluster *types.Cluster, provider *types.Provider) (*types.Cluster, error) {
+	f.calls = append(f.calls, "Provision")
+	cluster.ClusterInfo = &types.ClusterInfo{Status: &types.ClusterStatus{Phase: types.Provisioned}}
+	return cluster, nil
+}
+
+func (f *fakeProvisioner) Status(ctx context.Context, cluster *types.Cluster, provider *types.Provider) (*types.ClusterStatus, error) {
+	f.calls = append(f.calls, "Status")
+	return &types.ClusterStatus{Phase: types.Provisioned}, nil
+}
+
+func (f *fakeProvisioner) Credentials(ctx context.Context, cluster *types.Cluster, provider *types.Provider) ([]byte, error) {
+	f.calls = append(f.calls, "Credentials")
+	return []byte("kubeconfig"), nil
+}
+
+func (f *fakeProvisioner) Deprovision(ctx context.Context, cluster *types.Cluster, provider *types.Provider) error {
+	f.calls = append(f.calls, "Deprovision")
+	return nil
+}
+
+func TestRegisterProvider(t *testing.T) {
+	const inHouse types.ProviderType = "in-house"
+	fake := &fakeProvisioner{}
+	RegisterProvider(inHouse, func(operatorType OperatorType) Provisioner {
+		fake.operatorType = operatorType
+		return fake
+	})
+	defer func() {
+		defaultRegistry.lock.Lock()
+		delete(defaultRegistry.factories, inHouse)
+		defaultRegistry.lock.Unlock()
+	}()
+
+	require.Contains(t, RegisteredProviders(), inHouse)
+
+	cluster := &types.Cluster{Name: "hydro-cluster"}
+	provider := &types.Provider{Type: inHouse}
+
+	cluster, err := Provision(cluster, provider)
+	require.NoError(t, err, "Provision should use the registered provisioner")
+	require.Equal(t, types.Provisioned, cluster.ClusterInfo.Status.Phase)
+	require.Equal(t, TerraformOperator, fake.operatorType)
+
+	_, err = Status(cluster, provider)
+	require.NoError(t, err)
+	_, err = Credentials(cluster, provider)
+	require.NoError(t, err)
+	require.NoError(t, Deprovision(cluster, provider))
+
+	require.Equal(t, []string{"Provision", "Status", "Credentials", "Deprovision"}, fake.calls)
+}
+
+func TestRegisterProviderNil(t *testing.T) {
+	require.Panics(t, func() { RegisterProvider("nil-provider", nil) })
+}
+
+func TestUnsupportedProvider(t *testing.T) {
+	require.ElementsMatch(t, []types.ProviderType{types.GCP, types.Gardener}, RegisteredProviders(), "GCP and Gardener should be registered by default")
+
+	_, err := Status(&types.Cluster{}, &types.Provider{Type: types.AWS})
+	require.Error(t, err)
+
+	var unsupported *UnsupportedProviderError
+	require.True(t, errors.As(err, &unsupported), "An unknown provider should return an UnsupportedProviderError")
+	require.Equal(t, types.AWS, unsupported.Type)
+	require.Equal(t, []types.ProviderType{types.Gardener, types.GCP}, unsupported.Registered)
+}
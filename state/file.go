@@ -0,0 +1,178 @@
+package state
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/kyma-incubator/hydroform/types"
+	"github.com/pkg/errors"
+)
+
+const fileExtension = ".json"
+
+// FileStore is a Store keeping the state of each cluster in a JSON file on the local filesystem, at `<dir>/<provider>/<project>/<cluster name>.json`.
+// The files contain credentials for the providers, so they are only readable by their owner.
+type FileStore struct {
+	dir   string
+	codec codec
+}
+
+// NewFileStore creates a FileStore keeping the files in the given directory. The directory is created on the first Save.
+func NewFileStore(dir string, opts ...Option) *FileStore {
+	return &FileStore{
+		dir:   dir,
+		codec: newCodec(opts),
+	}
+}
+
+// Load returns the ClusterInfo saved for the key, or ErrNotFound if there is none.
+func (s *FileStore) Load(ctx context.Context, key Key) (*types.ClusterInfo, error) {
+	data, err := s.loadRaw(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := s.codec.decode(data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to decode the state of %s", key)
+	}
+	return info, nil
+}
+
+// Save stores the ClusterInfo for the key. The file is replaced atomically, so a crash while saving never leaves a partially written state behind.
+func (s *FileStore) Save(ctx context.Context, key Key, info *types.ClusterInfo) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	data, err := s.codec.encode(info)
+	if err != nil {
+		return errors.Wrapf(err, "unable to encode the state of %s", key)
+	}
+	return s.saveRaw(ctx, key, data)
+}
+
+func (s *FileStore) loadRaw(ctx context.Context, key Key) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadFile(s.path(key))
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read the state of %s", key)
+	}
+	return data, nil
+}
+
+func (s *FileStore) saveRaw(ctx context.Context, key Key, data []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(s.path(key))
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return errors.Wrapf(err, "unable to create the state directory %s", dir)
+	}
+
+	tmp, err := ioutil.TempFile(dir, "."+key.Name)
+	if err != nil {
+		return errors.Wrapf(err, "unable to save the state of %s", key)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return errors.Wrapf(err, "unable to save the state of %s", key)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return errors.Wrapf(err, "unable to save the state of %s", key)
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrapf(err, "unable to save the state of %s", key)
+	}
+
+	return errors.Wrapf(os.Rename(tmp.Name(), s.path(key)), "unable to save the state of %s", key)
+}
+
+// Delete removes the file of the key.
+func (s *FileStore) Delete(ctx context.Context, key Key) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	err := os.Remove(s.path(key))
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "unable to delete the state of %s", key)
+	}
+	return nil
+}
+
+// List returns the keys of all saved clusters.
+func (s *FileStore) List(ctx context.Context) ([]Key, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	providers, err := ioutil.ReadDir(s.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list the saved states")
+	}
+
+	var keys []Key
+	for _, p := range providers {
+		if !p.IsDir() {
+			continue
+		}
+		provider := types.ProviderType(p.Name())
+		entries, err := ioutil.ReadDir(filepath.Join(s.dir, p.Name()))
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to list the saved states")
+		}
+		for _, e := range entries {
+			// the states of clusters without project are kept in the directory of the provider
+			if !e.IsDir() {
+				keys = appendKey(keys, Key{Provider: provider}, e)
+				continue
+			}
+			files, err := ioutil.ReadDir(filepath.Join(s.dir, p.Name(), e.Name()))
+			if err != nil {
+				return nil, errors.Wrap(err, "unable to list the saved states")
+			}
+			for _, f := range files {
+				keys = appendKey(keys, Key{Provider: provider, Project: e.Name()}, f)
+			}
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool { return lessKey(keys[i], keys[j]) })
+	return keys, nil
+}
+
+// appendKey adds the key of the state file to the keys, with the provider and project of the given key.
+func appendKey(keys []Key, key Key, f os.FileInfo) []Key {
+	// skip the temporary files of unfinished saves
+	if f.IsDir() || strings.HasPrefix(f.Name(), ".") || filepath.Ext(f.Name()) != fileExtension {
+		return keys
+	}
+	key.Name = strings.TrimSuffix(f.Name(), fileExtension)
+	return append(keys, key)
+}
+
+func (s *FileStore) stateCodec() codec {
+	return s.codec
+}
+
+func (s *FileStore) path(key Key) string {
+	return filepath.Join(s.dir, string(key.Provider), key.Project, key.Name+fileExtension)
+}
@@ -0,0 +1,24 @@
+package state
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kyma-incubator/hydroform/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hydroform-state")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	store := NewFileStore(filepath.Join(dir, "states"))
+	testStore(t, store)
+
+	info, err := os.Stat(store.path(Key{Provider: types.Gardener, Project: "my-project", Name: "hydro-cluster"}))
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm(), "The state should only be readable by its owner")
+}
@@ -0,0 +1,161 @@
+package state
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/kyma-incubator/hydroform/types"
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+const (
+	providerLabel = "hydroform.kyma-project.io/provider"
+	projectLabel  = "hydroform.kyma-project.io/project"
+	clusterLabel  = "hydroform.kyma-project.io/cluster"
+	secretDataKey = "clusterInfo"
+)
+
+// SecretStore is a Store keeping the state of each cluster in a Kubernetes Secret named `hydroform-<provider>-<project>-<cluster name>`.
+// The Secrets are labeled with the provider, the project and the cluster name, so that they can be listed.
+type SecretStore struct {
+	client    kubernetes.Interface
+	namespace string
+	codec     codec
+}
+
+// NewSecretStore creates a SecretStore keeping the Secrets in the given namespace.
+func NewSecretStore(client kubernetes.Interface, namespace string, opts ...Option) *SecretStore {
+	return &SecretStore{
+		client:    client,
+		namespace: namespace,
+		codec:     newCodec(opts),
+	}
+}
+
+// Load returns the ClusterInfo saved for the key, or ErrNotFound if there is none.
+func (s *SecretStore) Load(ctx context.Context, key Key) (*types.ClusterInfo, error) {
+	data, err := s.loadRaw(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := s.codec.decode(data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to decode the state of %s", key)
+	}
+	return info, nil
+}
+
+// Save stores the ClusterInfo for the key, creating the Secret if it does not exist yet.
+func (s *SecretStore) Save(ctx context.Context, key Key, info *types.ClusterInfo) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	data, err := s.codec.encode(info)
+	if err != nil {
+		return errors.Wrapf(err, "unable to encode the state of %s", key)
+	}
+	return s.saveRaw(ctx, key, data)
+}
+
+func (s *SecretStore) loadRaw(ctx context.Context, key Key) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	secret, err := s.secrets().Get(secretName(key), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read the state of %s", key)
+	}
+	return secret.Data[secretDataKey], nil
+}
+
+func (s *SecretStore) saveRaw(ctx context.Context, key Key, data []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	secret, err := s.secrets().Get(secretName(key), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = s.secrets().Create(&v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      secretName(key),
+				Namespace: s.namespace,
+				Labels: map[string]string{
+					providerLabel: string(key.Provider),
+					projectLabel:  key.Project,
+					clusterLabel:  key.Name,
+				},
+			},
+			Type: v1.SecretTypeOpaque,
+			Data: map[string][]byte{secretDataKey: data},
+		})
+		return errors.Wrapf(err, "unable to save the state of %s", key)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "unable to save the state of %s", key)
+	}
+
+	secret.Data = map[string][]byte{secretDataKey: data}
+	_, err = s.secrets().Update(secret)
+	return errors.Wrapf(err, "unable to save the state of %s", key)
+}
+
+// Delete removes the Secret of the key.
+func (s *SecretStore) Delete(ctx context.Context, key Key) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	err := s.secrets().Delete(secretName(key), &metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "unable to delete the state of %s", key)
+	}
+	return nil
+}
+
+// List returns the keys of all saved clusters, based on the labels of the Secrets.
+func (s *SecretStore) List(ctx context.Context) ([]Key, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	secrets, err := s.secrets().List(metav1.ListOptions{LabelSelector: fmt.Sprintf("%s,%s,%s", providerLabel, projectLabel, clusterLabel)})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list the saved states")
+	}
+
+	keys := make([]Key, 0, len(secrets.Items))
+	for _, secret := range secrets.Items {
+		keys = append(keys, Key{
+			Provider: types.ProviderType(secret.Labels[providerLabel]),
+			Project:  secret.Labels[projectLabel],
+			Name:     secret.Labels[clusterLabel],
+		})
+	}
+
+	sort.Slice(keys, func(i, j int) bool { return lessKey(keys[i], keys[j]) })
+	return keys, nil
+}
+
+func (s *SecretStore) stateCodec() codec {
+	return s.codec
+}
+
+func (s *SecretStore) secrets() corev1.SecretInterface {
+	return s.client.CoreV1().Secrets(s.namespace)
+}
+
+func secretName(key Key) string {
+	return fmt.Sprintf("hydroform-%s-%s-%s", key.Provider, key.Project, key.Name)
+}
@@ -0,0 +1,21 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/kyma-incubator/hydroform/types"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestSecretStore(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	testStore(t, NewSecretStore(client, "hydroform"))
+
+	secret, err := client.CoreV1().Secrets("hydroform").Get("hydroform-gardener-my-project-hydro-cluster", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, string(types.Gardener), secret.Labels[providerLabel])
+	require.Equal(t, "my-project", secret.Labels[projectLabel])
+	require.Equal(t, "hydro-cluster", secret.Labels[clusterLabel])
+}
@@ -0,0 +1,178 @@
+// Package state provides stores that keep the ClusterInfo of provisioned clusters, including the internal state needed to update or deprovision them, outside of the process memory.
+package state
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/kyma-incubator/hydroform/encryption"
+	"github.com/kyma-incubator/hydroform/types"
+	"github.com/pkg/errors"
+)
+
+// ErrNotFound is returned by Store.Load when the store holds no state for the given key.
+var ErrNotFound = errors.New("state not found")
+
+// Store keeps the ClusterInfo of clusters, keyed by provider, project and cluster name. Implementations must be safe for concurrent use.
+type Store interface {
+	// Load returns the ClusterInfo saved for the key, or ErrNotFound if there is none.
+	Load(ctx context.Context, key Key) (*types.ClusterInfo, error)
+	// Save stores the ClusterInfo for the key, replacing any previous one.
+	Save(ctx context.Context, key Key, info *types.ClusterInfo) error
+	// Delete removes the ClusterInfo saved for the key. Deleting a key which does not exist is not an error.
+	Delete(ctx context.Context, key Key) error
+	// List returns the keys of all saved clusters, sorted by provider, project and name.
+	List(ctx context.Context) ([]Key, error)
+}
+
+// Key identifies the state of a cluster in a Store.
+type Key struct {
+	// Provider is the type of the provider the cluster runs on.
+	Provider types.ProviderType `json:"provider"`
+	// Project is the name of the provider project the cluster belongs to. Clusters of different projects can have the same name.
+	Project string `json:"project"`
+	// Name is the name of the cluster.
+	Name string `json:"name"`
+}
+
+// KeyFor returns the key of the given cluster and provider.
+func KeyFor(cluster *types.Cluster, provider *types.Provider) Key {
+	return Key{Provider: provider.Type, Project: provider.ProjectName, Name: cluster.Name}
+}
+
+func (k Key) String() string {
+	return fmt.Sprintf("%s/%s/%s", k.Provider, k.Project, k.Name)
+}
+
+// Option configures a Store.
+type Option func(c *codec)
+
+// WithEncryption encrypts the saved states with the keys of the provider. A store with encryption cannot read states saved without it, and the other way round.
+func WithEncryption(keys encryption.KeyProvider) Option {
+	return func(c *codec) {
+		c.keys = keys
+	}
+}
+
+// codec turns ClusterInfo into the data saved by the stores.
+type codec struct {
+	// keys encrypt the data. If nil, the data is not encrypted.
+	keys encryption.KeyProvider
+}
+
+func newCodec(opts []Option) codec {
+	c := codec{}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+func (c codec) encode(info *types.ClusterInfo) ([]byte, error) {
+	data, err := json.Marshal(info)
+	if err != nil || c.keys == nil {
+		return data, err
+	}
+	return encryption.Encrypt(c.keys, data)
+}
+
+func (c codec) decode(data []byte) (*types.ClusterInfo, error) {
+	if c.keys != nil {
+		var err error
+		data, err = encryption.Decrypt(c.keys, data)
+		if err != nil {
+			return nil, err
+		}
+	} else if id, err := encryption.KeyID(data); err == nil {
+		return nil, fmt.Errorf("the state is encrypted with key %q, use a store with encryption to read it", id)
+	}
+
+	info := &types.ClusterInfo{}
+	if err := json.Unmarshal(data, info); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// rotate re-encrypts the data key of encoded data with the current key, leaving the encrypted state untouched. Data of a codec without encryption is returned as is.
+func (c codec) rotate(data []byte) ([]byte, error) {
+	if c.keys == nil {
+		return data, nil
+	}
+	return encryption.Rotate(c.keys, data)
+}
+
+// rawStore is a Store which can read and write the encoded states, so that Reencrypt does not have to decode them.
+type rawStore interface {
+	Store
+	loadRaw(ctx context.Context, key Key) ([]byte, error)
+	saveRaw(ctx context.Context, key Key, data []byte) error
+	stateCodec() codec
+}
+
+// Reencrypt re-encrypts every state of the store with the current key, so that the previous keys can be retired after a rotation.
+// For the stores of this package only the data keys of the states are re-encrypted, the states are neither decrypted nor decoded. The states of other stores are loaded and saved again.
+func Reencrypt(ctx context.Context, store Store) error {
+	keys, err := store.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	raw, isRaw := store.(rawStore)
+	for _, key := range keys {
+		if isRaw {
+			err = reencryptRaw(ctx, raw, key)
+		} else {
+			err = resave(ctx, store, key)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reencryptRaw re-encrypts the data key of the state of the key, and saves the state if the key changed.
+func reencryptRaw(ctx context.Context, store rawStore, key Key) error {
+	data, err := store.loadRaw(ctx, key)
+	if err == ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	rotated, err := store.stateCodec().rotate(data)
+	if err != nil {
+		return errors.Wrapf(err, "unable to re-encrypt the state of %s", key)
+	}
+	if bytes.Equal(rotated, data) {
+		return nil
+	}
+	return store.saveRaw(ctx, key, rotated)
+}
+
+// resave loads the state of the key and saves it again.
+func resave(ctx context.Context, store Store, key Key) error {
+	info, err := store.Load(ctx, key)
+	if err == ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return store.Save(ctx, key, info)
+}
+
+// lessKey reports whether the key a sorts before b, ordering by provider, project and name.
+func lessKey(a, b Key) bool {
+	if a.Provider != b.Provider {
+		return a.Provider < b.Provider
+	}
+	if a.Project != b.Project {
+		return a.Project < b.Project
+	}
+	return a.Name < b.Name
+}
@@ -0,0 +1,143 @@
+package state
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/hashicorp/terraform/terraform"
+	"github.com/kyma-incubator/hydroform/encryption"
+	"github.com/kyma-incubator/hydroform/types"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newClusterInfo() *types.ClusterInfo {
+	state := terraform.NewState()
+	state.RootModule().Resources["google_container_cluster.gke_cluster"] = &terraform.ResourceState{
+		Type:     "google_container_cluster",
+		Provider: "provider.google",
+		Primary: &terraform.InstanceState{
+			ID:         "hydro-cluster",
+			Attributes: map[string]string{"id": "hydro-cluster", "name": "hydro-cluster"},
+		},
+	}
+
+	return &types.ClusterInfo{
+		Endpoint:                 "35.1.2.3",
+		CertificateAuthorityData: []byte("My cert"),
+		InternalState:            &types.InternalState{TerraformState: state},
+		Status:                   &types.ClusterStatus{Phase: types.Provisioned},
+		Outputs:                  map[string]interface{}{"endpoint": "35.1.2.3"},
+	}
+}
+
+// testStore checks the behavior every Store must have.
+func testStore(t *testing.T, store Store) {
+	ctx := context.Background()
+	gcpKey := Key{Provider: types.GCP, Project: "my-project", Name: "hydro-cluster"}
+	gardenerKey := Key{Provider: types.Gardener, Project: "my-project", Name: "hydro-cluster"}
+	otherProjectKey := Key{Provider: types.GCP, Project: "other-project", Name: "hydro-cluster"}
+
+	_, err := store.Load(ctx, gcpKey)
+	require.Equal(t, ErrNotFound, err, "Loading a missing state should return ErrNotFound")
+
+	keys, err := store.List(ctx)
+	require.NoError(t, err)
+	require.Empty(t, keys)
+
+	info := newClusterInfo()
+	require.NoError(t, store.Save(ctx, gcpKey, info))
+	require.NoError(t, store.Save(ctx, gardenerKey, &types.ClusterInfo{Endpoint: "https://api.hydro-cluster.fake"}))
+	require.NoError(t, store.Save(ctx, otherProjectKey, &types.ClusterInfo{Endpoint: "35.7.8.9"}))
+
+	loaded, err := store.Load(ctx, gcpKey)
+	require.NoError(t, err)
+	require.Equal(t, info.Endpoint, loaded.Endpoint)
+	require.Equal(t, info.CertificateAuthorityData, loaded.CertificateAuthorityData)
+	require.Equal(t, info.Status, loaded.Status)
+	require.Equal(t, info.Outputs, loaded.Outputs)
+	require.True(t, info.InternalState.TerraformState.Equal(loaded.InternalState.TerraformState), "The Terraform state should be preserved")
+
+	info.Endpoint = "35.4.5.6"
+	require.NoError(t, store.Save(ctx, gcpKey, info), "Saving should replace the previous state")
+	loaded, err = store.Load(ctx, gcpKey)
+	require.NoError(t, err)
+	require.Equal(t, "35.4.5.6", loaded.Endpoint)
+	loaded, err = store.Load(ctx, otherProjectKey)
+	require.NoError(t, err)
+	require.Equal(t, "35.7.8.9", loaded.Endpoint, "Clusters with the same name in different projects should have their own state")
+
+	keys, err = store.List(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []Key{gardenerKey, gcpKey, otherProjectKey}, keys)
+
+	require.NoError(t, store.Delete(ctx, gcpKey))
+	require.NoError(t, store.Delete(ctx, gcpKey), "Deleting a missing state should not fail")
+	_, err = store.Load(ctx, gcpKey)
+	require.Equal(t, ErrNotFound, err)
+	_, err = store.Load(ctx, otherProjectKey)
+	require.NoError(t, err, "Deleting a state should keep the state of the cluster with the same name in another project")
+
+	keys, err = store.List(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []Key{gardenerKey, otherProjectKey}, keys)
+}
+
+func TestEncryption(t *testing.T) {
+	ctx := context.Background()
+	key := Key{Provider: types.GCP, Project: "my-project", Name: "hydro-cluster"}
+	oldKey := encryption.Key{ID: "old", Material: bytes.Repeat([]byte{1}, 32)}
+	newKey := encryption.Key{ID: "new", Material: bytes.Repeat([]byte{2}, 32)}
+
+	oldKeys, err := encryption.NewStaticKeyProvider(oldKey)
+	require.NoError(t, err)
+	rotatedKeys, err := encryption.NewStaticKeyProvider(newKey, oldKey)
+	require.NoError(t, err)
+	newKeys, err := encryption.NewStaticKeyProvider(newKey)
+	require.NoError(t, err)
+
+	client := fake.NewSimpleClientset()
+	secret := func() []byte {
+		s, err := client.CoreV1().Secrets("hydroform").Get("hydroform-gcp-my-project-hydro-cluster", metav1.GetOptions{})
+		require.NoError(t, err)
+		return s.Data[secretDataKey]
+	}
+
+	testStore(t, NewSecretStore(fake.NewSimpleClientset(), "hydroform", WithEncryption(oldKeys)))
+
+	info := newClusterInfo()
+	require.NoError(t, NewSecretStore(client, "hydroform", WithEncryption(oldKeys)).Save(ctx, key, info))
+	require.False(t, bytes.Contains(secret(), info.CertificateAuthorityData), "The state should be encrypted")
+
+	_, err = NewSecretStore(client, "hydroform").Load(ctx, key)
+	require.Error(t, err, "An encrypted state cannot be read without the keys")
+	_, err = NewSecretStore(client, "hydroform", WithEncryption(newKeys)).Load(ctx, key)
+	var decryptionErr *encryption.DecryptionError
+	require.True(t, errors.As(err, &decryptionErr), "Loading with an unknown key should return a DecryptionError")
+	require.Equal(t, encryption.UnknownKey, decryptionErr.Reason)
+
+	encrypted := secret()
+	require.NoError(t, Reencrypt(ctx, NewSecretStore(client, "hydroform", WithEncryption(rotatedKeys))))
+	id, err := encryption.KeyID(secret())
+	require.NoError(t, err)
+	require.Equal(t, "new", id, "The state should be re-encrypted with the current key")
+	require.Equal(t, ciphertext(t, encrypted), ciphertext(t, secret()), "Only the data key should be re-encrypted")
+
+	loaded, err := NewSecretStore(client, "hydroform", WithEncryption(newKeys)).Load(ctx, key)
+	require.NoError(t, err, "The previous key should not be needed after re-encryption")
+	require.Equal(t, info.Endpoint, loaded.Endpoint)
+}
+
+// ciphertext returns the encrypted state of an encrypted blob.
+func ciphertext(t *testing.T, data []byte) []byte {
+	blob := struct {
+		Ciphertext []byte `json:"ciphertext"`
+	}{}
+	require.NoError(t, json.Unmarshal(data, &blob))
+	require.NotEmpty(t, blob.Ciphertext)
+	return blob.Ciphertext
+}
@@ -0,0 +1,68 @@
+package types
+
+import "fmt"
+
+// Autoscaling specifies how a pool of nodes scales with the load of the cluster, and how its nodes are replaced during updates.
+type Autoscaling struct {
+	// Min is the number of nodes the pool shrinks to at most.
+	Min int `json:"min"`
+	// Max is the number of nodes the pool grows to at most.
+	Max int `json:"max"`
+	// MaxSurge is the number of nodes added above the size of the pool while its nodes are replaced. If both MaxSurge and MaxUnavailable are 0, one node is added at a time. GCP does not support it and ignores it.
+	MaxSurge int `json:"maxSurge,omitempty"`
+	// MaxUnavailable is the number of nodes of the pool that can be unavailable while its nodes are replaced. GCP does not support it and ignores it.
+	MaxUnavailable int `json:"maxUnavailable,omitempty"`
+}
+
+// ValidateAutoscaling checks the autoscaling settings of the cluster and its node pools, including that every autoscaled pool starts with a number of nodes between its bounds.
+// Every provisioner includes the returned errors in its validation.
+func ValidateAutoscaling(cluster *Cluster) []FieldError {
+	var fieldErrs []FieldError
+	if cluster.Autoscaling != nil {
+		fieldErrs = append(fieldErrs, cluster.Autoscaling.validate("Cluster.Autoscaling")...)
+		if len(cluster.NodePools) == 0 {
+			fieldErrs = append(fieldErrs, cluster.Autoscaling.validateNodeCount("Cluster.NodeCount", cluster.NodeCount)...)
+		}
+	}
+
+	for i, pool := range cluster.NodePools {
+		path := fmt.Sprintf("Cluster.NodePools[%d]", i)
+		autoscaling := cluster.Autoscaling
+		if pool.Autoscaling != nil {
+			autoscaling = pool.Autoscaling
+			fieldErrs = append(fieldErrs, autoscaling.validate(path+".Autoscaling")...)
+		}
+		if autoscaling != nil {
+			fieldErrs = append(fieldErrs, autoscaling.validateNodeCount(path+".NodeCount", pool.NodeCount)...)
+		}
+	}
+	return fieldErrs
+}
+
+func (a *Autoscaling) validate(path string) []FieldError {
+	var fieldErrs []FieldError
+	if a.Min < 0 {
+		fieldErrs = append(fieldErrs, FieldError{Field: path + ".Min", Reason: "cannot be less than 0", Value: a.Min})
+	}
+	if a.Max < 1 {
+		fieldErrs = append(fieldErrs, FieldError{Field: path + ".Max", Reason: "cannot be less than 1", Value: a.Max})
+	}
+	if a.Max < a.Min {
+		fieldErrs = append(fieldErrs, FieldError{Field: path + ".Max", Reason: fmt.Sprintf("cannot be less than %s.Min", path), Value: a.Max})
+	}
+	if a.MaxSurge < 0 {
+		fieldErrs = append(fieldErrs, FieldError{Field: path + ".MaxSurge", Reason: "cannot be less than 0", Value: a.MaxSurge})
+	}
+	if a.MaxUnavailable < 0 {
+		fieldErrs = append(fieldErrs, FieldError{Field: path + ".MaxUnavailable", Reason: "cannot be less than 0", Value: a.MaxUnavailable})
+	}
+	return fieldErrs
+}
+
+// validateNodeCount checks that a pool starts with a number of nodes between the bounds.
+func (a *Autoscaling) validateNodeCount(field string, nodeCount int) []FieldError {
+	if nodeCount < a.Min || nodeCount > a.Max {
+		return []FieldError{{Field: field, Reason: fmt.Sprintf("has to be between the autoscaling bounds %d and %d", a.Min, a.Max), Value: nodeCount}}
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateAutoscaling(t *testing.T) {
+	cluster := &Cluster{NodeCount: 2, Autoscaling: &Autoscaling{Min: 1, Max: 3, MaxSurge: 1}}
+	require.Empty(t, ValidateAutoscaling(cluster), "A node count between the bounds should be valid")
+
+	cluster.NodeCount = 4
+	require.Equal(t, []FieldError{
+		{Field: "Cluster.NodeCount", Reason: "has to be between the autoscaling bounds 1 and 3", Value: 4},
+	}, ValidateAutoscaling(cluster))
+
+	cluster.Autoscaling = &Autoscaling{Min: -1, Max: -2, MaxSurge: -1, MaxUnavailable: -1}
+	cluster.NodeCount = 0
+	require.Equal(t, []string{
+		"Cluster.Autoscaling.Min",
+		"Cluster.Autoscaling.Max",
+		"Cluster.Autoscaling.Max",
+		"Cluster.Autoscaling.MaxSurge",
+		"Cluster.Autoscaling.MaxUnavailable",
+		"Cluster.NodeCount",
+	}, (&ValidationError{Errors: ValidateAutoscaling(cluster)}).Fields())
+
+	cluster = &Cluster{
+		Autoscaling: &Autoscaling{Min: 1, Max: 3},
+		NodePools: []NodePool{
+			{Name: "inherited", NodeCount: 0},
+			{Name: "own", NodeCount: 5, Autoscaling: &Autoscaling{Min: 2, Max: 5}},
+			{Name: "invalid", NodeCount: 1, Autoscaling: &Autoscaling{Min: 2, Max: 1}},
+		},
+	}
+	require.Equal(t, []string{
+		"Cluster.NodePools[0].NodeCount",
+		"Cluster.NodePools[2].Autoscaling.Max",
+		"Cluster.NodePools[2].NodeCount",
+	}, (&ValidationError{Errors: ValidateAutoscaling(cluster)}).Fields(), "Node pools should be checked against their own autoscaling, or else the one of the cluster")
+
+	require.Empty(t, ValidateAutoscaling(&Cluster{NodeCount: 10}), "Clusters without autoscaling should not be checked")
+}
@@ -1,24 +1,39 @@
 package types
 
-import "github.com/kyma-incubator/hydroform/internal/terraform"
+import (
+	"fmt"
+	"strings"
+)
 
 // Cluster contains detailed cluster specification and properties.
 type Cluster struct {
 	// Name specifies the unique name used to identify the cluster.
 	Name string `json:"name"`
-	// KubernetesVersion specifies the Kubernetes version used.
+	// KubernetesVersion specifies the Kubernetes version used. Besides a concrete version, it can be a version prefix such as `1.15`, which stands for the newest matching version, `latest`, or `default`, the version the provider uses by default.
+	// Operations which apply the specification resolve it to a version the provider offers, and reject unsupported versions with a ValidationError. The returned cluster holds the resolved version.
+	// The resolved version is stored in the InternalState and kept by later operations as long as the specification matches it, so that `latest` or `1.15` do not upgrade the cluster when a newer version is released. Imported clusters keep their live version the same way. To upgrade, set a version the stored one does not match.
 	KubernetesVersion string `json:"kubernetesVersion"`
 	// CPU specifies the number of CPUs available in the cluster.
 	CPU int `json:"cpu"`
 	// DiskSizeGB indicates the disk size available in the cluster.
 	DiskSizeGB int `json:"diskSizeGB"`
-	// NodeCount specifies the number of nodes available in the cluster.
+	// NodeCount specifies the number of nodes available in the cluster. It is only used if NodePools is empty. Without Autoscaling, the default pool on Gardener scales between the `autoscaler_min` and `autoscaler_max` custom configurations instead.
 	NodeCount int `json:"nodeCount"`
-	// MachineType specifies the hardware cluster is provisioned on.
+	// MachineType specifies the hardware cluster is provisioned on. It is only used if NodePools is empty.
 	MachineType string `json:"machineType"`
+	// NodePools specifies the groups of nodes of the cluster. If it is empty, the cluster has a single default pool made of its MachineType, NodeCount, DiskSizeGB, and Autoscaling.
+	NodePools []NodePool `json:"nodePools,omitempty"`
+	// Autoscaling makes the default pool, and the node pools without their own settings, scale with the load of the cluster.
+	Autoscaling *Autoscaling `json:"autoscaling,omitempty"`
+	// Networking specifies the networks of the cluster. If it is not set, GCP uses the default network, and Gardener the CIDR custom configurations.
+	Networking *Networking `json:"networking,omitempty"`
 	// Location specifies the location of the actual cluster.
-	Location    string       `json:"location"`
-	ClusterInfo *ClusterInfo `json:"clusterInfo"`
+	Location string `json:"location"`
+	// Labels are attached to the cluster at the provider, for example to tell which team owns it. On GCP, they are the resource labels of the cluster. On Gardener, they are the labels of the Shoot.
+	Labels map[string]string `json:"labels,omitempty"`
+	// Annotations are attached to the cluster for tools, and cannot be used to select clusters. On Gardener, they are the annotations of the Shoot. GCP ignores them, as GKE clusters have no annotations.
+	Annotations map[string]string `json:"annotations,omitempty"`
+	ClusterInfo *ClusterInfo      `json:"clusterInfo"`
 }
 
 // ClusterInfo contains the actual provider-related cluster details retrieved after the cluster was provisioned.
@@ -30,11 +45,19 @@ type ClusterInfo struct {
 	// InternalState contains the Hydroform-specific information used to manage the cluster.
 	InternalState *InternalState `json:"internalState"`
 	Status        *ClusterStatus `json:"status"`
+	// Outputs holds every value the provider reported about the cluster when it was provisioned, updated, or imported, keyed by output name. The values are strings, lists or maps.
+	// GCP reports `endpoint`, `cluster_ca_certificate`, `master_version`, `cluster_ipv4_cidr`, `instance_group_urls`, and `self_link`, the URL of the cluster in the GKE API.
+	// Gardener reports `uid`, `self_link`, the path of the Shoot in the Gardener API, `domain`, and `technical_id`, the name of the Shoot namespace in the seed.
+	Outputs map[string]interface{} `json:"outputs,omitempty"`
 }
 
 // ClusterStatus contains possible values used to indicate the current cluster status.
 type ClusterStatus struct {
 	Phase Phase `json:"phase"`
+	// Labels are the labels the cluster currently carries at the provider.
+	Labels map[string]string `json:"labels,omitempty"`
+	// Annotations are the annotations the cluster currently carries at the provider.
+	Annotations map[string]string `json:"annotations,omitempty"`
 }
 
 // Phase indicates the current status of the cluster.
@@ -55,7 +78,72 @@ const (
 	Unknown Phase = "Unknown"
 )
 
-// InternalState holds the state information of the internal operator which is currently in use. Hydroform uses this information for internal purposes only.
-type InternalState struct {
-	TerraformState *terraform.State
+// NotFoundError is returned when the provider does not know the requested cluster, for example because it has already been deprovisioned.
+type NotFoundError struct {
+	// Name is the name of the cluster.
+	Name string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("cluster %q not found", e.Name)
+}
+
+// IncompleteListError is returned by List along with the clusters it found, when some locations of the provider could not be listed. Clusters in these locations are missing from the list.
+type IncompleteListError struct {
+	// Locations are the locations which could not be listed.
+	Locations []string
+}
+
+func (e *IncompleteListError) Error() string {
+	return fmt.Sprintf("the clusters in %s could not be listed", strings.Join(e.Locations, ", "))
+}
+
+// Copy returns a copy of the cluster specification, which can be changed without affecting the cluster. The ClusterInfo is shared, since it is the state of the cluster rather than its specification.
+func (c *Cluster) Copy() *Cluster {
+	cp := *c
+	cp.Labels = copyStringMap(c.Labels)
+	cp.Annotations = copyStringMap(c.Annotations)
+	cp.Autoscaling = c.Autoscaling.copy()
+	if c.Networking != nil {
+		networking := *c.Networking
+		if c.Networking.AWS != nil {
+			aws := *c.Networking.AWS
+			networking.AWS = &aws
+		}
+		if c.Networking.Azure != nil {
+			azure := *c.Networking.Azure
+			networking.Azure = &azure
+		}
+		cp.Networking = &networking
+	}
+	if c.NodePools != nil {
+		cp.NodePools = make([]NodePool, 0, len(c.NodePools))
+		for _, pool := range c.NodePools {
+			pool.Autoscaling = pool.Autoscaling.copy()
+			pool.Labels = copyStringMap(pool.Labels)
+			pool.Taints = append([]Taint(nil), pool.Taints...)
+			pool.Zones = append([]string(nil), pool.Zones...)
+			cp.NodePools = append(cp.NodePools, pool)
+		}
+	}
+	return &cp
+}
+
+func (a *Autoscaling) copy() *Autoscaling {
+	if a == nil {
+		return nil
+	}
+	cp := *a
+	return &cp
+}
+
+func copyStringMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	cp := make(map[string]string, len(m))
+	for k, v := range m {
+		cp[k] = v
+	}
+	return cp
 }
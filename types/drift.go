@@ -0,0 +1,48 @@
+package types
+
+// DriftReport describes how the live cluster differs from its declared specification and from the state stored for it. Detecting drift never changes the cluster or the stored state.
+type DriftReport struct {
+	// Resources lists the resources that drifted, sorted by address.
+	Resources []ResourceDrift `json:"resources"`
+}
+
+// Drifted indicates whether the live cluster differs from the declared specification or from the stored state.
+func (r *DriftReport) Drifted() bool {
+	return len(r.Resources) > 0
+}
+
+// ResourceDrift describes how a single resource drifted.
+type ResourceDrift struct {
+	// Address identifies the resource in the template, for example `google_container_cluster.gke_cluster`.
+	Address string `json:"address"`
+	// Type is the type of the resource, for example `google_container_cluster`.
+	Type string `json:"type"`
+	// Name is the name of the resource in the template.
+	Name string `json:"name"`
+	// Missing indicates that the resource is declared, or in the stored state, but does not exist anymore.
+	Missing bool `json:"missing,omitempty"`
+	// Change indicates what updating the cluster would do to bring the resource back to the declared specification. It is empty if the resource matches the specification.
+	Change ChangeType `json:"change,omitempty"`
+	// Attributes lists the attributes that drifted, sorted by name.
+	Attributes []AttributeDrift `json:"attributes,omitempty"`
+}
+
+// AttributeDrift describes how a single resource attribute drifted.
+type AttributeDrift struct {
+	// Name is the flattened attribute path, for example `node_config.0.machine_type`.
+	Name string `json:"name"`
+	// Declared is the value of the declared specification. It is only set if it differs from the live value.
+	Declared string `json:"declared,omitempty"`
+	// Stored is the value in the stored state.
+	Stored string `json:"stored"`
+	// Live is the current value of the cluster.
+	Live string `json:"live"`
+	// ChangedOutside indicates that the live value differs from the stored one, because the cluster was changed without Hydroform.
+	ChangedOutside bool `json:"changedOutside,omitempty"`
+	// DiffersFromDeclared indicates that the live value differs from the declared one, so updating the cluster would change it.
+	DiffersFromDeclared bool `json:"differsFromDeclared,omitempty"`
+	// Sensitive indicates that the values are hidden because they contain secrets.
+	Sensitive bool `json:"sensitive,omitempty"`
+	// RequiresReplacement indicates that restoring the declared value forces the resource to be destroyed and created again.
+	RequiresReplacement bool `json:"requiresReplacement,omitempty"`
+}
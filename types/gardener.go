@@ -0,0 +1,169 @@
+package types
+
+import "fmt"
+
+// GardenerConfig is the typed configuration of the Gardener provider. It replaces the CustomConfigurations of the provider, whose keys are given for each field. Validation errors refer to the fields by these keys.
+type GardenerConfig struct {
+	// TargetProvider is the cloud provider Gardener creates the cluster on, which is `gcp`, `aws`, or `azure`. Key: `target_provider`.
+	TargetProvider ProviderType `json:"targetProvider"`
+	// TargetSeed is the seed cluster hosting the control plane of the cluster. Key: `target_seed`.
+	TargetSeed string `json:"targetSeed"`
+	// TargetSecret is the secret binding holding the credentials of the target provider. Key: `target_secret`.
+	TargetSecret string `json:"targetSecret"`
+	// DiskType is the type of the node disks on the target provider. Key: `disk_type`.
+	DiskType string `json:"diskType"`
+	// WorkerCIDR is the IP range of the worker subnet. It is replaced by the NodeCIDR of the cluster networking. Key: `workercidr`.
+	WorkerCIDR string `json:"workerCIDR,omitempty"`
+	// AutoscalerMin is the minimum size of the default worker group. It is only used by clusters without node pools and autoscaling. If both bounds are 0, they are unset and default to the NodeCount of the cluster. Key: `autoscaler_min`.
+	AutoscalerMin int `json:"autoscalerMin,omitempty"`
+	// AutoscalerMax is the maximum size of the default worker group. It is only used by clusters without node pools and autoscaling. Key: `autoscaler_max`.
+	AutoscalerMax int `json:"autoscalerMax,omitempty"`
+	// MaxSurge is the number of nodes added to the default worker group while its nodes are replaced. If both MaxSurge and MaxUnavailable are 0, one node is added at a time. Key: `max_surge`.
+	MaxSurge int `json:"maxSurge,omitempty"`
+	// MaxUnavailable is the number of nodes of the default worker group that can be unavailable while its nodes are replaced. Key: `max_unavailable`.
+	MaxUnavailable int `json:"maxUnavailable,omitempty"`
+	// GCP is the configuration for the `gcp` target provider.
+	GCP *GardenerGCPConfig `json:"gcp,omitempty"`
+	// AWS is the configuration for the `aws` target provider.
+	AWS *GardenerAWSConfig `json:"aws,omitempty"`
+	// Azure is the configuration for the `azure` target provider.
+	Azure *GardenerAzureConfig `json:"azure,omitempty"`
+}
+
+// GardenerGCPConfig is the configuration of a Gardener cluster on GCP.
+type GardenerGCPConfig struct {
+	// Zone is the zone of the nodes. Key: `zone`.
+	Zone string `json:"zone"`
+}
+
+// GardenerAWSConfig is the configuration of a Gardener cluster on AWS. The IP ranges are replaced by the AWS subnets of the cluster networking.
+type GardenerAWSConfig struct {
+	// Zone is the zone of the nodes. Key: `zone`.
+	Zone string `json:"zone"`
+	// VPCCIDR is the IP range of the VPC. Key: `vpccidr`.
+	VPCCIDR string `json:"vpcCIDR,omitempty"`
+	// PublicCIDR is the IP range of the public subnet. Key: `publicscidr`.
+	PublicCIDR string `json:"publicCIDR,omitempty"`
+	// InternalCIDR is the IP range of the internal subnet. Key: `internalscidr`.
+	InternalCIDR string `json:"internalCIDR,omitempty"`
+}
+
+// GardenerAzureConfig is the configuration of a Gardener cluster on Azure. The IP range is replaced by the Azure subnets of the cluster networking.
+type GardenerAzureConfig struct {
+	// VNetCIDR is the IP range of the virtual network. Key: `vnetcidr`.
+	VNetCIDR string `json:"vnetCIDR,omitempty"`
+}
+
+// ParseGardenerConfig converts the custom configurations of a Gardener provider to a GardenerConfig. It returns an error for every value of the wrong type, for example a node count given as a string. Missing values are left empty, and unknown keys are ignored.
+func ParseGardenerConfig(customConfigurations map[string]interface{}) (*GardenerConfig, []FieldError) {
+	p := &configParser{values: customConfigurations}
+	config := &GardenerConfig{
+		TargetProvider: ProviderType(p.string("target_provider")),
+		TargetSeed:     p.string("target_seed"),
+		TargetSecret:   p.string("target_secret"),
+		DiskType:       p.string("disk_type"),
+		WorkerCIDR:     p.string("workercidr"),
+		AutoscalerMin:  p.int("autoscaler_min"),
+		AutoscalerMax:  p.int("autoscaler_max"),
+		MaxSurge:       p.int("max_surge"),
+		MaxUnavailable: p.int("max_unavailable"),
+	}
+	zone := p.string("zone")
+	vpcCIDR, publicCIDR, internalCIDR := p.string("vpccidr"), p.string("publicscidr"), p.string("internalscidr")
+	vnetCIDR := p.string("vnetcidr")
+
+	switch config.TargetProvider {
+	case GCP:
+		config.GCP = &GardenerGCPConfig{Zone: zone}
+	case AWS:
+		config.AWS = &GardenerAWSConfig{Zone: zone, VPCCIDR: vpcCIDR, PublicCIDR: publicCIDR, InternalCIDR: internalCIDR}
+	case Azure:
+		config.Azure = &GardenerAzureConfig{VNetCIDR: vnetCIDR}
+	}
+	return config, p.fieldErrs
+}
+
+// CustomConfigurations converts the configuration to the custom configurations of a Gardener provider. Only the configuration of the target provider is included, and the autoscaler bounds only if one of them is set.
+func (c *GardenerConfig) CustomConfigurations() map[string]interface{} {
+	config := map[string]interface{}{
+		"max_surge":       c.MaxSurge,
+		"max_unavailable": c.MaxUnavailable,
+	}
+	// the bounds are left out if neither is set, so that they are reported as missing instead of as a group of size 0
+	if c.AutoscalerMin != 0 || c.AutoscalerMax != 0 {
+		config["autoscaler_min"] = c.AutoscalerMin
+		config["autoscaler_max"] = c.AutoscalerMax
+	}
+	if c.MaxSurge == 0 && c.MaxUnavailable == 0 {
+		config["max_surge"] = 1
+	}
+	set := func(key, value string) {
+		if value != "" {
+			config[key] = value
+		}
+	}
+	set("target_provider", string(c.TargetProvider))
+	set("target_seed", c.TargetSeed)
+	set("target_secret", c.TargetSecret)
+	set("disk_type", c.DiskType)
+	set("workercidr", c.WorkerCIDR)
+
+	switch {
+	case c.TargetProvider == GCP && c.GCP != nil:
+		set("zone", c.GCP.Zone)
+	case c.TargetProvider == AWS && c.AWS != nil:
+		set("zone", c.AWS.Zone)
+		set("vpccidr", c.AWS.VPCCIDR)
+		set("publicscidr", c.AWS.PublicCIDR)
+		set("internalscidr", c.AWS.InternalCIDR)
+	case c.TargetProvider == Azure && c.Azure != nil:
+		set("vnetcidr", c.Azure.VNetCIDR)
+	}
+	return config
+}
+
+// Copy returns a copy of the configuration, which can be changed without affecting the configuration.
+func (c *GardenerConfig) Copy() *GardenerConfig {
+	cp := *c
+	if c.GCP != nil {
+		gcp := *c.GCP
+		cp.GCP = &gcp
+	}
+	if c.AWS != nil {
+		aws := *c.AWS
+		cp.AWS = &aws
+	}
+	if c.Azure != nil {
+		azure := *c.Azure
+		cp.Azure = &azure
+	}
+	return &cp
+}
+
+// configParser reads typed values from custom configurations, and collects an error for every value of the wrong type.
+type configParser struct {
+	values    map[string]interface{}
+	fieldErrs []FieldError
+}
+
+func (p *configParser) string(key string) string {
+	s, _ := p.value(key, StringConfiguration).(string)
+	return s
+}
+
+func (p *configParser) int(key string) int {
+	i, _ := p.value(key, IntegerConfiguration).(int)
+	return i
+}
+
+func (p *configParser) value(key string, configType ConfigurationType) interface{} {
+	value, ok := p.values[key]
+	if !ok {
+		return nil
+	}
+	converted, ok := convertConfiguration(configType, value)
+	if !ok {
+		p.fieldErrs = append(p.fieldErrs, FieldError{Field: customConfigurationField(key), Reason: fmt.Sprintf("has to be %s", typeNames[configType]), Value: value})
+	}
+	return converted
+}
@@ -0,0 +1,100 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseGardenerConfig(t *testing.T) {
+	config, fieldErrs := ParseGardenerConfig(map[string]interface{}{
+		"target_provider": "aws",
+		"target_seed":     "aws-eu1",
+		"target_secret":   "secret-name",
+		"disk_type":       "gp2",
+		"zone":            "eu-west-1b",
+		"workercidr":      "10.250.0.0/19",
+		"vpccidr":         "10.250.0.0/16",
+		"publicscidr":     "10.250.96.0/22",
+		"internalscidr":   "10.250.112.0/22",
+		"autoscaler_min":  int64(2),
+		"autoscaler_max":  4.0,
+		"max_surge":       uint(4),
+		"max_unavailable": 1,
+		"unknown":         true,
+	})
+	require.Empty(t, fieldErrs)
+	expected := &GardenerConfig{
+		TargetProvider: AWS,
+		TargetSeed:     "aws-eu1",
+		TargetSecret:   "secret-name",
+		DiskType:       "gp2",
+		WorkerCIDR:     "10.250.0.0/19",
+		AutoscalerMin:  2,
+		AutoscalerMax:  4,
+		MaxSurge:       4,
+		MaxUnavailable: 1,
+		AWS: &GardenerAWSConfig{
+			Zone:         "eu-west-1b",
+			VPCCIDR:      "10.250.0.0/16",
+			PublicCIDR:   "10.250.96.0/22",
+			InternalCIDR: "10.250.112.0/22",
+		},
+	}
+	require.Equal(t, expected, config, "Numbers of every type should be converted")
+
+	_, fieldErrs = ParseGardenerConfig(map[string]interface{}{
+		"target_provider": "gcp",
+		"target_seed":     42,
+		"zone":            []string{"europe-west3-b"},
+		"autoscaler_min":  "2",
+		"autoscaler_max":  2.5,
+	})
+	require.Equal(t, []FieldError{
+		{Field: "Provider.CustomConfigurations['target_seed']", Reason: "has to be a string", Value: 42},
+		{Field: "Provider.CustomConfigurations['autoscaler_min']", Reason: "has to be an integer", Value: "2"},
+		{Field: "Provider.CustomConfigurations['autoscaler_max']", Reason: "has to be an integer", Value: 2.5},
+		{Field: "Provider.CustomConfigurations['zone']", Reason: "has to be a string", Value: []string{"europe-west3-b"}},
+	}, fieldErrs)
+}
+
+func TestGardenerConfigCustomConfigurations(t *testing.T) {
+	config := &GardenerConfig{
+		TargetProvider: Azure,
+		TargetSeed:     "az-eu1",
+		TargetSecret:   "secret-name",
+		DiskType:       "standard",
+		WorkerCIDR:     "10.250.0.0/19",
+		AutoscalerMin:  2,
+		AutoscalerMax:  4,
+		GCP:            &GardenerGCPConfig{Zone: "europe-west3-b"},
+		Azure:          &GardenerAzureConfig{VNetCIDR: "10.250.0.0/16"},
+	}
+	custom := config.CustomConfigurations()
+	require.Equal(t, map[string]interface{}{
+		"target_provider": "azure",
+		"target_seed":     "az-eu1",
+		"target_secret":   "secret-name",
+		"disk_type":       "standard",
+		"workercidr":      "10.250.0.0/19",
+		"vnetcidr":        "10.250.0.0/16",
+		"autoscaler_min":  2,
+		"autoscaler_max":  4,
+		"max_surge":       1,
+		"max_unavailable": 0,
+	}, custom, "Only the configuration of the target provider should be included, and one node should be added at a time by default")
+
+	parsed, fieldErrs := ParseGardenerConfig(custom)
+	require.Empty(t, fieldErrs)
+	config.GCP = nil
+	config.MaxSurge = 1
+	require.Equal(t, config, parsed, "The custom configurations should convert back to the configuration")
+
+	config.AutoscalerMin, config.AutoscalerMax = 0, 0
+	custom = config.CustomConfigurations()
+	require.NotContains(t, custom, "autoscaler_min", "Unset bounds should be left out")
+	require.NotContains(t, custom, "autoscaler_max", "Unset bounds should be left out")
+
+	config.AutoscalerMin = 3
+	require.Equal(t, 0, config.CustomConfigurations()["autoscaler_max"], "A missing bound should be included when the other one is set, so that it is validated")
+}
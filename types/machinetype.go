@@ -0,0 +1,13 @@
+package types
+
+// MachineType is a type of machine the nodes of a cluster can run on.
+type MachineType struct {
+	// Name is the name of the machine type, for example `n1-standard-4`.
+	Name string `json:"name"`
+	// CPUs is the number of virtual CPUs of the machine.
+	CPUs int `json:"cpus"`
+	// MemoryMB is the memory of the machine in megabytes.
+	MemoryMB int `json:"memoryMB"`
+	// GPUs is the number of GPUs attached to the machine. GCP attaches GPUs separately, so its machine types have none.
+	GPUs int `json:"gpus,omitempty"`
+}
@@ -0,0 +1,126 @@
+package types
+
+import (
+	"fmt"
+	"net"
+)
+
+// Networking specifies the networks of the cluster. The IP ranges are CIDR blocks, for example `10.250.0.0/19`. The ranges of the nodes, pods, and services cannot overlap.
+type Networking struct {
+	// Network is the name of the existing VPC network the cluster runs in. If it is not set, the default network is used. Gardener does not support it.
+	Network string `json:"network,omitempty"`
+	// Subnetwork is the name of the subnetwork the nodes run in. On GCP, it is created with NodeCIDR if that is set, and must exist in Network otherwise. Gardener does not support it.
+	Subnetwork string `json:"subnetwork,omitempty"`
+	// NodeCIDR is the IP range of the nodes. On Gardener, it is also the worker subnet.
+	NodeCIDR string `json:"nodeCIDR,omitempty"`
+	// PodCIDR is the IP range of the pods.
+	PodCIDR string `json:"podCIDR,omitempty"`
+	// ServiceCIDR is the IP range of the services.
+	ServiceCIDR string `json:"serviceCIDR,omitempty"`
+	// AWS specifies the subnets Gardener creates on AWS. It is only used with the `aws` target provider.
+	AWS *AWSSubnets `json:"aws,omitempty"`
+	// Azure specifies the subnets Gardener creates on Azure. It is only used with the `azure` target provider.
+	Azure *AzureSubnets `json:"azure,omitempty"`
+}
+
+// AWSSubnets are the subnets of a cluster on AWS. NodeCIDR, PublicCIDR, and InternalCIDR have to be within VPCCIDR, and cannot overlap.
+type AWSSubnets struct {
+	// VPCCIDR is the IP range of the VPC created for the cluster.
+	VPCCIDR string `json:"vpcCIDR"`
+	// PublicCIDR is the IP range of the public subnet, used for bastions and load balancers.
+	PublicCIDR string `json:"publicCIDR"`
+	// InternalCIDR is the IP range of the private subnet, used for internal load balancers.
+	InternalCIDR string `json:"internalCIDR"`
+}
+
+// AzureSubnets are the subnets of a cluster on Azure. NodeCIDR has to be within VNetCIDR.
+type AzureSubnets struct {
+	// VNetCIDR is the IP range of the virtual network created for the cluster.
+	VNetCIDR string `json:"vnetCIDR"`
+}
+
+// ValidateNetworking checks that the IP ranges of the cluster networks are valid CIDR blocks, that the ranges of the nodes, pods, and services do not overlap, and that the subnets are within the network they belong to.
+func ValidateNetworking(networking *Networking) []FieldError {
+	if networking == nil {
+		return nil
+	}
+
+	v := &cidrValidator{blocks: map[string]*net.IPNet{}}
+	node := v.parse("Cluster.Networking.NodeCIDR", networking.NodeCIDR)
+	pod := v.parse("Cluster.Networking.PodCIDR", networking.PodCIDR)
+	service := v.parse("Cluster.Networking.ServiceCIDR", networking.ServiceCIDR)
+	v.disjoint(node, pod, service)
+
+	if aws := networking.AWS; aws != nil {
+		vpc := v.parse("Cluster.Networking.AWS.VPCCIDR", aws.VPCCIDR)
+		public := v.parse("Cluster.Networking.AWS.PublicCIDR", aws.PublicCIDR)
+		internal := v.parse("Cluster.Networking.AWS.InternalCIDR", aws.InternalCIDR)
+		v.within(vpc, node, public, internal)
+		v.disjoint(node, public, internal)
+		v.disjoint(vpc, pod)
+		v.disjoint(vpc, service)
+	}
+	if azure := networking.Azure; azure != nil {
+		vnet := v.parse("Cluster.Networking.Azure.VNetCIDR", azure.VNetCIDR)
+		v.within(vnet, node)
+		v.disjoint(vnet, pod)
+		v.disjoint(vnet, service)
+	}
+	return v.fieldErrs
+}
+
+// cidrValidator collects the errors of the CIDR blocks of a cluster. Blocks that are not set or not valid are skipped by the overlap and containment checks.
+type cidrValidator struct {
+	blocks    map[string]*net.IPNet
+	fieldErrs []FieldError
+}
+
+// parse returns the field of the block, or an empty string if the block is not set or not valid.
+func (v *cidrValidator) parse(field, cidr string) string {
+	if cidr == "" {
+		return ""
+	}
+	ip, block, err := net.ParseCIDR(cidr)
+	if err != nil {
+		v.fieldErrs = append(v.fieldErrs, FieldError{Field: field, Reason: "must be a CIDR block, for example 10.250.0.0/16", Value: cidr})
+		return ""
+	}
+	if !ip.Equal(block.IP) {
+		v.fieldErrs = append(v.fieldErrs, FieldError{Field: field, Reason: fmt.Sprintf("cannot have host bits set, use %s", block), Value: cidr})
+		return ""
+	}
+	v.blocks[field] = block
+	return field
+}
+
+// disjoint reports the blocks overlapping with a block before them.
+func (v *cidrValidator) disjoint(fields ...string) {
+	for i, field := range fields {
+		for _, other := range fields[:i] {
+			if field == "" || other == "" {
+				continue
+			}
+			a, b := v.blocks[field], v.blocks[other]
+			if a.Contains(b.IP) || b.Contains(a.IP) {
+				v.fieldErrs = append(v.fieldErrs, FieldError{Field: field, Reason: fmt.Sprintf("cannot overlap with %s", other), Value: a.String()})
+			}
+		}
+	}
+}
+
+// within reports the blocks that are not part of the outer block.
+func (v *cidrValidator) within(outer string, fields ...string) {
+	if outer == "" {
+		return
+	}
+	outerOnes, _ := v.blocks[outer].Mask.Size()
+	for _, field := range fields {
+		if field == "" {
+			continue
+		}
+		inner := v.blocks[field]
+		if ones, _ := inner.Mask.Size(); ones < outerOnes || !v.blocks[outer].Contains(inner.IP) {
+			v.fieldErrs = append(v.fieldErrs, FieldError{Field: field, Reason: fmt.Sprintf("has to be within %s", outer), Value: inner.String()})
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateNetworking(t *testing.T) {
+	require.Empty(t, ValidateNetworking(nil))
+
+	networking := &Networking{
+		NodeCIDR:    "10.250.0.0/19",
+		PodCIDR:     "100.96.0.0/11",
+		ServiceCIDR: "100.64.0.0/13",
+		AWS: &AWSSubnets{
+			VPCCIDR:      "10.250.0.0/16",
+			PublicCIDR:   "10.250.96.0/22",
+			InternalCIDR: "10.250.112.0/22",
+		},
+		Azure: &AzureSubnets{VNetCIDR: "10.250.0.0/16"},
+	}
+	require.Empty(t, ValidateNetworking(networking), "Disjoint ranges within their networks should be valid")
+
+	networking = &Networking{
+		NodeCIDR:    "10.250.0.0/19",
+		PodCIDR:     "10.250.16.0/20",
+		ServiceCIDR: "100.64.0.1/13",
+		AWS: &AWSSubnets{
+			VPCCIDR:      "10.250.0.0/24",
+			PublicCIDR:   "10.251.96.0/22",
+			InternalCIDR: "10.250.0.0/16",
+		},
+		Azure: &AzureSubnets{VNetCIDR: "10.250.0"},
+	}
+	require.Equal(t, []FieldError{
+		{Field: "Cluster.Networking.ServiceCIDR", Reason: "cannot have host bits set, use 100.64.0.0/13", Value: "100.64.0.1/13"},
+		{Field: "Cluster.Networking.PodCIDR", Reason: "cannot overlap with Cluster.Networking.NodeCIDR", Value: "10.250.16.0/20"},
+		{Field: "Cluster.Networking.NodeCIDR", Reason: "has to be within Cluster.Networking.AWS.VPCCIDR", Value: "10.250.0.0/19"},
+		{Field: "Cluster.Networking.AWS.PublicCIDR", Reason: "has to be within Cluster.Networking.AWS.VPCCIDR", Value: "10.251.96.0/22"},
+		{Field: "Cluster.Networking.AWS.InternalCIDR", Reason: "has to be within Cluster.Networking.AWS.VPCCIDR", Value: "10.250.0.0/16"},
+		{Field: "Cluster.Networking.AWS.InternalCIDR", Reason: "cannot overlap with Cluster.Networking.NodeCIDR", Value: "10.250.0.0/16"},
+		{Field: "Cluster.Networking.Azure.VNetCIDR", Reason: "must be a CIDR block, for example 10.250.0.0/16", Value: "10.250.0"},
+	}, ValidateNetworking(networking))
+}
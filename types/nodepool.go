@@ -0,0 +1,43 @@
+package types
+
+// NodePool is a group of nodes sharing the same configuration. On GCP, each pool is a GKE node pool. On Gardener, each pool is a worker group of the Shoot.
+type NodePool struct {
+	// Name identifies the pool within the cluster.
+	Name string `json:"name"`
+	// MachineType specifies the hardware the nodes of the pool run on.
+	MachineType string `json:"machineType"`
+	// NodeCount is the number of nodes the pool starts with. Unless the pool is autoscaled, it keeps this size.
+	NodeCount int `json:"nodeCount"`
+	// Autoscaling makes the pool scale with the load of the cluster. If it is not set, the Autoscaling of the cluster is used.
+	Autoscaling *Autoscaling `json:"autoscaling,omitempty"`
+	// DiskSizeGB is the disk size of each node. If it is not set, the DiskSizeGB of the cluster is used.
+	DiskSizeGB int `json:"diskSizeGB,omitempty"`
+	// DiskType is the provider-specific type of the node disks, for example `pd-ssd` on GCP. If it is not set, the default of the provider is used, which is the `disk_type` custom configuration on Gardener.
+	DiskType string `json:"diskType,omitempty"`
+	// Labels are the Kubernetes labels of the nodes of the pool.
+	Labels map[string]string `json:"labels,omitempty"`
+	// Taints are the Kubernetes taints of the nodes of the pool. GCP does not support them.
+	Taints []Taint `json:"taints,omitempty"`
+	// Zones are the zones the nodes of the pool run in. GCP does not support them. On Gardener, the nodes of all pools are spread over the zones of all pools.
+	Zones []string `json:"zones,omitempty"`
+}
+
+// Taint keeps pods that do not tolerate it from being scheduled on a node.
+type Taint struct {
+	Key   string `json:"key"`
+	Value string `json:"value,omitempty"`
+	// Effect is what happens to the pods not tolerating the taint.
+	Effect TaintEffect `json:"effect"`
+}
+
+// TaintEffect lists the effects a taint can have on the pods not tolerating it.
+type TaintEffect string
+
+const (
+	// NoSchedule prevents new pods from being scheduled on the node.
+	NoSchedule TaintEffect = "NoSchedule"
+	// PreferNoSchedule avoids scheduling new pods on the node, if possible.
+	PreferNoSchedule TaintEffect = "PreferNoSchedule"
+	// NoExecute prevents new pods from being scheduled on the node, and evicts the pods already running on it.
+	NoExecute TaintEffect = "NoExecute"
+)
@@ -0,0 +1,96 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Plan describes the changes an operation would make to the cluster infrastructure, without applying them.
+type Plan struct {
+	// Resources lists the resources that would be changed, sorted by address.
+	Resources []ResourceChange `json:"resources"`
+}
+
+// ResourceChange describes the change planned for a single resource.
+type ResourceChange struct {
+	// Address identifies the resource in the template, for example `google_container_cluster.gke_cluster`.
+	Address string `json:"address"`
+	// Type is the type of the resource, for example `google_container_cluster`.
+	Type string `json:"type"`
+	// Name is the name of the resource in the template.
+	Name string `json:"name"`
+	// Change indicates what would happen to the resource.
+	Change ChangeType `json:"change"`
+	// Attributes lists the attribute changes of the resource, sorted by name.
+	Attributes []AttributeChange `json:"attributes,omitempty"`
+}
+
+// AttributeChange describes the change planned for a single resource attribute.
+type AttributeChange struct {
+	// Name is the flattened attribute path, for example `node_config.0.machine_type`.
+	Name string `json:"name"`
+	// Old is the current value of the attribute.
+	Old string `json:"old"`
+	// New is the planned value of the attribute. It is empty if the value is only known after applying the change.
+	New string `json:"new"`
+	// Computed indicates that the new value is only known after applying the change.
+	Computed bool `json:"computed,omitempty"`
+	// Sensitive indicates that the values are hidden because they contain secrets.
+	Sensitive bool `json:"sensitive,omitempty"`
+	// RequiresReplacement indicates that changing the attribute forces the resource to be destroyed and created again.
+	RequiresReplacement bool `json:"requiresReplacement,omitempty"`
+}
+
+// ChangeType indicates what would happen to a resource.
+type ChangeType string
+
+const (
+	// ChangeCreate indicates that the resource would be created.
+	ChangeCreate ChangeType = "create"
+	// ChangeUpdate indicates that the resource would be updated in place.
+	ChangeUpdate ChangeType = "update"
+	// ChangeDestroy indicates that the resource would be destroyed.
+	ChangeDestroy ChangeType = "destroy"
+	// ChangeReplace indicates that the resource would be destroyed and created again.
+	ChangeReplace ChangeType = "replace"
+)
+
+// ResourcesWith returns the resources of the plan that would undergo the given change.
+func (p *Plan) ResourcesWith(change ChangeType) []ResourceChange {
+	var resources []ResourceChange
+	for _, r := range p.Resources {
+		if r.Change == change {
+			resources = append(resources, r)
+		}
+	}
+	return resources
+}
+
+// Empty indicates whether the plan does not change anything.
+func (p *Plan) Empty() bool {
+	return len(p.Resources) == 0
+}
+
+// ReplacementError is returned when updating a cluster would replace the resources holding the cluster itself, which recreates the cluster from scratch.
+type ReplacementError struct {
+	// Resources lists the planned changes that require the replacement.
+	Resources []ResourceChange
+}
+
+func (e *ReplacementError) Error() string {
+	changes := make([]string, 0, len(e.Resources))
+	for _, r := range e.Resources {
+		var attributes []string
+		for _, a := range r.Attributes {
+			if a.RequiresReplacement {
+				attributes = append(attributes, a.Name)
+			}
+		}
+		if len(attributes) == 0 {
+			changes = append(changes, fmt.Sprintf("%s (%s)", r.Address, r.Change))
+			continue
+		}
+		changes = append(changes, fmt.Sprintf("%s (%s forced by: %s)", r.Address, r.Change, strings.Join(attributes, ", ")))
+	}
+	return fmt.Sprintf("the update requires recreating the cluster: %s; allow replacement to apply it anyway", strings.Join(changes, "; "))
+}
@@ -10,6 +10,8 @@ type Provider struct {
 	CredentialsFilePath string `json:"credentialsFilePath"`
 	// CustomConfigurations is a list of custom properties relevant for the chosen provider.
 	CustomConfigurations map[string]interface{} `json:"customConfigurations"`
+	// Gardener is the typed configuration of the Gardener provider. If it is set, it is used instead of CustomConfigurations.
+	Gardener *GardenerConfig `json:"gardener,omitempty"`
 }
 
 // ProviderType lists available cloud providers.
@@ -25,3 +27,18 @@ const (
 	// Gardener stands for the Gardener platform.
 	Gardener ProviderType = "gardener"
 )
+
+// Copy returns a copy of the provider, which can be changed without affecting the provider.
+func (p *Provider) Copy() *Provider {
+	cp := *p
+	if p.CustomConfigurations != nil {
+		cp.CustomConfigurations = make(map[string]interface{}, len(p.CustomConfigurations))
+		for k, v := range p.CustomConfigurations {
+			cp.CustomConfigurations[k] = v
+		}
+	}
+	if p.Gardener != nil {
+		cp.Gardener = p.Gardener.Copy()
+	}
+	return &cp
+}
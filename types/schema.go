@@ -0,0 +1,132 @@
+package types
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ProviderSchema describes the custom configurations a provider accepts, so that they can be checked, documented, or rendered as a form.
+type ProviderSchema struct {
+	// Type is the provider the schema belongs to.
+	Type ProviderType `json:"type"`
+	// CustomConfigurations lists the keys the provider accepts in Provider.CustomConfigurations, in the order they are validated.
+	CustomConfigurations []ConfigurationSchema `json:"customConfigurations"`
+}
+
+// ConfigurationSchema describes a single key of the custom configurations.
+type ConfigurationSchema struct {
+	// Key is the key of the custom configuration, for example `target_provider`.
+	Key string `json:"key"`
+	// Type is the type the value has to be of.
+	Type ConfigurationType `json:"type"`
+	// Required indicates that the key always has to be set. Keys needed only for some clusters are not required, their description tells when they are needed.
+	Required bool `json:"required,omitempty"`
+	// Default is the value used if the key is not set.
+	Default interface{} `json:"default,omitempty"`
+	// AllowedValues lists the values the key can have. Any value of the right type is allowed if it is empty.
+	AllowedValues []interface{} `json:"allowedValues,omitempty"`
+	// Description explains what the key is used for.
+	Description string `json:"description"`
+}
+
+// ConfigurationType is the type of the value of a custom configuration.
+type ConfigurationType string
+
+const (
+	// StringConfiguration is a string value.
+	StringConfiguration ConfigurationType = "string"
+	// IntegerConfiguration is a whole number of any Go type, including the float64 values JSON decoders produce.
+	IntegerConfiguration ConfigurationType = "integer"
+)
+
+// Validate checks the custom configurations against the schema. It reports missing required keys, values of the wrong type or not among the allowed ones, and unknown keys, which are most likely typos.
+func (s *ProviderSchema) Validate(customConfigurations map[string]interface{}) []FieldError {
+	var fieldErrs []FieldError
+	known := map[string]bool{}
+	for _, config := range s.CustomConfigurations {
+		known[config.Key] = true
+		value, ok := customConfigurations[config.Key]
+		if !ok {
+			if config.Required {
+				fieldErrs = append(fieldErrs, FieldError{Field: customConfigurationField(config.Key), Reason: "cannot be empty"})
+			}
+			continue
+		}
+		if fieldErr := config.validate(value); fieldErr != nil {
+			fieldErrs = append(fieldErrs, *fieldErr)
+		}
+	}
+
+	var unknown []string
+	for key := range customConfigurations {
+		if !known[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	for _, key := range unknown {
+		fieldErrs = append(fieldErrs, FieldError{Field: customConfigurationField(key), Reason: fmt.Sprintf("is not a custom configuration of %s", s.Type), Value: customConfigurations[key]})
+	}
+	return fieldErrs
+}
+
+// ApplyDefaults sets the keys with a default that are not set in the custom configurations.
+func (s *ProviderSchema) ApplyDefaults(customConfigurations map[string]interface{}) {
+	for _, config := range s.CustomConfigurations {
+		if _, ok := customConfigurations[config.Key]; !ok && config.Default != nil {
+			customConfigurations[config.Key] = config.Default
+		}
+	}
+}
+
+func (c *ConfigurationSchema) validate(value interface{}) *FieldError {
+	converted, ok := convertConfiguration(c.Type, value)
+	if !ok {
+		return &FieldError{Field: customConfigurationField(c.Key), Reason: fmt.Sprintf("has to be %s", typeNames[c.Type]), Value: value}
+	}
+	if len(c.AllowedValues) == 0 {
+		return nil
+	}
+	allowed := make([]string, 0, len(c.AllowedValues))
+	for _, v := range c.AllowedValues {
+		if v == converted {
+			return nil
+		}
+		allowed = append(allowed, fmt.Sprint(v))
+	}
+	return &FieldError{Field: customConfigurationField(c.Key), Reason: fmt.Sprintf("has to be one of: %s", strings.Join(allowed, ", ")), Value: value}
+}
+
+// typeNames are the names of the configuration types in the field errors.
+var typeNames = map[ConfigurationType]string{
+	StringConfiguration:  "a string",
+	IntegerConfiguration: "an integer",
+}
+
+// convertConfiguration converts a value to the Go type of the configuration type, which is string or int, and reports whether the value has the configuration type.
+func convertConfiguration(configType ConfigurationType, value interface{}) (interface{}, bool) {
+	switch configType {
+	case StringConfiguration:
+		s, ok := value.(string)
+		return s, ok
+	case IntegerConfiguration:
+		switch v := reflect.ValueOf(value); v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return int(v.Int()), true
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return int(v.Uint()), true
+		case reflect.Float32, reflect.Float64:
+			if f := v.Float(); f == math.Trunc(f) && !math.IsInf(f, 0) {
+				return int(f), true
+			}
+		}
+	}
+	return nil, false
+}
+
+func customConfigurationField(key string) string {
+	return fmt.Sprintf("Provider.CustomConfigurations['%s']", key)
+}
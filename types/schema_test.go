@@ -0,0 +1,52 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProviderSchemaValidate(t *testing.T) {
+	schema := &ProviderSchema{
+		Type: Gardener,
+		CustomConfigurations: []ConfigurationSchema{
+			{Key: "target_provider", Type: StringConfiguration, Required: true, AllowedValues: []interface{}{"gcp", "azure", "aws"}},
+			{Key: "target_seed", Type: StringConfiguration, Required: true},
+			{Key: "autoscaler_max", Type: IntegerConfiguration},
+			{Key: "max_surge", Type: IntegerConfiguration, Default: 1, AllowedValues: []interface{}{0, 1, 2}},
+		},
+	}
+	require.Empty(t, schema.Validate(map[string]interface{}{
+		"target_provider": "aws",
+		"target_seed":     "aws-eu1",
+		"autoscaler_max":  4.0,
+		"max_surge":       int32(2),
+	}), "Integers of every type should be accepted")
+
+	require.Equal(t, []FieldError{
+		{Field: "Provider.CustomConfigurations['target_provider']", Reason: "has to be one of: gcp, azure, aws", Value: "nimbus"},
+		{Field: "Provider.CustomConfigurations['target_seed']", Reason: "cannot be empty"},
+		{Field: "Provider.CustomConfigurations['autoscaler_max']", Reason: "has to be an integer", Value: "4"},
+		{Field: "Provider.CustomConfigurations['max_surge']", Reason: "has to be one of: 0, 1, 2", Value: 3},
+		{Field: "Provider.CustomConfigurations['autoscaller_max']", Reason: "is not a custom configuration of gardener", Value: 4},
+	}, schema.Validate(map[string]interface{}{
+		"target_provider": "nimbus",
+		"autoscaler_max":  "4",
+		"autoscaller_max": 4,
+		"max_surge":       3,
+	}))
+}
+
+func TestProviderSchemaApplyDefaults(t *testing.T) {
+	schema := &ProviderSchema{
+		Type: Gardener,
+		CustomConfigurations: []ConfigurationSchema{
+			{Key: "target_provider", Type: StringConfiguration, Required: true},
+			{Key: "max_surge", Type: IntegerConfiguration, Default: 1},
+			{Key: "max_unavailable", Type: IntegerConfiguration, Default: 0},
+		},
+	}
+	config := map[string]interface{}{"max_surge": 3}
+	schema.ApplyDefaults(config)
+	require.Equal(t, map[string]interface{}{"max_surge": 3, "max_unavailable": 0}, config, "Only the keys which are not set should get their default")
+}
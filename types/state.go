@@ -0,0 +1,128 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/kyma-incubator/hydroform/internal/terraform"
+)
+
+// InternalStateVersion is the version of the format InternalState is serialized in by this version of Hydroform.
+const InternalStateVersion = 2
+
+// InternalState holds the state information of the internal operator which is currently in use. Hydroform uses this information for internal purposes only.
+//
+// InternalState is serialized to JSON as a versioned envelope:
+//
+//	{
+//	  "version": 2,
+//	  "operator": "terraform",
+//	  "provider": "gcp",
+//	  "kubernetesVersion": "1.15.4",
+//	  "terraformState": { ... }
+//	}
+//
+// The version is the format of the envelope, see InternalStateVersion. The Terraform state is stored in the format of Terraform state files, which carries its own version.
+// States saved by older versions of Hydroform are upgraded when they are read. States saved by newer versions are refused.
+type InternalState struct {
+	// Operator is the type of the operator that created the state, for example `terraform`.
+	Operator string
+	// Provider is the type of the provider the cluster runs on.
+	Provider ProviderType
+	// KubernetesVersion is the concrete version the Kubernetes version of the cluster specification was resolved to. As long as the specification matches it, it is used instead of resolving the specification again, so that aliases such as `latest` do not upgrade the cluster.
+	// It is empty in states saved by older versions of Hydroform.
+	KubernetesVersion string
+	TerraformState    *terraform.State
+}
+
+type internalStateEnvelope struct {
+	Version           int             `json:"version"`
+	Operator          string          `json:"operator,omitempty"`
+	Provider          ProviderType    `json:"provider,omitempty"`
+	KubernetesVersion string          `json:"kubernetesVersion,omitempty"`
+	TerraformState    json.RawMessage `json:"terraformState,omitempty"`
+}
+
+// MarshalJSON encodes the state in the current version of the envelope.
+func (s *InternalState) MarshalJSON() ([]byte, error) {
+	envelope := internalStateEnvelope{
+		Version:           InternalStateVersion,
+		Operator:          s.Operator,
+		Provider:          s.Provider,
+		KubernetesVersion: s.KubernetesVersion,
+	}
+	if s.TerraformState != nil {
+		var buf bytes.Buffer
+		if err := terraform.WriteState(s.TerraformState, &buf); err != nil {
+			return nil, err
+		}
+		envelope.TerraformState = buf.Bytes()
+	}
+	return json.Marshal(envelope)
+}
+
+// UnmarshalJSON decodes a state in any version of the envelope up to InternalStateVersion, upgrading it to the current version.
+func (s *InternalState) UnmarshalJSON(data []byte) error {
+	var envelope internalStateEnvelope
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return err
+	}
+	// the first version had no version field
+	if envelope.Version == 0 {
+		envelope.Version = 1
+	}
+	if envelope.Version > InternalStateVersion {
+		return fmt.Errorf("internal state version %d is not supported, the latest supported version is %d; upgrade Hydroform to read it", envelope.Version, InternalStateVersion)
+	}
+
+	var state *terraform.State
+	if len(envelope.TerraformState) > 0 && string(envelope.TerraformState) != "null" {
+		var err error
+		state, err = terraform.ReadState(bytes.NewReader(envelope.TerraformState))
+		if err != nil {
+			return err
+		}
+	}
+
+	*s = InternalState{
+		Operator:          envelope.Operator,
+		Provider:          envelope.Provider,
+		KubernetesVersion: envelope.KubernetesVersion,
+		TerraformState:    state,
+	}
+	for version := envelope.Version; version < InternalStateVersion; version++ {
+		if err := internalStateMigrations[version](s); err != nil {
+			return fmt.Errorf("unable to upgrade internal state from version %d: %s", version, err)
+		}
+	}
+	return nil
+}
+
+// internalStateMigrations upgrade a state read from an older version of the envelope. The migration at a given version upgrades the state from that version to the next one.
+var internalStateMigrations = map[int]func(s *InternalState) error{
+	1: migrateInternalStateV1,
+}
+
+// clusterResourceProviders maps the types of the resources holding a cluster to the provider of the cluster.
+var clusterResourceProviders = map[string]ProviderType{
+	"google_container_cluster": GCP,
+	"gardener_shoot":           Gardener,
+}
+
+// migrateInternalStateV1 upgrades states which only held the Terraform state. Terraform was the only operator at that time, and the provider is recognized by the resource holding the cluster.
+func migrateInternalStateV1(s *InternalState) error {
+	s.Operator = "terraform"
+	if s.TerraformState == nil {
+		return nil
+	}
+	for _, module := range s.TerraformState.Modules {
+		for _, resource := range module.Resources {
+			if provider, ok := clusterResourceProviders[resource.Type]; ok {
+				s.Provider = provider
+				return nil
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hashicorp/terraform/terraform"
+	"github.com/stretchr/testify/require"
+)
+
+func newTerraformState(resourceType string) *terraform.State {
+	state := terraform.NewState()
+	state.RootModule().Resources[resourceType+".cluster"] = &terraform.ResourceState{
+		Type: resourceType,
+		Primary: &terraform.InstanceState{
+			ID:         "hydro-cluster",
+			Attributes: map[string]string{"id": "hydro-cluster"},
+		},
+	}
+	return state
+}
+
+func TestInternalStateJSON(t *testing.T) {
+	state := &InternalState{
+		Operator:          "terraform",
+		Provider:          Gardener,
+		KubernetesVersion: "1.15.4",
+		TerraformState:    newTerraformState("gardener_shoot"),
+	}
+
+	data, err := json.Marshal(state)
+	require.NoError(t, err)
+
+	var envelope map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &envelope))
+	require.Equal(t, float64(InternalStateVersion), envelope["version"], "The envelope should record its version")
+	require.Equal(t, "terraform", envelope["operator"])
+	require.Equal(t, "gardener", envelope["provider"])
+
+	decoded := &InternalState{}
+	require.NoError(t, json.Unmarshal(data, decoded))
+	require.Equal(t, state.Operator, decoded.Operator)
+	require.Equal(t, state.Provider, decoded.Provider)
+	require.Equal(t, state.KubernetesVersion, decoded.KubernetesVersion)
+	require.True(t, state.TerraformState.Equal(decoded.TerraformState), "The Terraform state should be preserved")
+}
+
+func TestInternalStateMigration(t *testing.T) {
+	// the first version was the default encoding of the struct holding the Terraform state only
+	legacy, err := json.Marshal(struct {
+		TerraformState *terraform.State
+	}{TerraformState: newTerraformState("google_container_cluster")})
+	require.NoError(t, err)
+
+	decoded := &InternalState{}
+	require.NoError(t, json.Unmarshal(legacy, decoded), "Version 1 states should be read")
+	require.Equal(t, "terraform", decoded.Operator, "The operator should be set by the migration")
+	require.Equal(t, GCP, decoded.Provider, "The provider should be recognized by the cluster resource")
+	require.NotNil(t, decoded.TerraformState.RootModule().Resources["google_container_cluster.cluster"])
+
+	err = json.Unmarshal([]byte(`{"version": 99, "operator": "terraform"}`), decoded)
+	require.Error(t, err, "States from newer versions should be refused")
+}
@@ -0,0 +1,45 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ValidationError is returned when the cluster or provider specification is not valid. It lists every invalid field, so that all of them can be fixed at once.
+type ValidationError struct {
+	// Errors lists the invalid fields in the order they were checked.
+	Errors []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	b.WriteString("input validation failed with the following information:")
+	for _, f := range e.Errors {
+		b.WriteString("\n - ")
+		b.WriteString(f.Error())
+	}
+	return b.String()
+}
+
+// Fields returns the names of the invalid fields.
+func (e *ValidationError) Fields() []string {
+	fields := make([]string, 0, len(e.Errors))
+	for _, f := range e.Errors {
+		fields = append(fields, f.Field)
+	}
+	return fields
+}
+
+// FieldError describes why a single field of the specification is not valid.
+type FieldError struct {
+	// Field is the path of the field, for example `Cluster.NodeCount` or `Provider.CustomConfigurations['workercidr']`.
+	Field string `json:"field"`
+	// Reason explains what is wrong with the field, for example `cannot be empty`.
+	Reason string `json:"reason"`
+	// Value is the invalid value. It is nil if the field is missing.
+	Value interface{} `json:"value,omitempty"`
+}
+
+func (e FieldError) Error() string {
+	return fmt.Sprintf("%s %s", e.Field, e.Reason)
+}
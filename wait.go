@@ -0,0 +1,158 @@
+package hydroform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kyma-incubator/hydroform/types"
+)
+
+const (
+	defaultPollInterval      = 10 * time.Second
+	defaultMaxPollInterval   = 2 * time.Minute
+	defaultBackoffMultiplier = 1.5
+)
+
+// WaitOption configures WaitFor.
+type WaitOption func(o *waitOptions)
+
+type waitOptions struct {
+	interval       time.Duration
+	maxInterval    time.Duration
+	multiplier     float64
+	onPhaseChange  func(previous, current types.Phase)
+	notFoundAsDone bool
+}
+
+// PollInterval sets the time to wait between the first two status checks. It defaults to 10 seconds.
+func PollInterval(d time.Duration) WaitOption {
+	return func(o *waitOptions) {
+		o.interval = d
+	}
+}
+
+// MaxPollInterval caps the time to wait between two status checks. It defaults to 2 minutes.
+func MaxPollInterval(d time.Duration) WaitOption {
+	return func(o *waitOptions) {
+		o.maxInterval = d
+	}
+}
+
+// BackoffMultiplier sets the factor the interval between status checks grows by while the phase does not change. It defaults to 1.5. A multiplier of 1 polls at a constant interval.
+func BackoffMultiplier(m float64) WaitOption {
+	return func(o *waitOptions) {
+		o.multiplier = m
+	}
+}
+
+// OnPhaseChange sets a function called every time the observed phase changes, including the first observation, for which the previous phase is empty.
+func OnPhaseChange(f func(previous, current types.Phase)) WaitOption {
+	return func(o *waitOptions) {
+		o.onPhaseChange = f
+	}
+}
+
+// NotFoundAsDone makes WaitFor succeed once the provider no longer finds the cluster. Use it to wait for the end of a deprovisioning.
+func NotFoundAsDone() WaitOption {
+	return func(o *waitOptions) {
+		o.notFoundAsDone = true
+	}
+}
+
+// UnexpectedPhaseError is returned by WaitFor when the cluster reaches a terminal phase other than the awaited one.
+type UnexpectedPhaseError struct {
+	// Phase is the terminal phase the cluster reached.
+	Phase types.Phase
+	// Expected is the phase WaitFor was waiting for.
+	Expected types.Phase
+}
+
+func (e *UnexpectedPhaseError) Error() string {
+	return fmt.Sprintf("cluster reached the %s phase while waiting for %s", e.Phase, e.Expected)
+}
+
+// terminalPhases are the phases a cluster does not leave without user action.
+var terminalPhases = map[types.Phase]bool{
+	types.Errored: true,
+}
+
+// WaitFor polls the status of the cluster until it reaches the given phase. The interval between the checks grows exponentially while the phase does not change and is reset when it does.
+// If the cluster reaches a terminal phase, such as Errored, WaitFor stops with an UnexpectedPhaseError. Errors returned by the provider also stop the wait, except for a NotFoundError if the NotFoundAsDone option is given.
+// The last observed status is returned along with the error. Cancelling the context stops the wait and returns the error of the context.
+func WaitFor(ctx context.Context, cluster *types.Cluster, provider *types.Provider, phase types.Phase, opts ...WaitOption) (*types.ClusterStatus, error) {
+	return defaultClient.WaitFor(ctx, cluster, provider, phase, opts...)
+}
+
+// WaitFor polls the status of the cluster until it reaches the given phase. See the package-level WaitFor function for details.
+func (c *Client) WaitFor(ctx context.Context, cluster *types.Cluster, provider *types.Provider, phase types.Phase, opts ...WaitOption) (*types.ClusterStatus, error) {
+	options := &waitOptions{
+		interval:    defaultPollInterval,
+		maxInterval: defaultMaxPollInterval,
+		multiplier:  defaultBackoffMultiplier,
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	var status *types.ClusterStatus
+	err := c.run(ctx, fmt.Sprintf("wait for %s", phase), cluster, provider, func(p Provisioner) (err error) {
+		cluster, provider := withDefaults(p, cluster, provider)
+		status, err = wait(ctx, p, cluster, provider, phase, options)
+		return err
+	})
+	return status, err
+}
+
+func wait(ctx context.Context, p Provisioner, cluster *types.Cluster, provider *types.Provider, phase types.Phase, options *waitOptions) (*types.ClusterStatus, error) {
+	var last *types.ClusterStatus
+	var previous types.Phase
+	interval := options.interval
+
+	for {
+		status, err := p.Status(ctx, cluster, provider)
+		if err != nil {
+			var notFound *types.NotFoundError
+			if options.notFoundAsDone && errors.As(err, &notFound) {
+				return last, nil
+			}
+			return last, err
+		}
+		last = status
+
+		if status.Phase != previous {
+			if options.onPhaseChange != nil {
+				options.onPhaseChange(previous, status.Phase)
+			}
+			previous = status.Phase
+			interval = options.interval
+		} else {
+			interval = nextInterval(interval, options)
+		}
+
+		if status.Phase == phase {
+			return status, nil
+		}
+		if terminalPhases[status.Phase] {
+			return status, &UnexpectedPhaseError{Phase: status.Phase, Expected: phase}
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return last, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// nextInterval grows the interval by the backoff multiplier, up to the maximum interval.
+func nextInterval(interval time.Duration, options *waitOptions) time.Duration {
+	next := time.Duration(float64(interval) * options.multiplier)
+	if next > options.maxInterval {
+		return options.maxInterval
+	}
+	return next
+}
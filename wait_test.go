@@ -0,0 +1,80 @@
+package hydroform
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kyma-incubator/hydroform/types"
+	"github.com/stretchr/testify/require"
+)
+
+// phasesProvisioner returns the given phases from consecutive Status calls, and a NotFoundError once they run out.
+type phasesProvisioner struct {
+	fakeProvisioner
+	phases []types.Phase
+}
+
+func (p *phasesProvisioner) Status(ctx context.Context, cluster *types.Cluster, provider *types.Provider) (*types.ClusterStatus, error) {
+	if len(p.phases) == 0 {
+		return nil, &types.NotFoundError{Name: cluster.Name}
+	}
+	phase := p.phases[0]
+	p.phases = p.phases[1:]
+	return &types.ClusterStatus{Phase: phase}, nil
+}
+
+func newWaitClient(phases ...types.Phase) *Client {
+	p := &phasesProvisioner{phases: phases}
+	return New(WithProvider(fakeProvider, func(OperatorType) Provisioner { return p }))
+}
+
+func TestWaitFor(t *testing.T) {
+	cluster := &types.Cluster{Name: "hydro-cluster"}
+	provider := &types.Provider{Type: fakeProvider}
+
+	var changes []types.Phase
+	c := newWaitClient(types.Pending, types.Provisioning, types.Provisioning, types.Provisioning, types.Provisioned)
+	status, err := c.WaitFor(context.Background(), cluster, provider, types.Provisioned,
+		PollInterval(time.Millisecond),
+		OnPhaseChange(func(previous, current types.Phase) {
+			changes = append(changes, current)
+		}))
+	require.NoError(t, err, "WaitFor should succeed once the phase is reached")
+	require.Equal(t, types.Provisioned, status.Phase)
+	require.Equal(t, []types.Phase{types.Pending, types.Provisioning, types.Provisioned}, changes, "Every phase change should be reported once")
+
+	c = newWaitClient(types.Provisioning, types.Errored, types.Provisioned)
+	status, err = c.WaitFor(context.Background(), cluster, provider, types.Provisioned, PollInterval(time.Millisecond))
+	var unexpected *UnexpectedPhaseError
+	require.True(t, errors.As(err, &unexpected), "WaitFor should stop on a terminal phase")
+	require.Equal(t, types.Errored, unexpected.Phase)
+	require.Equal(t, types.Errored, status.Phase)
+
+	c = newWaitClient(types.Stopping)
+	_, err = c.WaitFor(context.Background(), cluster, provider, types.Unknown, PollInterval(time.Millisecond))
+	var notFound *types.NotFoundError
+	require.True(t, errors.As(err, &notFound), "A missing cluster should fail the wait by default")
+
+	c = newWaitClient(types.Stopping, types.Stopping)
+	status, err = c.WaitFor(context.Background(), cluster, provider, types.Unknown, PollInterval(time.Millisecond), NotFoundAsDone())
+	require.NoError(t, err, "A missing cluster should end the wait with NotFoundAsDone")
+	require.Equal(t, types.Stopping, status.Phase, "The last observed status should be returned")
+}
+
+func TestWaitForCancelled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	c := newWaitClient(types.Provisioning, types.Provisioning)
+	_, err := c.WaitFor(ctx, &types.Cluster{Name: "hydro-cluster"}, &types.Provider{Type: fakeProvider}, types.Provisioned, PollInterval(time.Hour))
+	require.Equal(t, context.DeadlineExceeded, err)
+}
+
+func TestNextInterval(t *testing.T) {
+	options := &waitOptions{maxInterval: 10 * time.Second, multiplier: 2}
+
+	require.Equal(t, 4*time.Second, nextInterval(2*time.Second, options))
+	require.Equal(t, 10*time.Second, nextInterval(8*time.Second, options), "The interval should not exceed the maximum")
+}